@@ -0,0 +1,28 @@
+// Command fakeclamd runs scanner.FakeClamd for local development, where a
+// real ClamAV daemon and its signature database are overkill. It flags the
+// EICAR test string only.
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/scanner"
+)
+
+func main() {
+	listen := flag.String("listen", "tcp://0.0.0.0:3310", "address to listen on, tcp://host:port or unix:///path")
+	maxStream := flag.Int("max-stream", 25<<20, "largest stream accepted, in bytes")
+	flag.Parse()
+
+	fake, err := scanner.NewFakeClamd(*listen, *maxStream)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("fake clamd listening on %s\n", fake.Address())
+
+	if err := fake.Serve(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	grpc_server "github.com/acyushka/nbf-file-storage-service/internal/presentation"
+	"github.com/acyushka/nbf-file-storage-service/internal/tenancy"
+)
+
+// runGC runs one garbage collection pass over the deployment's own objects
+// and each tenant's, and exits, for
+//
+//	server --config config.yaml gc [-dry-run] [-grace 24h] [-rate 50]
+//
+// Flags default to the gc section of the config.
+func runGC(ctx context.Context, cfg *config.Config, args []string) error {
+	opts := grpc_server.GCOptions(cfg.GC)
+
+	flags := flag.NewFlagSet("gc", flag.ExitOnError)
+	flags.BoolVar(&opts.DryRun, "dry-run", opts.DryRun, "report orphans without deleting them")
+	flags.DurationVar(&opts.GracePeriod, "grace", opts.GracePeriod, "only remove orphans older than this")
+	flags.IntVar(&opts.DeletesPerSecond, "rate", opts.DeletesPerSecond, "deletes per second, 0 for unlimited")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	fileStorageService, tenants, err := grpc_server.NewToolService(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	report, err := fileStorageService.CollectGarbage(ctx, opts)
+	if report != nil {
+		fmt.Fprintln(os.Stdout, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tenants {
+		report, err := t.Service.CollectGarbage(tenancy.WithID(ctx, t.ID), opts)
+		if report != nil {
+			fmt.Fprintf(os.Stdout, "tenant %s: %s\n", t.ID, report)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// runKeyring creates the local-kms keyring at documents.local_kms_path, for
+//
+//	server --config config.yaml keyring
+//
+// An existing keyring is left alone.
+func runKeyring(ctx context.Context, cfg *config.Config, args []string) error {
+	id, err := storage.CreateKeyring(cfg.Documents.LocalKMSPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "created keyring %s with master key %s\n", cfg.Documents.LocalKMSPath, id)
+
+	return nil
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -27,18 +29,54 @@ func main() {
 
 	log.Debug("Logger is working")
 
-	gRPCserver, err := grpc_server.NewGrpcServer(ctx, cfg)
+	// Arguments left after the config flags select a one-shot command.
+	if args := flag.Args(); len(args) > 0 {
+		var run func(context.Context, *config.Config, []string) error
+		switch args[0] {
+		case "gc":
+			run = runGC
+		case "verify":
+			run = runVerify
+		case "keyring":
+			run = runKeyring
+		default:
+			panic(fmt.Errorf("unknown command %q", args[0]))
+		}
+
+		cmdCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		if err := run(cmdCtx, cfg, args[1:]); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	fileStorageService, tenants, err := grpc_server.NewService(ctx, cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	gRPCserver, err := grpc_server.NewGrpcServer(ctx, cfg, fileStorageService, tenants)
 	if err != nil {
 		panic(err)
 	}
 
+	httpServer := grpc_server.NewHttpServer(ctx, cfg, fileStorageService, tenants)
+
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	grpc_server.StartBackgroundJobs(jobsCtx, cfg, fileStorageService, tenants)
+
 	go gRPCserver.MustStart(ctx)
+	go httpServer.MustStart(ctx)
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
+	stopJobs()
 	gRPCserver.MustStop(ctx)
+	httpServer.MustStop(ctx)
 
 	log.Info("Server is gracefully stopped")
 }
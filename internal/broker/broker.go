@@ -0,0 +1,21 @@
+package broker
+
+import "context"
+
+// Message is one event on its way to the broker.
+type Message struct {
+	// ID is unique per event and stays the same across redeliveries, so
+	// consumers can drop duplicates.
+	ID      string
+	Subject string
+	// Key groups related messages, e.g. all events of one user.
+	Key  string
+	Data []byte
+}
+
+// Broker delivers messages to other services. Publish returns only once
+// the broker has accepted the message.
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
@@ -0,0 +1,38 @@
+package broker
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory keeps published messages in memory, for tests and local runs
+// without a broker.
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Publish(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// Messages returns everything published so far, oldest first.
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
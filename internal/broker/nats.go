@@ -0,0 +1,215 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATS publishes over the NATS client protocol. Each publish is followed by
+// a PING, and the PONG that answers it confirms the server has processed
+// the message; an -ERR before it fails the publish.
+//
+// The event ID goes out as the Nats-Msg-Id header, which JetStream uses to
+// drop redeliveries within its duplicate window.
+type NATS struct {
+	address string
+	user    *url.Userinfo
+	timeout time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	headers bool
+}
+
+// NewNATS accepts "nats://[user:pass@|token@]host:port".
+func NewNATS(address string, timeout time.Duration) (*NATS, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nats address: %w", err)
+	}
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("nats address must be nats://, got %q", address)
+	}
+
+	return &NATS{
+		address: u.Host,
+		user:    u.User,
+		timeout: timeout,
+	}, nil
+}
+
+func (n *NATS) Publish(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := n.publish(ctx, msg); err != nil {
+		// The connection state is unknown now; start over next time.
+		n.conn.Close()
+		n.conn = nil
+		return fmt.Errorf("failed to publish %s: %w", msg.Subject, err)
+	}
+
+	return nil
+}
+
+func (n *NATS) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: n.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	n.conn = conn
+	n.reader = bufio.NewReader(conn)
+
+	if err := n.handshake(ctx); err != nil {
+		conn.Close()
+		n.conn = nil
+		return fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	return nil
+}
+
+func (n *NATS) handshake(ctx context.Context) error {
+	if err := n.setDeadline(ctx); err != nil {
+		return err
+	}
+
+	line, err := n.readLine()
+	if err != nil {
+		return err
+	}
+	infoJSON, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		return fmt.Errorf("unexpected greeting %q", line)
+	}
+
+	var info struct {
+		Headers bool `json:"headers"`
+	}
+	if err := json.Unmarshal([]byte(infoJSON), &info); err != nil {
+		return fmt.Errorf("invalid server info: %w", err)
+	}
+	n.headers = info.Headers
+
+	options := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"headers":  info.Headers,
+		"name":     "nbf-file-storage-service",
+		"lang":     "go",
+		"version":  "1",
+		"protocol": 1,
+	}
+	if n.user != nil {
+		if pass, ok := n.user.Password(); ok {
+			options["user"] = n.user.Username()
+			options["pass"] = pass
+		} else {
+			options["auth_token"] = n.user.Username()
+		}
+	}
+
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("failed to encode connect options: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(n.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+
+	return n.awaitPong()
+}
+
+func (n *NATS) publish(ctx context.Context, msg Message) error {
+	if err := n.setDeadline(ctx); err != nil {
+		return err
+	}
+
+	var frame []byte
+	if n.headers && msg.ID != "" {
+		header := "NATS/1.0\r\nNats-Msg-Id: " + msg.ID + "\r\n\r\n"
+		frame = fmt.Appendf(nil, "HPUB %s %d %d\r\n%s", msg.Subject, len(header), len(header)+len(msg.Data), header)
+	} else {
+		frame = fmt.Appendf(nil, "PUB %s %d\r\n", msg.Subject, len(msg.Data))
+	}
+	frame = append(frame, msg.Data...)
+	frame = append(frame, "\r\nPING\r\n"...)
+
+	if _, err := n.conn.Write(frame); err != nil {
+		return err
+	}
+
+	return n.awaitPong()
+}
+
+// awaitPong reads until the PONG answering our PING, answering the
+// server's own PINGs on the way.
+func (n *NATS) awaitPong() error {
+	for {
+		line, err := n.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.Trim(strings.TrimPrefix(line, "-ERR "), "'"))
+		}
+	}
+}
+
+func (n *NATS) readLine() (string, error) {
+	line, err := n.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (n *NATS) setDeadline(ctx context.Context) error {
+	deadline := time.Now().Add(n.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	return n.conn.SetDeadline(deadline)
+}
+
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		return nil
+	}
+
+	err := n.conn.Close()
+	n.conn = nil
+
+	return err
+}
@@ -0,0 +1,14 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache is a byte-oriented key/value store with per-key expiry. Values are
+// opaque so the same callers work against the in-process LRU and Redis.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
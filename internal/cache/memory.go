@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryCache is a size-bounded LRU whose entries also expire by TTL.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(elem)
+
+	return entry.value, true, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = time.Now().Add(ttl)
+		c.order.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryEntry{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	})
+
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+var errNil = errors.New("redis: nil")
+
+// RedisCache speaks just enough RESP (GET, SET PX, DEL) to share cached
+// entries between service replicas.
+type RedisCache struct {
+	addr     string
+	password string
+	prefix   string
+	pool     chan *redisConn
+}
+
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func NewRedisCache(addr string, password string, prefix string, poolSize int) *RedisCache {
+	return &RedisCache{
+		addr:     addr,
+		password: password,
+		prefix:   prefix,
+		pool:     make(chan *redisConn, max(1, poolSize)),
+	}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", c.prefix+key)
+	if errors.Is(err, errNil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+
+	return value, true, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := c.do(ctx, "SET", c.prefix+key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, "DEL", c.prefix+key)
+	return err
+}
+
+func (c *RedisCache) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.conn.SetDeadline(deadline)
+	} else {
+		conn.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	}
+
+	reply, err := conn.command(args...)
+	if err != nil && !errors.Is(err, errNil) {
+		// The connection state is unknown after an I/O or protocol error.
+		conn.conn.Close()
+		return nil, err
+	}
+
+	c.put(conn)
+
+	return reply, err
+}
+
+func (c *RedisCache) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.pool:
+		return conn, nil
+	default:
+	}
+
+	var d net.Dialer
+	netConn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: dial: %w", err)
+	}
+
+	conn := &redisConn{conn: netConn, r: bufio.NewReader(netConn)}
+	if c.password != "" {
+		if _, err := conn.command("AUTH", c.password); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("redis: auth: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (c *RedisCache) put(conn *redisConn) {
+	select {
+	case c.pool <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+func (c *redisConn) command(args ...string) (any, error) {
+	buf := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, arg := range args {
+		buf = fmt.Appendf(buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, fmt.Errorf("redis: write: %w", err)
+	}
+
+	return c.readReply()
+}
+
+func (c *redisConn) readReply() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: read: %w", err)
+	}
+	if len(line) < 3 {
+		return nil, fmt.Errorf("redis: short reply %q", line)
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("redis: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad bulk length: %w", err)
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, fmt.Errorf("redis: read: %w", err)
+		}
+		return data[:n], nil
+	default:
+		return nil, fmt.Errorf("redis: unsupported reply %q", line)
+	}
+}
@@ -1,22 +1,431 @@
 package config
 
+import "time"
+
 type Config struct {
-	Env          string       `yaml:"env" env-default:"dev"`
-	Host         string       `yaml:"host"`
-	Port         int          `yaml:"port"`
-	Minio        Minio        `yaml:"minio"`
-	PresignedUrl PresignedUrl `yaml:"presigned_url"`
+	Env           string        `yaml:"env" env-default:"dev"`
+	Host          string        `yaml:"host"`
+	Port          int           `yaml:"port"`
+	HTTP          HTTP          `yaml:"http"`
+	Minio         Minio         `yaml:"minio"`
+	PresignedUrl  PresignedUrl  `yaml:"presigned_url"`
+	Similarity    Similarity    `yaml:"similarity"`
+	Transcoding   Transcoding   `yaml:"transcoding"`
+	Batch         Batch         `yaml:"batch"`
+	URLCache      URLCache      `yaml:"url_cache"`
+	Share         Share         `yaml:"share"`
+	Images        Images        `yaml:"images"`
+	ImageProxy    ImageProxy    `yaml:"image_proxy"`
+	Documents     Documents     `yaml:"documents"`
+	Scanning      Scanning      `yaml:"scanning"`
+	Moderation    Moderation    `yaml:"moderation"`
+	Outbox        Outbox        `yaml:"outbox"`
+	Notifications Notifications `yaml:"notifications"`
+	Webhooks      Webhooks      `yaml:"webhooks"`
+	GC            GC            `yaml:"gc"`
+	Privacy       Privacy       `yaml:"privacy"`
+	Trash         Trash         `yaml:"trash"`
+	Lifecycle     Lifecycle     `yaml:"lifecycle"`
+	Tenancy       Tenancy       `yaml:"tenancy"`
+	Replication   Replication   `yaml:"replication"`
+	Scrub         Scrub         `yaml:"scrub"`
+	// Callers presenting this token in the x-internal-token metadata key are
+	// trusted to skip existence checks.
+	InternalToken string `yaml:"internal_token" env:"INTERNAL_TOKEN"`
+}
+
+type HTTP struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port" env-default:"60006"`
+	// PublicURL is where clients reach this server; share links, image
+	// proxy and download URLs are minted against it.
+	PublicURL string `yaml:"public_url" env-default:"http://localhost:60006"`
+	// SigningSecret keys the HMAC on image proxy and download URLs; both
+	// endpoints are off when it is empty.
+	SigningSecret  string        `yaml:"signing_secret" env:"HTTP_SIGNING_SECRET"`
+	DownloadExpiry time.Duration `yaml:"download_expiry" env-default:"1h"`
 }
 
 type Minio struct {
-	Endpoint   string `yaml:"endpoint"`
-	PublicURL  string `yaml:"public_url"`
-	AccessKey  string `yaml:"access_key" env:"MINIO_ROOT_USER"`
-	SecretKey  string `yaml:"secret_key" env:"MINIO_ROOT_PASSWORD"`
-	UseSSL     bool   `yaml:"use_ssl" env:"MINIO_USE_SSL"`
-	BucketName string `yaml:"bucket" env:"MINIO_BUCKET_NAME"`
+	Endpoint   string     `yaml:"endpoint"`
+	PublicURL  string     `yaml:"public_url"`
+	AccessKey  string     `yaml:"access_key" env:"MINIO_ROOT_USER"`
+	SecretKey  string     `yaml:"secret_key" env:"MINIO_ROOT_PASSWORD"`
+	UseSSL     bool       `yaml:"use_ssl" env:"MINIO_USE_SSL"`
+	BucketName string     `yaml:"bucket" env:"MINIO_BUCKET_NAME"`
+	Visibility Visibility `yaml:"visibility"`
+	Encryption Encryption `yaml:"encryption"`
+	// Versioning turns on bucket versioning, so deleted and overwritten
+	// objects stay recoverable until lifecycle rules expire them.
+	Versioning bool `yaml:"versioning" env:"MINIO_VERSIONING"`
+	// Buckets take some kinds of object out of the default bucket, each
+	// with a policy, encryption and lifecycle of its own.
+	Buckets []Bucket `yaml:"buckets"`
+}
+
+// Bucket is an extra bucket the given kinds are routed to. Routing a kind
+// does not move what is already stored; copy it over, e.g. with mc mirror,
+// before switching.
+type Bucket struct {
+	Name string `yaml:"name"`
+	// Kinds are "avatars" and "photos" for stored images and their
+	// renditions, and "documents" for private files.
+	Kinds []string `yaml:"kinds"`
+	// PublicRead opens the whole bucket to anonymous reads.
+	PublicRead bool `yaml:"public_read"`
+	// Encryption replaces minio.encryption's mode and KMS key here; the
+	// master keys are shared. Left empty, the default applies.
+	Encryption BucketEncryption `yaml:"encryption"`
+}
+
+type BucketEncryption struct {
+	Mode     string `yaml:"mode"`
+	KMSKeyID string `yaml:"kms_key_id"`
+}
+
+// Visibility controls which uploads are readable without a presigned URL.
+type Visibility struct {
+	PublicAvatars bool `yaml:"public_avatars" env-default:"true"`
+	PublicPhotos  bool `yaml:"public_photos" env-default:"false"`
+	// PublicLegacy keeps uploads from before deduplication, stored under
+	// <user>/photos/, readable the way the whole bucket used to be, so the
+	// avatar URLs handed out for them keep working. Turning it off makes
+	// those URLs fail until they are fetched again through the service.
+	PublicLegacy bool `yaml:"public_legacy" env-default:"true"`
+	// ExtraPublicPaths are additional object key patterns to keep public.
+	ExtraPublicPaths []string `yaml:"extra_public_paths"`
+}
+
+// Encryption selects server-side encryption for stored objects.
+type Encryption struct {
+	// Mode is "none", "sse-s3", "sse-kms" or "sse-c". MinIO only accepts
+	// customer keys over TLS, so sse-c also needs use_ssl.
+	Mode     string `yaml:"mode" env:"MINIO_SSE_MODE" env-default:"none"`
+	KMSKeyID string `yaml:"kms_key_id" env:"MINIO_SSE_KMS_KEY_ID"`
+	// MasterKey is a base64 encoded secret of at least 32 bytes that
+	// per-user SSE-C keys are derived from.
+	MasterKey string `yaml:"master_key" env:"MINIO_SSE_MASTER_KEY"`
+	// PreviousMasterKeys stay readable until rotation has re-encrypted
+	// everything under MasterKey; drop them afterwards.
+	PreviousMasterKeys []string `yaml:"previous_master_keys" env:"MINIO_SSE_PREVIOUS_MASTER_KEYS"`
+	// Rotate runs the re-encryption job in the background on startup.
+	Rotate bool `yaml:"rotate"`
 }
 
 type PresignedUrl struct {
 	ExpiryHours int `yaml:"expiry_hours"`
 }
+
+type Similarity struct {
+	// Max Hamming distance between perceptual hashes for two photos to be
+	// treated as near-duplicates.
+	Threshold int `yaml:"threshold" env-default:"6"`
+}
+
+type Transcoding struct {
+	Enabled bool `yaml:"enabled"`
+	// Formats to produce, in order of preference when negotiating.
+	Formats     []string `yaml:"formats" env-default:"avif,webp"`
+	Quality     int      `yaml:"quality" env-default:"75"`
+	WebPCommand string   `yaml:"webp_command" env-default:"cwebp"`
+	AVIFCommand string   `yaml:"avif_command" env-default:"avifenc"`
+	// Concurrency bounds the uploads transcoded at once, in the
+	// background. Uploads arriving with every slot taken go without
+	// renditions until their content is uploaded again.
+	Concurrency int `yaml:"concurrency" env-default:"2"`
+}
+
+type Batch struct {
+	MaxPhotos   int `yaml:"max_photos" env-default:"100"`
+	Concurrency int `yaml:"concurrency" env-default:"8"`
+}
+
+type URLCache struct {
+	Enabled bool `yaml:"enabled"`
+	// Backend is "memory" or "redis".
+	Backend string        `yaml:"backend" env-default:"memory"`
+	Size    int           `yaml:"size" env-default:"100000"`
+	TTL     time.Duration `yaml:"ttl" env-default:"20h"`
+	// SafetyMargin caps TTL so a cached URL always has at least this long
+	// left before its presigned expiry.
+	SafetyMargin  time.Duration `yaml:"safety_margin" env-default:"1h"`
+	NegativeTTL   time.Duration `yaml:"negative_ttl" env-default:"30s"`
+	RedisAddr     string        `yaml:"redis_addr"`
+	RedisPassword string        `yaml:"redis_password" env:"REDIS_PASSWORD"`
+	RedisPoolSize int           `yaml:"redis_pool_size" env-default:"10"`
+}
+
+type Share struct {
+	// RedirectExpiry bounds how long a URL handed out by a share link stays
+	// valid after the link is revoked.
+	RedirectExpiry time.Duration `yaml:"redirect_expiry" env-default:"5m"`
+}
+
+// Images bounds the images the service decodes, uploads and originals
+// behind the image proxy alike.
+type Images struct {
+	// MaxPixels rejects images whose header declares more pixels, so a
+	// small file cannot unpack into gigabytes of memory.
+	MaxPixels int64 `yaml:"max_pixels" env-default:"50000000"`
+}
+
+type ImageProxy struct {
+	MaxDimension int           `yaml:"max_dimension" env-default:"4096"`
+	Quality      int           `yaml:"quality" env-default:"82"`
+	CacheMaxAge  time.Duration `yaml:"cache_max_age" env-default:"720h"`
+}
+
+// Documents configures envelope encrypted storage for sensitive uploads
+// such as ID documents.
+type Documents struct {
+	Enabled bool `yaml:"enabled"`
+	// KeyProvider is "config", wrapping data keys with MasterKeys, or
+	// "local-kms", a keyring file standing in for an external KMS. Create
+	// the keyring once with the keyring command; startup fails without it.
+	KeyProvider  string `yaml:"key_provider" env-default:"local-kms"`
+	CurrentKeyID string `yaml:"current_key_id"`
+	// MasterKeys maps key IDs to base64 encoded 32-byte keys. Keep retired
+	// keys here for as long as documents wrapped under them exist.
+	MasterKeys   map[string]string `yaml:"master_keys" env:"DOCUMENTS_MASTER_KEYS"`
+	LocalKMSPath string            `yaml:"local_kms_path" env-default:"/var/lib/file-storage/keyring.json"`
+	ChunkSize    int               `yaml:"chunk_size" env-default:"65536"`
+}
+
+// Scanning sends uploads through a clamd-protocol malware scanner.
+type Scanning struct {
+	Enabled bool `yaml:"enabled"`
+	// Address is "tcp://host:port" or "unix:///path/to/clamd.sock".
+	Address string `yaml:"address" env:"CLAMD_ADDRESS" env-default:"tcp://clamav:3310"`
+	// Mode "sync" scans before storing and rejects infected uploads.
+	// "async" stores first and scans in the background; photos cannot be
+	// served until they come back clean. Avatars, which are public once
+	// stored, and documents are always scanned inline.
+	Mode      string        `yaml:"mode" env-default:"sync"`
+	Timeout   time.Duration `yaml:"timeout" env-default:"30s"`
+	ChunkSize int           `yaml:"chunk_size" env-default:"65536"`
+	// Concurrency bounds background scans in async mode. Uploads arriving
+	// with every slot taken wait for the next pending pass, which runs
+	// every PendingInterval.
+	Concurrency     int           `yaml:"concurrency" env-default:"4"`
+	PendingInterval time.Duration `yaml:"pending_interval" env-default:"1m"`
+	// FailOpen accepts uploads while the scanner is unreachable instead
+	// of rejecting them.
+	FailOpen bool `yaml:"fail_open"`
+}
+
+// Moderation holds new photos back from share links, public pages and the
+// image proxy until they are approved. Owners can always fetch their own.
+type Moderation struct {
+	Enabled bool `yaml:"enabled"`
+	// Classifier is "none", leaving every photo to human review, or
+	// "http", which posts uploads to ClassifierURL for a first verdict.
+	Classifier        string        `yaml:"classifier" env-default:"none"`
+	ClassifierURL     string        `yaml:"classifier_url"`
+	ClassifierTimeout time.Duration `yaml:"classifier_timeout" env-default:"10s"`
+	Concurrency       int           `yaml:"concurrency" env-default:"4"`
+	// RejectedRetention is how long a rejected photo can still be restored
+	// before it is purged.
+	RejectedRetention time.Duration `yaml:"rejected_retention" env-default:"72h"`
+	PurgeInterval     time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Outbox publishes upload and delete events to a broker. Events are queued
+// in the bucket before the change they describe and relayed from there, so
+// a crash or broker outage delays them but never loses them.
+type Outbox struct {
+	Enabled bool `yaml:"enabled"`
+	// Broker is "nats", "kafka" or "memory", which only keeps events in
+	// process and is meant for tests. Kafka is only reached through a
+	// Confluent REST proxy (its v2 API) at KafkaRESTURL; brokers cannot be
+	// addressed directly.
+	Broker         string        `yaml:"broker" env-default:"memory"`
+	NATSURL        string        `yaml:"nats_url"`
+	KafkaRESTURL   string        `yaml:"kafka_rest_url"`
+	PublishTimeout time.Duration `yaml:"publish_timeout" env-default:"10s"`
+	SubjectPrefix  string        `yaml:"subject_prefix" env-default:"file_storage."`
+	PollInterval   time.Duration `yaml:"poll_interval" env-default:"5s"`
+	BatchSize      int           `yaml:"batch_size" env-default:"100"`
+	// CommitTimeout is how long a queued event waits for the change it
+	// describes before it is dropped as belonging to a failed request.
+	CommitTimeout time.Duration `yaml:"commit_timeout" env-default:"1m"`
+}
+
+// Notifications keeps the catalog in step with changes made to the bucket
+// around the service: console deletes, lifecycle expiry and direct uploads.
+type Notifications struct {
+	Enabled bool `yaml:"enabled"`
+	// Source is "listen" (MinIO's ListenBucketNotification API, which
+	// drops events while disconnected), "webhook" (a MinIO webhook target
+	// posting to /minio/events on the HTTP server with WebhookToken as its
+	// auth_token) or "replay" (captured webhook bodies read from
+	// ReplayFile, for development).
+	Source       string        `yaml:"source" env-default:"listen"`
+	WebhookToken string        `yaml:"webhook_token" env:"NOTIFICATIONS_WEBHOOK_TOKEN"`
+	ReplayFile   string        `yaml:"replay_file"`
+	RetryDelay   time.Duration `yaml:"retry_delay" env-default:"5s"`
+	// DirectUploads lets clients upload photos straight to the bucket
+	// through CreateUploadURL. Not available with SSE-C.
+	DirectUploads       bool          `yaml:"direct_uploads"`
+	DirectUploadExpiry  time.Duration `yaml:"direct_upload_expiry" env-default:"15m"`
+	DirectUploadMaxSize int64         `yaml:"direct_upload_max_size" env-default:"20971520"`
+	// Direct uploads whose notification was lost are picked up every
+	// IngestInterval, once they are that old.
+	IngestInterval time.Duration `yaml:"ingest_interval" env-default:"10m"`
+}
+
+// Webhooks delivers upload, delete and moderation events to partner
+// endpoints registered through the admin RPCs. Events reach them through
+// the outbox, whose poll_interval, batch_size and commit_timeout apply
+// even when the outbox itself is disabled.
+type Webhooks struct {
+	Enabled bool `yaml:"enabled"`
+	// Workers bounds the deliveries in flight at once.
+	Workers      int           `yaml:"workers" env-default:"4"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+	// A delivery is retried with exponential backoff between
+	// InitialBackoff and MaxBackoff, and dead-lettered after MaxAttempts.
+	MaxAttempts    int           `yaml:"max_attempts" env-default:"10"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env-default:"30s"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env-default:"6h"`
+}
+
+// GC removes objects nothing in the catalog points at any more, left behind
+// by failed uploads, crashes and abandoned direct uploads. The same pass
+// runs one-shot with the "gc" subcommand.
+type GC struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval" env-default:"24h"`
+	// GracePeriod protects objects written by requests still in flight;
+	// only orphans older than this are removed.
+	GracePeriod time.Duration `yaml:"grace_period" env-default:"24h"`
+	// DryRun reports orphans without deleting them.
+	DryRun bool `yaml:"dry_run"`
+	// DeletesPerSecond caps the delete rate; 0 means unlimited.
+	DeletesPerSecond int `yaml:"deletes_per_second" env-default:"50"`
+}
+
+// Privacy covers the user data erasure and export RPCs.
+type Privacy struct {
+	// ReceiptSecret signs erasure receipts; erasure is refused without
+	// one.
+	ReceiptSecret string `yaml:"receipt_secret" env:"PRIVACY_RECEIPT_SECRET"`
+	// ExportExpiry is how long an export's URL stays valid. The archive
+	// itself is left for the garbage collector once it has expired.
+	ExportExpiry time.Duration `yaml:"export_expiry" env-default:"24h"`
+}
+
+// Trash makes DeletePhoto recoverable: deleted photos are kept, out of the
+// catalog, until Retention passes, and RestorePhoto puts them back.
+// Turning it off stops the purge too, leaving what is already in the
+// trash in place.
+type Trash struct {
+	Enabled       bool          `yaml:"enabled"`
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Lifecycle expires and tiers objects by key prefix. The rules are written
+// to the bucket lifecycle at startup, replacing whatever has drifted from
+// them.
+type Lifecycle struct {
+	Enabled bool            `yaml:"enabled"`
+	Rules   []LifecycleRule `yaml:"rules"`
+	// Fallback is "auto" to expire objects in-process when the backend has
+	// no bucket lifecycle, "always" to do so alongside it, e.g. to honour
+	// ages shorter than the whole days bucket lifecycle counts in, or
+	// "never". Transitions need the backend and are never done in-process.
+	Fallback         string        `yaml:"fallback" env-default:"auto"`
+	FallbackInterval time.Duration `yaml:"fallback_interval" env-default:"1h"`
+}
+
+// LifecycleRule applies to the objects under Prefix, or with Kind set, to
+// the stored blobs of that photo kind. Zero durations leave an action out.
+type LifecycleRule struct {
+	// ID names the rule on the bucket; it defaults to the prefix.
+	ID     string `yaml:"id"`
+	Prefix string `yaml:"prefix"`
+	Kind   string `yaml:"kind"`
+	// ExpireAfter deletes objects this long after they were written. It is
+	// refused on rules that cover stored blobs, kind rules included: blobs
+	// are shared between photos, and go when the last photo using one is
+	// deleted.
+	ExpireAfter time.Duration `yaml:"expire_after"`
+	// NoncurrentExpireAfter removes old versions this long after they were
+	// replaced, when versioning is on.
+	NoncurrentExpireAfter time.Duration `yaml:"noncurrent_expire_after"`
+	// TransitionAfter moves objects to StorageClass, a tier set up on the
+	// MinIO side.
+	TransitionAfter time.Duration `yaml:"transition_after"`
+	StorageClass    string        `yaml:"storage_class"`
+}
+
+// Tenancy serves other products from this deployment, each in a namespace
+// of its own. Requests that name no tenant are served as before.
+type Tenancy struct {
+	Enabled bool `yaml:"enabled"`
+	// Source is "metadata", naming the tenant in the x-tenant-id metadata
+	// key with its token in x-tenant-token, or "claims", taking it from the
+	// Claim of an HS256 bearer token signed with ClaimsSecret.
+	Source       string `yaml:"source" env-default:"metadata"`
+	ClaimsSecret string `yaml:"claims_secret" env:"TENANCY_CLAIMS_SECRET"`
+	Claim        string `yaml:"claim" env-default:"tenant"`
+	// RequireTenant refuses requests that name no tenant.
+	RequireTenant bool     `yaml:"require_tenant"`
+	Tenants       []Tenant `yaml:"tenants"`
+}
+
+// Tenant is a product served from this deployment. Limits left at zero
+// fall back to the deployment's own.
+type Tenant struct {
+	// ID is lowercase letters, digits and dashes.
+	ID    string `yaml:"id"`
+	Token string `yaml:"token"`
+	// Bucket gives the tenant a bucket of its own; without one its objects
+	// live under tenants/{id}/ in the default bucket.
+	Bucket string `yaml:"bucket"`
+	// PublicURL is where the tenant's clients reach the HTTP server. Its
+	// path, or its host when it has none, selects the tenant there.
+	PublicURL            string `yaml:"public_url"`
+	PresignedExpiryHours int    `yaml:"presigned_expiry_hours"`
+	MaxBatchPhotos       int    `yaml:"max_batch_photos"`
+	// MaxUploadSize caps each uploaded file in bytes.
+	MaxUploadSize int64 `yaml:"max_upload_size"`
+}
+
+// Replication copies every object written or deleted through the service
+// to a secondary backend for disaster recovery. Copies are made in the
+// background from a queue kept in the bucket, so the replica lags behind.
+type Replication struct {
+	Enabled bool `yaml:"enabled"`
+	// Target is "s3", any S3-compatible endpoint, where each bucket is
+	// mirrored to one of the same name, or "fs", a directory. Copies on s3
+	// are encrypted like the primary's, so sse-s3 and sse-kms need the
+	// same setup there; fs keeps plaintext and refuses encrypted buckets.
+	Target    string `yaml:"target" env-default:"s3"`
+	Endpoint  string `yaml:"endpoint"`
+	AccessKey string `yaml:"access_key" env:"REPLICA_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"REPLICA_SECRET_KEY"`
+	UseSSL    bool   `yaml:"use_ssl"`
+	Directory string `yaml:"directory"`
+	// Failover serves reads from the replica while the primary fails its
+	// health checks. Photo URLs then point at the download endpoint, so it
+	// needs http.signing_secret.
+	Failover            bool          `yaml:"failover"`
+	HealthCheckInterval time.Duration `yaml:"health_check_interval" env-default:"5s"`
+	PollInterval        time.Duration `yaml:"poll_interval" env-default:"5s"`
+	BatchSize           int           `yaml:"batch_size" env-default:"100"`
+	// Failed copies are retried with exponential backoff from
+	// InitialBackoff up to MaxBackoff.
+	InitialBackoff time.Duration `yaml:"initial_backoff" env-default:"30s"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env-default:"1h"`
+}
+
+// Scrub re-reads stored photos and documents in the background and flags
+// those that no longer match their checksums under _meta/corrupt/.
+type Scrub struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval" env-default:"168h"`
+	// ObjectsPerSecond caps the read rate; 0 means unlimited.
+	ObjectsPerSecond int `yaml:"objects_per_second" env-default:"20"`
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event is something that happened to a user's files, published for other
+// services to react to.
+type Event struct {
+	Type string
+	// Key orders events: consumers see events with the same key in the
+	// order they were published.
+	Key        string
+	Payload    any
+	OccurredAt time.Time
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Bus fans events out to in-process subscribers, synchronously and in
+// publish order.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []func(context.Context, Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handler func(context.Context, Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, handler)
+}
+
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, handler := range b.subscribers {
+		handler(ctx, event)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	FitCover   = "cover"
+	FitContain = "contain"
+	FitFill    = "fill"
+)
+
+func ValidFit(fit string) bool {
+	switch fit {
+	case FitCover, FitContain, FitFill:
+		return true
+	}
+	return false
+}
+
+// Fit scales img into a w*h box. A zero dimension is derived from the other
+// one and the aspect ratio; both zero keeps the original size.
+//
+//   - cover scales to fill the box and crops the overflow around the centre
+//   - contain scales to fit inside the box without ever upscaling
+//   - fill stretches to exactly w*h
+func Fit(img image.Image, w, h int, fit string) image.Image {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+
+	switch {
+	case w == 0 && h == 0:
+		return img
+	case w == 0:
+		w = max(1, sw*h/sh)
+	case h == 0:
+		h = max(1, sh*w/sw)
+	}
+
+	switch fit {
+	case FitFill:
+		return Resize(img, w, h)
+	case FitContain:
+		scale := min(float64(w)/float64(sw), float64(h)/float64(sh), 1)
+		return Resize(img, max(1, int(float64(sw)*scale)), max(1, int(float64(sh)*scale)))
+	default:
+		scale := max(float64(w)/float64(sw), float64(h)/float64(sh))
+		rw, rh := max(w, int(float64(sw)*scale)), max(h, int(float64(sh)*scale))
+		resized := Resize(img, rw, rh)
+		x0, y0 := (rw-w)/2, (rh-h)/2
+		return resized.SubImage(image.Rect(x0, y0, x0+w, y0+h))
+	}
+}
+
+// Encode writes img in the given format. WebP and AVIF go through the
+// transcoder, fed with a lossless PNG intermediate.
+func Encode(ctx context.Context, img image.Image, format string, quality int, transcoder Transcoder) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case "jpeg":
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+	case "png":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode png: %w", err)
+		}
+	case FormatWebP, FormatAVIF:
+		if transcoder == nil {
+			return nil, fmt.Errorf("format %q requires transcoding to be enabled", format)
+		}
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode png: %w", err)
+		}
+		return transcoder.Transcode(ctx, buf.Bytes(), format)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	return buf.Bytes(), nil
+}
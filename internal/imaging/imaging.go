@@ -0,0 +1,36 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+var ErrTooManyPixels = errors.New("image has too many pixels")
+
+// Decode parses an image in any of the registered formats and returns it
+// together with the format name reported by the decoder. Images over
+// maxPixels are refused from their header, before any pixel memory is
+// allocated; zero leaves the size unchecked.
+func Decode(data []byte, maxPixels int64) (image.Image, string, error) {
+	if maxPixels > 0 {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decode image: %w", err)
+		}
+		if pixels := int64(cfg.Width) * int64(cfg.Height); pixels > maxPixels {
+			return nil, "", fmt.Errorf("%w: %dx%d is over %d", ErrTooManyPixels, cfg.Width, cfg.Height, maxPixels)
+		}
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return img, format, nil
+}
@@ -0,0 +1,70 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+)
+
+// DHash computes a 64-bit difference hash: the image is reduced to a 9x8
+// grayscale grid and each bit records whether a pixel is brighter than its
+// right-hand neighbour. Re-encoded or resized copies of the same shot land
+// within a few bits of each other.
+func DHash(img image.Image) uint64 {
+	const w, h = 9, 8
+	gray := grayGrid(img, w, h)
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if gray[y*w+x] > gray[y*w+x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+func ParseHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// Distance is the number of differing bits between two hashes.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// grayGrid box-averages img down to a w*h grid of luminance values. Large
+// images are sampled on a sparse lattice, which is plenty for a 64-bit hash.
+func grayGrid(img image.Image, w, h int) []float64 {
+	bounds := img.Bounds()
+	grid := make([]float64, w*h)
+	counts := make([]int, w*h)
+	stepX := max(1, bounds.Dx()/(w*32))
+	stepY := max(1, bounds.Dy()/(h*32))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		gy := (y - bounds.Min.Y) * h / bounds.Dy()
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			gx := (x - bounds.Min.X) * w / bounds.Dx()
+			r, g, b, _ := img.At(x, y).RGBA()
+			grid[gy*w+gx] += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			counts[gy*w+gx]++
+		}
+	}
+
+	for i := range grid {
+		if counts[i] > 0 {
+			grid[i] /= float64(counts[i])
+		}
+	}
+
+	return grid
+}
@@ -0,0 +1,148 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+)
+
+const (
+	blurHashX      = 4
+	blurHashY      = 3
+	thumbnailSize  = 32
+	base83Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+)
+
+func NewPlaceholder(img image.Image) *models.Placeholder {
+	bounds := img.Bounds()
+	thumb := Resize(img, thumbnailSize, thumbnailSize)
+
+	return &models.Placeholder{
+		BlurHash:      blurHash(thumb, blurHashX, blurHashY),
+		DominantColor: dominantColor(thumb),
+		Width:         bounds.Dx(),
+		Height:        bounds.Dy(),
+	}
+}
+
+// blurHash implements the encoder from https://blurha.sh over a small
+// thumbnail; the hash only carries a handful of cosine components, so
+// encoding the full-size image would buy nothing.
+func blurHash(img *image.RGBA, xComponents, yComponents int) string {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	factors := make([][3]float64, 0, xComponents*yComponents)
+
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1.0
+			}
+
+			var r, g, b float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := math.Cos(math.Pi*float64(i*x)/float64(w)) * math.Cos(math.Pi*float64(j*y)/float64(h))
+					px := img.Pix[img.PixOffset(x, y):]
+					r += basis * srgbToLinear(px[0])
+					g += basis * srgbToLinear(px[1])
+					b += basis * srgbToLinear(px[2])
+				}
+			}
+
+			scale := normalisation / float64(w*h)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encode83((xComponents-1)+(yComponents-1)*9, 1))
+
+	maximumValue := 1.0
+	if len(factors) > 1 {
+		actualMax := 0.0
+		for _, f := range factors[1:] {
+			actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maximumValue = float64(quantisedMax+1) / 166
+		hash.WriteString(encode83(quantisedMax, 1))
+	} else {
+		hash.WriteString(encode83(0, 1))
+	}
+
+	dc := factors[0]
+	hash.WriteString(encode83(int(linearToSRGB(dc[0]))<<16|int(linearToSRGB(dc[1]))<<8|int(linearToSRGB(dc[2])), 4))
+
+	for _, f := range factors[1:] {
+		quant := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encode83(quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2))
+	}
+
+	return hash.String()
+}
+
+// dominantColor buckets pixels into a coarse 8x8x8 colour cube and returns
+// the mean colour of the most populated bucket as #rrggbb.
+func dominantColor(img *image.RGBA) string {
+	type bucket struct{ r, g, b, n int }
+	var buckets [512]bucket
+
+	best := 0
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		r, g, b, a := int(img.Pix[i]), int(img.Pix[i+1]), int(img.Pix[i+2]), img.Pix[i+3]
+		if a < 128 {
+			continue
+		}
+
+		idx := (r>>5)<<6 | (g>>5)<<3 | b>>5
+		buckets[idx].r += r
+		buckets[idx].g += g
+		buckets[idx].b += b
+		buckets[idx].n++
+		if buckets[idx].n > buckets[best].n {
+			best = idx
+		}
+	}
+
+	top := buckets[best]
+	if top.n == 0 {
+		return "#000000"
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", top.r/top.n, top.g/top.n, top.b/top.n)
+}
+
+func encode83(value, length int) string {
+	var b strings.Builder
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		b.WriteByte(base83Alphabet[digit])
+	}
+	return b.String()
+}
+
+func srgbToLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) float64 {
+	c := math.Max(0, math.Min(1, v))
+	if c <= 0.0031308 {
+		return math.Trunc(c*12.92*255 + 0.5)
+	}
+	return math.Trunc((1.055*math.Pow(c, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
@@ -0,0 +1,59 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// ToRGBA returns img as an *image.RGBA anchored at the origin, copying only
+// when needed.
+func ToRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return rgba
+}
+
+// Resize scales img to exactly w*h pixels. Each destination pixel averages
+// the source pixels its footprint covers, which keeps downscaled output
+// free of aliasing; upscaling degrades to nearest-neighbour.
+func Resize(img image.Image, w, h int) *image.RGBA {
+	src := ToRGBA(img)
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		y0 := y * sh / h
+		y1 := max(y0+1, (y+1)*sh/h)
+		for x := 0; x < w; x++ {
+			x0 := x * sw / w
+			x1 := max(x0+1, (x+1)*sw/w)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					px := row[sx*4 : sx*4+4]
+					r += uint32(px[0])
+					g += uint32(px[1])
+					b += uint32(px[2])
+					a += uint32(px[3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,99 @@
+package imaging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatWebP = "webp"
+	FormatAVIF = "avif"
+)
+
+var contentTypes = map[string]string{
+	"jpeg":     "image/jpeg",
+	"png":      "image/png",
+	"gif":      "image/gif",
+	FormatWebP: "image/webp",
+	FormatAVIF: "image/avif",
+}
+
+// ContentType returns the MIME type for a format name.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// NormalizeFormat accepts either a bare format name or a MIME type, as sent
+// in an Accept header, and returns the bare lower-case format name.
+func NormalizeFormat(format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+	format = strings.TrimPrefix(format, "image/")
+	if format == "jpg" {
+		return "jpeg"
+	}
+	return format
+}
+
+type Transcoder interface {
+	Transcode(ctx context.Context, data []byte, format string) ([]byte, error)
+}
+
+// CommandTranscoder shells out to the reference encoders (cwebp, avifenc),
+// which have no pure-Go equivalent.
+type CommandTranscoder struct {
+	commands map[string]string
+	quality  int
+}
+
+func NewCommandTranscoder(webpCommand string, avifCommand string, quality int) *CommandTranscoder {
+	return &CommandTranscoder{
+		commands: map[string]string{
+			FormatWebP: webpCommand,
+			FormatAVIF: avifCommand,
+		},
+		quality: quality,
+	}
+}
+
+func (t *CommandTranscoder) Transcode(ctx context.Context, data []byte, format string) ([]byte, error) {
+	command, ok := t.commands[format]
+	if !ok || command == "" {
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	dir, err := os.MkdirTemp("", "transcode-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in")
+	out := filepath.Join(dir, "out."+format)
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write input: %w", err)
+	}
+
+	var args []string
+	switch format {
+	case FormatWebP:
+		args = []string{"-quiet", "-q", strconv.Itoa(t.quality), in, "-o", out}
+	case FormatAVIF:
+		args = []string{"-q", strconv.Itoa(t.quality), in, out}
+	}
+
+	if output, err := exec.CommandContext(ctx, command, args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", command, err, strings.TrimSpace(string(output)))
+	}
+
+	encoded, err := os.ReadFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output: %w", err)
+	}
+
+	return encoded, nil
+}
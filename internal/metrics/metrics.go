@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+)
+
+var (
+	UploadedBytes   = expvar.NewInt("uploaded_bytes_total")
+	DedupHits       = expvar.NewInt("dedup_hits_total")
+	DedupBytesSaved = expvar.NewInt("dedup_bytes_saved_total")
+	// Gauge, measured by every garbage collection pass.
+	StoredBytes = expvar.NewInt("stored_blob_bytes")
+
+	TranscodeFailures  = expvar.NewInt("transcode_failures_total")
+	TranscodesDeferred = expvar.NewInt("transcodes_deferred_total")
+
+	URLCacheHits   = expvar.NewInt("url_cache_hits_total")
+	URLCacheMisses = expvar.NewInt("url_cache_misses_total")
+
+	ReencryptedObjects = expvar.NewInt("reencrypted_objects_total")
+	ReencryptFailures  = expvar.NewInt("reencrypt_failures_total")
+
+	ScannedUploads  = expvar.NewInt("scanned_uploads_total")
+	InfectedUploads = expvar.NewInt("infected_uploads_total")
+	ScanFailures    = expvar.NewInt("scan_failures_total")
+	ScansDeferred   = expvar.NewInt("scans_deferred_total")
+
+	ModerationTransitions = expvar.NewInt("moderation_transitions_total")
+	ClassifierFailures    = expvar.NewInt("classifier_failures_total")
+	PurgedRejected        = expvar.NewInt("purged_rejected_photos_total")
+	PurgedTrash           = expvar.NewInt("purged_trash_photos_total")
+
+	OutboxPublished = expvar.NewInt("outbox_published_total")
+	OutboxFailures  = expvar.NewInt("outbox_publish_failures_total")
+	OutboxDropped   = expvar.NewInt("outbox_dropped_total")
+
+	BucketEvents          = expvar.NewInt("bucket_events_total")
+	BucketEventFailures   = expvar.NewInt("bucket_event_failures_total")
+	DirectUploads         = expvar.NewInt("direct_uploads_total")
+	RejectedDirectUploads = expvar.NewInt("rejected_direct_uploads_total")
+
+	WebhookDeliveries      = expvar.NewInt("webhook_deliveries_total")
+	WebhookFailures        = expvar.NewInt("webhook_failures_total")
+	WebhookDeadLetters     = expvar.NewInt("webhook_dead_letters_total")
+	WebhookEnqueueFailures = expvar.NewInt("webhook_enqueue_failures_total")
+
+	GCOrphans        = expvar.NewInt("gc_orphans_total")
+	GCDeleted        = expvar.NewInt("gc_deleted_objects_total")
+	GCReclaimedBytes = expvar.NewInt("gc_reclaimed_bytes_total")
+
+	ErasedItems       = expvar.NewInt("erased_items_total")
+	CompletedErasures = expvar.NewInt("completed_erasures_total")
+	UserExports       = expvar.NewInt("user_exports_total")
+
+	LifecycleExpired = expvar.NewInt("lifecycle_expired_objects_total")
+
+	ReplicatedObjects          = expvar.NewInt("replicated_objects_total")
+	ReplicationFailures        = expvar.NewInt("replication_failures_total")
+	ReplicationEnqueueFailures = expvar.NewInt("replication_enqueue_failures_total")
+	ReplicaFailoverReads       = expvar.NewInt("replica_failover_reads_total")
+	// Gauges, set after every replication pass.
+	ReplicationPending    = expvar.NewInt("replication_pending_objects")
+	ReplicationLagSeconds = expvar.NewInt("replication_lag_seconds")
+
+	ChecksumMismatches = expvar.NewInt("upload_checksum_mismatches_total")
+	ScrubbedObjects    = expvar.NewInt("scrubbed_objects_total")
+	CorruptObjects     = expvar.NewInt("corrupt_objects_total")
+)
+
+func Handler() http.Handler {
+	return expvar.Handler()
+}
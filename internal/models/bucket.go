@@ -0,0 +1,37 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// BucketEvent is a change to one object in the bucket, as MinIO reports it.
+type BucketEvent struct {
+	// Name is the S3 event name, e.g. "s3:ObjectCreated:Put".
+	Name string
+	// Bucket is the bucket the object is in.
+	Bucket      string
+	Key         string
+	Size        int64
+	ContentType string
+	// Principal is the access key the change was made with.
+	Principal  string
+	OccurredAt time.Time
+}
+
+func (e BucketEvent) Created() bool {
+	return strings.HasPrefix(e.Name, "s3:ObjectCreated:")
+}
+
+// Removed covers deletes as well as lifecycle expiry.
+func (e BucketEvent) Removed() bool {
+	return strings.HasPrefix(e.Name, "s3:ObjectRemoved:") || strings.HasPrefix(e.Name, "s3:LifecycleExpiration:")
+}
+
+// DirectUpload is a presigned URL for uploading a photo straight to the
+// bucket, under an ID reserved for it.
+type DirectUpload struct {
+	PhotoID   string
+	URL       string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"io"
+	"time"
+)
+
+// Download is an object ready to be streamed to an HTTP client.
+type Download struct {
+	Content     io.ReadSeekCloser
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+	Size        int64
+	// ExpiresAt is set when the link serving the download stops working.
+	ExpiresAt time.Time
+	// Public is set when anyone may see the content, so shared caches may
+	// keep it.
+	Public bool
+}
+
+// ImageOptions describes an on-the-fly rendition requested from the image
+// proxy.
+type ImageOptions struct {
+	Width  int
+	Height int
+	Fit    string
+	Format string
+}
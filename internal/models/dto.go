@@ -7,4 +7,12 @@ type PhotoData struct {
 	FileSize    int64
 	FileName    string
 	ContentType string
+	// Checksums are the ones the client sent along, if any.
+	Checksums Checksums
+}
+
+// Checksums of an upload as lowercase hex. Empty ones are unknown.
+type Checksums struct {
+	SHA256 string
+	CRC32C string
 }
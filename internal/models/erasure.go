@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+type ErasureStatus string
+
+const (
+	ErasureRunning   ErasureStatus = "running"
+	ErasureCompleted ErasureStatus = "completed"
+	ErasureFailed    ErasureStatus = "failed"
+)
+
+// Erasure tracks the removal of everything stored for one user. It is
+// saved as it goes, so progress survives restarts and an interrupted
+// erasure picks up where it stopped.
+type Erasure struct {
+	ID     string        `json:"id"`
+	UserID string        `json:"user_id"`
+	Status ErasureStatus `json:"status"`
+	// Stage names the kind of data being removed, e.g. "photos".
+	Stage string `json:"stage,omitempty"`
+	// Deleted counts the photos, documents and other items removed so
+	// far.
+	Deleted int    `json:"deleted"`
+	Error   string `json:"error,omitempty"`
+	// Blobs lists the blobs the user's photos pointed at, so that on a
+	// versioned bucket their old versions can be purged once no one else
+	// refers to them.
+	Blobs     []string        `json:"blobs,omitempty"`
+	StartedAt time.Time       `json:"started_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Receipt   *ErasureReceipt `json:"receipt,omitempty"`
+}
+
+// ErasureReceipt attests that an erasure completed. Signature is the hex
+// HMAC-SHA256, under the receipt secret, of
+// "erasure_id|user_id|deleted|started_at|completed_at" with both times in
+// Unix seconds.
+type ErasureReceipt struct {
+	ErasureID   string    `json:"erasure_id"`
+	UserID      string    `json:"user_id"`
+	Deleted     int       `json:"deleted"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Signature   string    `json:"signature"`
+}
+
+// UserDataExport is a ZIP archive of everything stored for a user.
+type UserDataExport struct {
+	URL       string
+	Size      int64
+	ExpiresAt time.Time
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// GCOptions tunes one garbage collection pass.
+type GCOptions struct {
+	// GracePeriod protects objects younger than it, which may belong to a
+	// request still in flight.
+	GracePeriod time.Duration
+	// DryRun reports orphans without deleting them.
+	DryRun bool
+	// DeletesPerSecond caps the delete rate; 0 means unlimited.
+	DeletesPerSecond int
+}
+
+// GCReport summarizes one garbage collection pass. In a dry run,
+// ReclaimedBytes is what deleting the orphans would have freed.
+type GCReport struct {
+	DryRun         bool
+	Scanned        int
+	Orphans        int
+	Deleted        int
+	Failed         int
+	ReclaimedBytes int64
+}
+
+func (r GCReport) String() string {
+	if r.DryRun {
+		return fmt.Sprintf("dry run: scanned %d, orphans %d, would reclaim %d bytes", r.Scanned, r.Orphans, r.ReclaimedBytes)
+	}
+
+	return fmt.Sprintf("scanned %d, orphans %d, deleted %d, failed %d, reclaimed %d bytes", r.Scanned, r.Orphans, r.Deleted, r.Failed, r.ReclaimedBytes)
+}
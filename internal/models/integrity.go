@@ -0,0 +1,29 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// CorruptObject flags a stored object whose content no longer matches the
+// checksum it was stored with.
+type CorruptObject struct {
+	Key string `json:"key"`
+	// Expected and Actual are lowercase hex SHA-256; Actual is empty when
+	// the object could not be decrypted at all.
+	Expected   string    `json:"expected"`
+	Actual     string    `json:"actual,omitempty"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// ScrubReport summarizes one scrub pass.
+type ScrubReport struct {
+	Checked int
+	// Skipped objects were stored without a checksum to compare with.
+	Skipped int
+	Corrupt int
+}
+
+func (r ScrubReport) String() string {
+	return fmt.Sprintf("checked %d, corrupt %d, skipped %d without a checksum", r.Checked, r.Corrupt, r.Skipped)
+}
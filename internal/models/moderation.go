@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ModerationState is where a photo stands in review. Photos stored before
+// moderation existed have none and count as approved.
+type ModerationState string
+
+const (
+	ModerationPending  ModerationState = "pending"
+	ModerationApproved ModerationState = "approved"
+	ModerationRejected ModerationState = "rejected"
+)
+
+// PendingPhoto is a photo waiting for a reviewer.
+type PendingPhoto struct {
+	UserID      string
+	PhotoID     string
+	URL         string
+	ContentType string
+	CreatedAt   time.Time
+}
+
+// ModerationChanged is the payload of a moderation state transition event.
+type ModerationChanged struct {
+	UserID  string          `json:"user_id"`
+	PhotoID string          `json:"photo_id"`
+	From    ModerationState `json:"from"`
+	To      ModerationState `json:"to"`
+	Reason  string          `json:"reason,omitempty"`
+	// Actor is "classifier" or "reviewer".
+	Actor string `json:"actor"`
+}
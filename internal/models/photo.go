@@ -0,0 +1,112 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+type PhotoKind string
+
+const (
+	KindAvatar PhotoKind = "avatars"
+	KindPhoto  PhotoKind = "photos"
+)
+
+// PhotoMeta is the catalog record that maps a user's photo_id onto the
+// content-addressed blob holding its bytes.
+type PhotoMeta struct {
+	PhotoID     string       `json:"photo_id"`
+	UserID      string       `json:"user_id"`
+	Kind        PhotoKind    `json:"kind"`
+	BlobKey     string       `json:"blob_key"`
+	SHA256      string       `json:"sha256"`
+	CRC32C      string       `json:"crc32c,omitempty"`
+	FileSize    int64        `json:"file_size"`
+	ContentType string       `json:"content_type"`
+	DHash       string       `json:"dhash,omitempty"`
+	Placeholder *Placeholder `json:"placeholder,omitempty"`
+	// Renditions maps a format name onto the object holding that encoding.
+	Renditions map[string]string `json:"renditions,omitempty"`
+	Visibility Visibility        `json:"visibility,omitempty"`
+	ScanStatus ScanStatus        `json:"scan_status,omitempty"`
+	Moderation ModerationState   `json:"moderation,omitempty"`
+	// ModerationReason explains the latest moderation decision.
+	ModerationReason string `json:"moderation_reason,omitempty"`
+	// ModerationEvents holds the IDs of the latest moderation events,
+	// whose presence here commits them in the outbox.
+	ModerationEvents []string `json:"moderation_events,omitempty"`
+	// DeleteAfter is set on rejected photos, which are purged once it
+	// passes.
+	DeleteAfter time.Time `json:"delete_after,omitzero"`
+	CreatedAt   time.Time `json:"created_at"`
+	// DeletedAt is set on records in the trash.
+	DeletedAt time.Time `json:"deleted_at,omitzero"`
+}
+
+// Placeholder is what clients render while the real photo loads.
+type Placeholder struct {
+	BlurHash      string `json:"blurhash"`
+	DominantColor string `json:"dominant_color"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+}
+
+// UserMetadata renders the placeholder as object user-metadata.
+func (p *Placeholder) UserMetadata() map[string]string {
+	if p == nil {
+		return nil
+	}
+
+	return map[string]string{
+		"Blurhash":       p.BlurHash,
+		"Dominant-Color": p.DominantColor,
+		"Width":          strconv.Itoa(p.Width),
+		"Height":         strconv.Itoa(p.Height),
+	}
+}
+
+type UploadedPhoto struct {
+	PhotoID     string
+	URL         string
+	Placeholder *Placeholder
+}
+
+type PhotoURL struct {
+	URL         string
+	ContentType string
+	Placeholder *Placeholder
+}
+
+// BlobRef counts how many photo records point at a shared blob.
+type BlobRef struct {
+	BlobKey    string            `json:"blob_key"`
+	SHA256     string            `json:"sha256"`
+	FileSize   int64             `json:"file_size"`
+	Renditions map[string]string `json:"renditions,omitempty"`
+	Refs       int               `json:"refs"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	// Claimed is set while the blob is being removed.
+	Claimed *time.Time `json:"claimed,omitempty"`
+}
+
+type SimilarPhoto struct {
+	PhotoID  string
+	Distance int
+}
+
+type PhotoURLResult struct {
+	PhotoID  string
+	PhotoURL *PhotoURL
+	Err      error
+}
+
+// DeletedPhoto is a photo in the trash, restorable until PurgeAt.
+type DeletedPhoto struct {
+	PhotoID     string
+	Kind        PhotoKind
+	ContentType string
+	FileSize    int64
+	Placeholder *Placeholder
+	DeletedAt   time.Time
+	PurgeAt     time.Time
+}
@@ -0,0 +1,14 @@
+package models
+
+import "fmt"
+
+// RotationReport summarizes one pass of the encryption rotation job.
+type RotationReport struct {
+	Scanned     int
+	Reencrypted int
+	Failed      int
+}
+
+func (r RotationReport) String() string {
+	return fmt.Sprintf("scanned %d, re-encrypted %d, failed %d", r.Scanned, r.Reencrypted, r.Failed)
+}
@@ -0,0 +1,24 @@
+package models
+
+import "fmt"
+
+// ScanStatus tracks a photo through asynchronous malware scanning. Photos
+// scanned inline, or stored before scanning existed, have none.
+type ScanStatus string
+
+const (
+	ScanPending ScanStatus = "pending"
+	ScanClean   ScanStatus = "clean"
+)
+
+// ScanReport summarizes one pass over photos still waiting for a scan.
+type ScanReport struct {
+	Scanned  int
+	Clean    int
+	Infected int
+	Failed   int
+}
+
+func (r ScanReport) String() string {
+	return fmt.Sprintf("scanned %d, clean %d, infected %d, failed %d", r.Scanned, r.Clean, r.Infected, r.Failed)
+}
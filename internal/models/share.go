@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type Visibility string
+
+const (
+	VisibilityPrivate Visibility = "private"
+	VisibilityLink    Visibility = "link"
+	VisibilityPublic  Visibility = "public"
+)
+
+func (v Visibility) Valid() bool {
+	switch v {
+	case VisibilityPrivate, VisibilityLink, VisibilityPublic:
+		return true
+	}
+	return false
+}
+
+// ShareLink is an opaque token that resolves to a fresh presigned URL for
+// one photo until it expires, runs out of accesses or is revoked.
+type ShareLink struct {
+	Token       string    `json:"token"`
+	UserID      string    `json:"user_id"`
+	PhotoID     string    `json:"photo_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	MaxAccesses int       `json:"max_accesses,omitempty"`
+	Accesses    int       `json:"accesses"`
+	Revoked     bool      `json:"revoked,omitempty"`
+}
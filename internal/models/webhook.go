@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PhotoEvent is the payload of upload and delete events.
+type PhotoEvent struct {
+	UserID      string    `json:"user_id"`
+	PhotoID     string    `json:"photo_id"`
+	Kind        PhotoKind `json:"kind"`
+	ContentType string    `json:"content_type,omitempty"`
+	FileSize    int64     `json:"file_size,omitempty"`
+}
+
+// WebhookSubscription is a partner endpoint receiving events. Events lists
+// the event types it wants; empty means all of them.
+type WebhookSubscription struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Wants reports whether the subscription receives events of eventType.
+func (s WebhookSubscription) Wants(eventType string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+
+	for _, event := range s.Events {
+		if event == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery is one event on its way to one subscription.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Body           json.RawMessage `json:"body"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error,omitempty"`
+	// LeasedUntil is set while an instance is sending the delivery, so
+	// others leave it alone until then.
+	LeasedUntil *time.Time `json:"leased_until,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,74 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type Decision string
+
+const (
+	DecisionApprove Decision = "approve"
+	DecisionReject  Decision = "reject"
+	// DecisionReview leaves the photo for a human reviewer.
+	DecisionReview Decision = "review"
+)
+
+type Verdict struct {
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason"`
+}
+
+// Classifier is the automated first pass over new uploads.
+type Classifier interface {
+	Classify(ctx context.Context, data []byte, contentType string) (Verdict, error)
+}
+
+// HTTPClassifier posts the raw upload to an external model and expects a
+// JSON Verdict back. Anything it cannot decide goes to review.
+type HTTPClassifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPClassifier(url string, timeout time.Duration) *HTTPClassifier {
+	return &HTTPClassifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *HTTPClassifier) Classify(ctx context.Context, data []byte, contentType string) (Verdict, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to build classifier request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to call classifier: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("classifier returned %s", resp.Status)
+	}
+
+	var verdict Verdict
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return Verdict{}, fmt.Errorf("failed to decode classifier verdict: %w", err)
+	}
+
+	switch verdict.Decision {
+	case DecisionApprove, DecisionReject:
+	default:
+		verdict.Decision = DecisionReview
+	}
+
+	return verdict, nil
+}
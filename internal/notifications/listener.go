@@ -0,0 +1,55 @@
+package notifications
+
+import (
+	"context"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// Listener follows the bucket through MinIO's ListenBucketNotification
+// API, reconnecting whenever the stream drops. The API keeps no backlog:
+// events raised while disconnected, or whose handling fails, are lost, so
+// pair it with periodic reconciliation or prefer the webhook.
+type Listener struct {
+	store      *storage.MinioClient
+	retryDelay time.Duration
+}
+
+func NewListener(store *storage.MinioClient, retryDelay time.Duration) *Listener {
+	return &Listener{
+		store:      store,
+		retryDelay: retryDelay,
+	}
+}
+
+func (l *Listener) Run(ctx context.Context, handle Handler) error {
+	for {
+		l.listen(ctx, handle)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.retryDelay):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, handle Handler) {
+	// Cancelling stops the stream's goroutine once we stop reading it.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for info := range l.store.ListenEvents(ctx, Events) {
+		if info.Err != nil {
+			metrics.BucketEventFailures.Add(1)
+			return
+		}
+		for _, event := range fromRecords(info.Records) {
+			if err := handle(ctx, event); err != nil {
+				metrics.BucketEventFailures.Add(1)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+// Events are the notification types the service reconciles.
+var Events = []string{
+	"s3:ObjectCreated:*",
+	"s3:ObjectRemoved:*",
+	"s3:LifecycleExpiration:*",
+}
+
+// Handler reconciles one bucket event. An error asks the source to deliver
+// the event again where it can.
+type Handler func(ctx context.Context, event models.BucketEvent) error
+
+// Source delivers bucket events to a handler until ctx is cancelled.
+type Source interface {
+	Run(ctx context.Context, handle Handler) error
+}
+
+// fromRecords converts MinIO's notification records, which carry object
+// keys URL-encoded.
+func fromRecords(records []notification.Event) []models.BucketEvent {
+	events := make([]models.BucketEvent, 0, len(records))
+	for _, record := range records {
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			key = record.S3.Object.Key
+		}
+
+		occurredAt, _ := time.Parse(time.RFC3339Nano, record.EventTime)
+
+		events = append(events, models.BucketEvent{
+			Name:        record.EventName,
+			Bucket:      record.S3.Bucket.Name,
+			Key:         key,
+			Size:        record.S3.Object.Size,
+			ContentType: record.S3.Object.ContentType,
+			Principal:   record.UserIdentity.PrincipalID,
+			OccurredAt:  occurredAt,
+		})
+	}
+
+	return events
+}
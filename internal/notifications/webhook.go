@@ -0,0 +1,55 @@
+package notifications
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+// Webhook receives the notifications of a MinIO webhook target. MinIO
+// retries a delivery until it is answered with 2xx, and with a queue_dir
+// configured it keeps undelivered events across restarts, so a failed
+// event is answered with 503 to have it sent again.
+type Webhook struct {
+	token  string
+	handle Handler
+}
+
+// NewWebhook expects MinIO to present token as its auth_token.
+func NewWebhook(token string, handle Handler) *Webhook {
+	return &Webhook{
+		token:  token,
+		handle: handle,
+	}
+}
+
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if w.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(w.token)) != 1 {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Records []notification.Event `json:"Records"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, "invalid notification", http.StatusBadRequest)
+		return
+	}
+
+	for _, event := range fromRecords(body.Records) {
+		if err := w.handle(r.Context(), event); err != nil {
+			metrics.BucketEventFailures.Add(1)
+			http.Error(rw, "failed to handle notification", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
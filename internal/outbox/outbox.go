@@ -0,0 +1,230 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/broker"
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+const root = "_meta/outbox/"
+
+// Entry is an event waiting to be relayed to the broker.
+//
+// Entries are written before the change they describe is committed, and
+// Commit names the object whose state marks that commit: the entry is
+// relayed once Commit exists, or once it is gone when Deleted is set. For
+// changes that rewrite an object which already exists, Marker is also set,
+// and the entry only counts as committed once the rewritten object holds
+// it. A crash anywhere between writing the entry and committing the change
+// therefore loses nothing; an entry whose change never commits is dropped
+// after the commit timeout.
+type Entry struct {
+	ID      string `json:"id"`
+	Subject string `json:"subject"`
+	Key     string `json:"key"`
+	Data    []byte `json:"data"`
+	// Payload is the event as JSON, for forwarders such as webhooks.
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Commit    string          `json:"commit"`
+	Deleted   bool            `json:"deleted,omitempty"`
+	Marker    string          `json:"marker,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Forwarder takes committed entries besides the broker. It may see an
+// entry again after a failure later in the relay, so it must be
+// idempotent per entry ID.
+type Forwarder func(ctx context.Context, entry Entry) error
+
+// Outbox queues events in the bucket and relays them to a broker, and to
+// any forwarders, in the order they were queued. Delivery is at least
+// once: an entry is only removed after every one of them accepted it, so a
+// crash in between publishes it again, under the same ID.
+type Outbox struct {
+	store *storage.MinioClient
+	// broker is nil when events only go to forwarders.
+	broker        broker.Broker
+	forwarders    []Forwarder
+	subjectPrefix string
+	batchSize     int
+	commitTimeout time.Duration
+
+	queued chan struct{}
+}
+
+func New(store *storage.MinioClient, b broker.Broker, subjectPrefix string, batchSize int, commitTimeout time.Duration) *Outbox {
+	return &Outbox{
+		store:         store,
+		broker:        b,
+		subjectPrefix: subjectPrefix,
+		batchSize:     max(1, batchSize),
+		commitTimeout: commitTimeout,
+		queued:        make(chan struct{}, 1),
+	}
+}
+
+// Forward has the relay hand committed entries to f as well. It must be
+// called before the relay starts.
+func (o *Outbox) Forward(f Forwarder) {
+	o.forwarders = append(o.forwarders, f)
+}
+
+// Add queues an entry. Keys sort by creation time, so listing the outbox
+// yields entries in order.
+func (o *Outbox) Add(ctx context.Context, entry Entry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	key := fmt.Sprintf("%s%020d-%s.json", root, entry.CreatedAt.UnixNano(), entry.ID)
+	if err := o.store.PutJSON(ctx, key, entry); err != nil {
+		return fmt.Errorf("failed to queue %s event: %w", entry.Subject, err)
+	}
+
+	select {
+	case o.queued <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Queued fires after entries are added, so the relay need not wait for
+// its next poll.
+func (o *Outbox) Queued() <-chan struct{} {
+	return o.queued
+}
+
+// Relay publishes every committed entry, a batch at a time, and reports
+// how many went out. An entry that is not committed yet holds back later
+// entries with the same key; a broker or forwarder failure ends the pass.
+//
+// Events are therefore ordered per key, by the clock of the instance that
+// queued them, and only while one relay runs at a time: relays on several
+// instances may publish a key's events out of order, or twice. Nothing is
+// ordered across keys. Entries are judged one at a time, so of the events
+// queued for one change, one may be dropped after the commit timeout while
+// another goes out; consumers must not rely on seeing them in pairs.
+func (o *Outbox) Relay(ctx context.Context) (int, error) {
+	published := 0
+	held := make(map[string]bool)
+
+	startAfter := ""
+	for {
+		objects, err := o.store.ListPage(ctx, root, startAfter, o.batchSize)
+		if err != nil {
+			return published, fmt.Errorf("failed to list outbox: %w", err)
+		}
+
+		for _, object := range objects {
+			if err := ctx.Err(); err != nil {
+				return published, err
+			}
+
+			ok, err := o.relay(ctx, object.Key, held)
+			if err != nil {
+				return published, err
+			}
+			if ok {
+				published++
+			}
+		}
+
+		if len(objects) < o.batchSize {
+			return published, nil
+		}
+		startAfter = objects[len(objects)-1].Key
+	}
+}
+
+func (o *Outbox) relay(ctx context.Context, key string, held map[string]bool) (bool, error) {
+	var entry Entry
+	if err := o.store.GetJSON(ctx, key, &entry); err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			// Relayed concurrently by another instance.
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read outbox entry: %w", err)
+	}
+	if held[entry.Key] {
+		return false, nil
+	}
+
+	committed, err := o.committed(ctx, entry)
+	if err != nil {
+		return false, err
+	}
+	if !committed {
+		if time.Since(entry.CreatedAt) > o.commitTimeout {
+			metrics.OutboxDropped.Add(1)
+			if err := o.store.Delete(ctx, key); err != nil {
+				return false, fmt.Errorf("failed to drop outbox entry: %w", err)
+			}
+			return false, nil
+		}
+		held[entry.Key] = true
+		return false, nil
+	}
+
+	for _, forward := range o.forwarders {
+		if err := forward(ctx, entry); err != nil {
+			metrics.OutboxFailures.Add(1)
+			return false, err
+		}
+	}
+
+	if o.broker != nil {
+		if err := o.broker.Publish(ctx, broker.Message{
+			ID:      entry.ID,
+			Subject: o.subjectPrefix + entry.Subject,
+			Key:     entry.Key,
+			Data:    entry.Data,
+		}); err != nil {
+			metrics.OutboxFailures.Add(1)
+			return false, err
+		}
+	}
+
+	if err := o.store.Delete(ctx, key); err != nil {
+		return false, fmt.Errorf("failed to remove relayed outbox entry: %w", err)
+	}
+	metrics.OutboxPublished.Add(1)
+
+	return true, nil
+}
+
+func (o *Outbox) committed(ctx context.Context, entry Entry) (bool, error) {
+	if entry.Marker != "" {
+		var commit json.RawMessage
+		err := o.store.GetJSON(ctx, entry.Commit, &commit)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to check commit of %s: %w", entry.ID, err)
+		}
+
+		return bytes.Contains(commit, []byte(`"`+entry.Marker+`"`)), nil
+	}
+
+	exists, err := o.store.Exists(ctx, entry.Commit)
+	if err != nil {
+		return false, fmt.Errorf("failed to check commit of %s: %w", entry.ID, err)
+	}
+
+	return exists != entry.Deleted, nil
+}
+
+func (o *Outbox) Close() error {
+	if o.broker == nil {
+		return nil
+	}
+	return o.broker.Close()
+}
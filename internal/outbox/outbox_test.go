@@ -0,0 +1,198 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/broker"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage/storagetest"
+)
+
+func newTestOutbox(t *testing.T, commitTimeout time.Duration) (*Outbox, *storage.MinioClient, *broker.Memory) {
+	t.Helper()
+
+	store, _ := storagetest.NewClient(t, "test")
+	b := broker.NewMemory()
+	return New(store, b, "test.", 2, commitTimeout), store, b
+}
+
+func ids(messages []broker.Message) string {
+	var names []string
+	for _, msg := range messages {
+		names = append(names, msg.ID)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRelayHoldsUncommittedEntriesPerKey(t *testing.T) {
+	ctx := context.Background()
+	o, store, b := newTestOutbox(t, time.Hour)
+
+	for _, entry := range []Entry{
+		{ID: "a1", Subject: "photo.uploaded", Key: "alice", Commit: "photos/a1.json"},
+		{ID: "b1", Subject: "photo.uploaded", Key: "bob", Commit: "photos/b1.json"},
+		{ID: "a2", Subject: "photo.uploaded", Key: "alice", Commit: "photos/a2.json"},
+	} {
+		if err := o.Add(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only bob's change and alice's second change are committed; alice's
+	// second event must wait for her first.
+	for _, key := range []string{"photos/b1.json", "photos/a2.json"} {
+		if err := store.PutJSON(ctx, key, struct{}{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	published, err := o.Relay(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != 1 || ids(b.Messages()) != "b1" {
+		t.Fatalf("published %d (%s), want only b1", published, ids(b.Messages()))
+	}
+
+	if err := store.PutJSON(ctx, "photos/a1.json", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	published, err = o.Relay(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != 2 || ids(b.Messages()) != "b1,a1,a2" {
+		t.Fatalf("published %d (%s), want a1 then a2", published, ids(b.Messages()))
+	}
+	if got := b.Messages()[1].Subject; got != "test.photo.uploaded" {
+		t.Fatalf("subject %q, want the prefixed subject", got)
+	}
+}
+
+func TestRelayWaitsForDeletes(t *testing.T) {
+	ctx := context.Background()
+	o, store, b := newTestOutbox(t, time.Hour)
+
+	if err := store.PutJSON(ctx, "photos/a.json", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Add(ctx, Entry{ID: "a", Subject: "photo.deleted", Key: "alice", Commit: "photos/a.json", Deleted: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if published, err := o.Relay(ctx); err != nil || published != 0 {
+		t.Fatalf("relayed %d, %v before the delete committed", published, err)
+	}
+
+	if err := store.Delete(ctx, "photos/a.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if published, err := o.Relay(ctx); err != nil || published != 1 {
+		t.Fatalf("relayed %d, %v after the delete committed, want 1", published, err)
+	}
+	if ids(b.Messages()) != "a" {
+		t.Fatalf("published %s, want a", ids(b.Messages()))
+	}
+}
+
+func TestRelayWaitsForMarkers(t *testing.T) {
+	ctx := context.Background()
+	o, store, b := newTestOutbox(t, time.Hour)
+
+	// The record exists before and after the change; only the marker
+	// landing in it commits the entry.
+	if err := store.PutJSON(ctx, "photos/a.json", map[string]any{"events": []string{"m0"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Add(ctx, Entry{ID: "a", Subject: "photo.moderation_changed", Key: "alice", Commit: "photos/a.json", Marker: "m1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if published, err := o.Relay(ctx); err != nil || published != 0 {
+		t.Fatalf("relayed %d, %v before the marker was written", published, err)
+	}
+
+	if err := store.PutJSON(ctx, "photos/a.json", map[string]any{"events": []string{"m0", "m1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if published, err := o.Relay(ctx); err != nil || published != 1 {
+		t.Fatalf("relayed %d, %v after the marker was written, want 1", published, err)
+	}
+	if ids(b.Messages()) != "a" {
+		t.Fatalf("published %s, want a", ids(b.Messages()))
+	}
+}
+
+func TestRelayDropsEntriesThatNeverCommit(t *testing.T) {
+	ctx := context.Background()
+	o, store, b := newTestOutbox(t, time.Minute)
+
+	if err := o.Add(ctx, Entry{
+		ID:        "a",
+		Subject:   "photo.uploaded",
+		Key:       "alice",
+		Commit:    "photos/a.json",
+		CreatedAt: time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if published, err := o.Relay(ctx); err != nil || published != 0 {
+		t.Fatalf("relayed %d, %v, want the entry dropped", published, err)
+	}
+	if len(b.Messages()) != 0 {
+		t.Fatalf("published %s for a change that never committed", ids(b.Messages()))
+	}
+
+	objects, err := store.List(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 0 {
+		t.Fatalf("%d entries left in the outbox", len(objects))
+	}
+}
+
+func TestRelayKeepsEntriesAForwarderRefused(t *testing.T) {
+	ctx := context.Background()
+	o, store, b := newTestOutbox(t, time.Hour)
+
+	var forwarded []string
+	failing := true
+	o.Forward(func(ctx context.Context, entry Entry) error {
+		forwarded = append(forwarded, entry.ID)
+		if failing {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	if err := store.PutJSON(ctx, "photos/a.json", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Add(ctx, Entry{ID: "a", Subject: "photo.uploaded", Key: "alice", Commit: "photos/a.json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.Relay(ctx); err == nil {
+		t.Fatal("relay succeeded past a failing forwarder")
+	}
+	if len(b.Messages()) != 0 {
+		t.Fatal("published to the broker before the forwarder accepted the entry")
+	}
+
+	failing = false
+	if published, err := o.Relay(ctx); err != nil || published != 1 {
+		t.Fatalf("relayed %d, %v, want 1", published, err)
+	}
+	if strings.Join(forwarded, ",") != "a,a" {
+		t.Fatalf("forwarded %v, want a twice", forwarded)
+	}
+}
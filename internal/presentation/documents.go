@@ -0,0 +1,128 @@
+package grpc_server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+	s3_v1 "github.com/acyushka/nbf-file-storage-service/pkg/pb/gen"
+
+	"github.com/hesoyamTM/nbf-auth/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *MinioServer) UploadDocument(ctx context.Context, req *s3_v1.UploadDocumentRequest) (*s3_v1.UploadDocumentResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if len(req.GetFileData()) == 0 {
+		log.Error("Error: file_data is empty")
+		return nil, status.Error(codes.InvalidArgument, "file_data is required")
+	}
+
+	if s.tenant(ctx).tooLarge(len(req.GetFileData())) {
+		log.Error("Error: file_data is too large")
+		return nil, status.Error(codes.ResourceExhausted, "file_data is too large")
+	}
+
+	documentID, err := s.tenant(ctx).service.UploadDocument(ctx, req.GetUserId(), bytes.NewReader(req.GetFileData()), req.GetFileName(), int64(len(req.GetFileData())), req.GetContentType(), fromPbChecksums(req.GetChecksums()))
+	if err := checksumError(err); err != nil {
+		log.Error("Error: document failed checksum verification")
+		return nil, err
+	}
+	if err := scanError(err); err != nil {
+		log.Error("Error: document failed malware scan")
+		return nil, err
+	}
+	if errors.Is(err, service.ErrDocumentsDisabled) {
+		log.Error("Error: document storage is disabled")
+		return nil, status.Error(codes.FailedPrecondition, "document storage is disabled")
+	}
+	if err != nil {
+		log.Error("Error: failed to upload document")
+		return nil, status.Errorf(codes.Internal, "failed to upload document: %v", err)
+	}
+
+	log.Info("Document uploaded successfuly")
+
+	return &s3_v1.UploadDocumentResponse{
+		DocumentId: documentID,
+	}, nil
+}
+
+func (s *MinioServer) GetDocumentURL(ctx context.Context, req *s3_v1.GetDocumentURLRequest) (*s3_v1.GetDocumentURLResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetDocumentId() == "" {
+		log.Error("Error: document_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "document_id is required")
+	}
+
+	path, expiresAt, err := s.tenant(ctx).service.SignDocumentPath(req.GetUserId(), req.GetDocumentId())
+	if errors.Is(err, service.ErrDocumentsDisabled) {
+		log.Error("Error: document storage is disabled")
+		return nil, status.Error(codes.FailedPrecondition, "document storage is disabled")
+	}
+	if errors.Is(err, service.ErrProxyDisabled) {
+		log.Error("Error: url signing is disabled")
+		return nil, status.Error(codes.FailedPrecondition, "url signing is disabled")
+	}
+	if err != nil {
+		log.Error("Error: failed to sign document url")
+		return nil, status.Errorf(codes.Internal, "failed to sign document url: %v", err)
+	}
+
+	return &s3_v1.GetDocumentURLResponse{
+		Url:       s.tenant(ctx).publicURL + path,
+		ExpiresAt: expiresAt.Unix(),
+	}, nil
+}
+
+func (s *MinioServer) DeleteDocument(ctx context.Context, req *s3_v1.DeleteDocumentRequest) (*s3_v1.DeleteDocumentResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetDocumentId() == "" {
+		log.Error("Error: document_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "document_id is required")
+	}
+
+	err = s.tenant(ctx).service.DeleteDocument(ctx, req.GetUserId(), req.GetDocumentId())
+	if errors.Is(err, service.ErrDocumentsDisabled) {
+		log.Error("Error: document storage is disabled")
+		return nil, status.Error(codes.FailedPrecondition, "document storage is disabled")
+	}
+	if errors.Is(err, service.ErrDocumentNotFound) {
+		log.Error("Error: document not found")
+		return nil, status.Error(codes.NotFound, "document not found")
+	}
+	if err != nil {
+		log.Error("Error: failed to delete document")
+		return nil, status.Errorf(codes.Internal, "failed to delete document: %v", err)
+	}
+
+	log.Info("Document deleted successfuly")
+
+	return &s3_v1.DeleteDocumentResponse{}, nil
+}
@@ -0,0 +1,345 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/notifications"
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+
+	"github.com/hesoyamTM/nbf-auth/pkg/logger"
+)
+
+type HttpServer struct {
+	server      *http.Server
+	service     *service.MinioService
+	cacheMaxAge time.Duration
+}
+
+func NewHttpServer(ctx context.Context, cfg *config.Config, service *service.MinioService, tenants []*Tenant) *HttpServer {
+	s := &HttpServer{
+		service:     service,
+		cacheMaxAge: cfg.ImageProxy.CacheMaxAge,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	s.routes(mux)
+	if cfg.Notifications.Enabled && cfg.Notifications.Source == "webhook" {
+		mux.Handle("POST /minio/events", notifications.NewWebhook(cfg.Notifications.WebhookToken, routeBucketEvent(service, tenants)))
+	}
+
+	s.server = &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.HTTP.Host, cfg.HTTP.Port),
+		Handler:           tenantHandler(mux, tenants),
+		ReadHeaderTimeout: 10 * time.Second,
+		// Handlers find the logger on the request context.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	return s
+}
+
+func (s *HttpServer) MustStart(ctx context.Context) {
+	const op = "http.MustStart"
+
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", op, err))
+	}
+
+	log.Info("http server is starting")
+
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		panic(fmt.Errorf("%s:%w", op, err))
+	}
+}
+
+func (s *HttpServer) MustStop(ctx context.Context) {
+	const op = "http.MustStop"
+
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", op, err))
+	}
+
+	log.Info("http server is stopping")
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		panic(fmt.Errorf("%s: %w", op, err))
+	}
+}
+
+// routes registers the public routes, which tenants get a set of their own.
+func (s *HttpServer) routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /s/{token}", s.handleShareLink)
+	mux.HandleFunc("GET /p/{user}/{photo}", s.handlePublicPhoto)
+	mux.HandleFunc("GET /img/{signature}/{user}/{photo}", s.handleImage)
+	mux.HandleFunc("GET /files/{signature}/{user}/{photo}", s.handleDownload)
+	mux.HandleFunc("GET /docs/{signature}/{user}/{document}", s.handleDocument)
+	mux.HandleFunc("GET /exports/{signature}/{user}/{export}", s.handleExport)
+}
+
+func (s *HttpServer) handleShareLink(w http.ResponseWriter, r *http.Request) {
+	url, err := s.service.ResolveShareLink(r.Context(), r.PathValue("token"))
+	s.redirect(w, r, url, err)
+}
+
+func (s *HttpServer) handlePublicPhoto(w http.ResponseWriter, r *http.Request) {
+	url, err := s.service.ResolvePublicPhoto(r.Context(), r.PathValue("user"), r.PathValue("photo"))
+	s.redirect(w, r, url, err)
+}
+
+// redirect sends the client on to a freshly presigned URL. The redirect
+// itself must never be cached, or revocation would stop being immediate.
+func (s *HttpServer) redirect(w http.ResponseWriter, r *http.Request, url string, err error) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch {
+	case errors.Is(err, service.ErrShareNotFound),
+		errors.Is(err, service.ErrPhotoNotFound),
+		errors.Is(err, service.ErrScanPending),
+		errors.Is(err, service.ErrModerationPending):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrShareGone):
+		http.Error(w, "link is no longer available", http.StatusGone)
+	case err != nil:
+		s.internalError(w, r, err)
+	default:
+		http.Redirect(w, r, url, http.StatusFound)
+	}
+}
+
+func (s *HttpServer) handleImage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	expires, err := strconv.ParseInt(query.Get("exp"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid exp", http.StatusBadRequest)
+		return
+	}
+
+	width, err := optionalInt(query.Get("w"))
+	if err != nil {
+		http.Error(w, "invalid w", http.StatusBadRequest)
+		return
+	}
+	height, err := optionalInt(query.Get("h"))
+	if err != nil {
+		http.Error(w, "invalid h", http.StatusBadRequest)
+		return
+	}
+
+	download, err := s.service.RenderImage(r.Context(), r.PathValue("signature"), r.PathValue("user"), r.PathValue("photo"), models.ImageOptions{
+		Width:  width,
+		Height: height,
+		Fit:    query.Get("fit"),
+		Format: query.Get("fmt"),
+	}, expires)
+	switch {
+	case errors.Is(err, service.ErrInvalidSignature):
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	case errors.Is(err, service.ErrDownloadExpired):
+		http.Error(w, "link expired", http.StatusGone)
+		return
+	case errors.Is(err, service.ErrInvalidImageOptions):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, service.ErrTooManyPixels):
+		http.Error(w, "image too large to render", http.StatusUnprocessableEntity)
+		return
+	case errors.Is(err, service.ErrPhotoNotFound),
+		errors.Is(err, service.ErrScanPending),
+		errors.Is(err, service.ErrModerationPending),
+		errors.Is(err, service.ErrProxyDisabled):
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case err != nil:
+		s.internalError(w, r, err)
+		return
+	}
+
+	// Only public photos may be kept by shared caches, and for no longer
+	// than the link lives if they are not.
+	if download.Public {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(s.cacheMaxAge.Seconds())))
+	} else {
+		maxAge := max(0, min(int(s.cacheMaxAge.Seconds()), int(time.Until(download.ExpiresAt).Seconds())))
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	}
+	serveDownload(w, r, download)
+}
+
+func (s *HttpServer) handleDownload(w http.ResponseWriter, r *http.Request) {
+	expires, err := strconv.ParseInt(r.URL.Query().Get("exp"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid exp", http.StatusBadRequest)
+		return
+	}
+
+	download, err := s.service.OpenDownload(r.Context(), r.PathValue("signature"), r.URL.Query().Get("by"), r.PathValue("user"), r.PathValue("photo"), expires)
+	switch {
+	case errors.Is(err, service.ErrInvalidSignature):
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	case errors.Is(err, service.ErrDownloadExpired):
+		http.Error(w, "link expired", http.StatusGone)
+		return
+	case errors.Is(err, service.ErrPhotoNotFound),
+		errors.Is(err, service.ErrScanPending),
+		errors.Is(err, service.ErrModerationPending),
+		errors.Is(err, service.ErrProxyDisabled):
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case err != nil:
+		s.internalError(w, r, err)
+		return
+	}
+
+	// Shared caches may keep public photos, but never past the link.
+	scope := "private"
+	if download.Public {
+		scope = "public"
+	}
+	maxAge := max(0, int(time.Until(download.ExpiresAt).Seconds()))
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, maxAge))
+	serveDownload(w, r, download)
+}
+
+// handleDocument serves a decrypted document. Documents are sensitive, so
+// nothing along the way may keep a copy.
+func (s *HttpServer) handleDocument(w http.ResponseWriter, r *http.Request) {
+	expires, err := strconv.ParseInt(r.URL.Query().Get("exp"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid exp", http.StatusBadRequest)
+		return
+	}
+
+	download, err := s.service.OpenDocument(r.Context(), r.PathValue("signature"), r.PathValue("user"), r.PathValue("document"), expires)
+	switch {
+	case errors.Is(err, service.ErrInvalidSignature):
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	case errors.Is(err, service.ErrDownloadExpired):
+		http.Error(w, "link expired", http.StatusGone)
+		return
+	case errors.Is(err, service.ErrDocumentNotFound),
+		errors.Is(err, service.ErrDocumentsDisabled),
+		errors.Is(err, service.ErrProxyDisabled):
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case err != nil:
+		s.internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Disposition", "attachment")
+	serveDownload(w, r, download)
+}
+
+// handleExport serves a user data export, for stores under SSE-C where the
+// archive cannot be presigned.
+func (s *HttpServer) handleExport(w http.ResponseWriter, r *http.Request) {
+	expires, err := strconv.ParseInt(r.URL.Query().Get("exp"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid exp", http.StatusBadRequest)
+		return
+	}
+
+	download, err := s.service.OpenExport(r.Context(), r.PathValue("signature"), r.PathValue("user"), r.PathValue("export"), expires)
+	switch {
+	case errors.Is(err, service.ErrInvalidSignature):
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	case errors.Is(err, service.ErrDownloadExpired):
+		http.Error(w, "link expired", http.StatusGone)
+		return
+	case errors.Is(err, service.ErrExportNotFound),
+		errors.Is(err, service.ErrProxyDisabled):
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case err != nil:
+		s.internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Disposition", "attachment")
+	serveDownload(w, r, download)
+}
+
+// serveDownload streams an object. http.ServeContent does the protocol work:
+// single and multi-range requests (206, or 416 when unsatisfiable), If-Range,
+// and If-None-Match/If-Modified-Since (304) against the validators set here.
+// The size comes from stat data, so a Range request only ever fetches the
+// requested bytes from storage.
+func serveDownload(w http.ResponseWriter, r *http.Request, download *models.Download) {
+	defer download.Content.Close()
+
+	w.Header().Set("Content-Type", download.ContentType)
+	w.Header().Set("Accept-Ranges", "bytes")
+	if download.ETag != "" {
+		w.Header().Set("ETag", download.ETag)
+	}
+
+	http.ServeContent(w, r, "", download.ModTime, &sizedContent{
+		ReadSeeker: download.Content,
+		size:       download.Size,
+	})
+}
+
+// sizedContent answers ServeContent's seek-to-end size probe from the known
+// object size instead of asking the underlying reader.
+type sizedContent struct {
+	io.ReadSeeker
+	size   int64
+	offset int64
+}
+
+func (c *sizedContent) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekEnd {
+		c.offset = c.size + offset
+		return c.offset, nil
+	}
+
+	if whence == io.SeekCurrent {
+		offset += c.offset
+	}
+
+	n, err := c.ReadSeeker.Seek(offset, io.SeekStart)
+	c.offset = n
+
+	return n, err
+}
+
+func (c *sizedContent) Read(p []byte) (int, error) {
+	n, err := c.ReadSeeker.Read(p)
+	c.offset += int64(n)
+
+	return n, err
+}
+
+func (s *HttpServer) internalError(w http.ResponseWriter, r *http.Request, err error) {
+	if log, logErr := logger.LoggerFromCtx(r.Context()); logErr == nil {
+		log.Error(fmt.Sprintf("Error: failed to serve %s: %v", r.URL.Path, err))
+	}
+	http.Error(w, "internal error", http.StatusInternalServerError)
+}
+
+func optionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
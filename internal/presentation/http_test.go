@@ -0,0 +1,205 @@
+package grpc_server
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage/storagetest"
+)
+
+// newTestHTTP serves the public routes over an in-memory bucket. The
+// second service shares storage and secret but signs links that have
+// already expired.
+func newTestHTTP(t *testing.T) (http.Handler, *service.MinioService, *service.MinioService) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.HTTP.SigningSecret = "secret"
+	cfg.HTTP.DownloadExpiry = time.Hour
+	cfg.PresignedUrl.ExpiryHours = 1
+	cfg.ImageProxy.MaxDimension = 64
+	cfg.ImageProxy.Quality = 80
+	cfg.ImageProxy.CacheMaxAge = time.Hour
+
+	store, _ := storagetest.NewClient(t, "test")
+	s := service.NewMinioService(service.Dependencies{Storage: store}, cfg)
+
+	expiredCfg := *cfg
+	expiredCfg.HTTP.DownloadExpiry = -time.Minute
+	expiredCfg.PresignedUrl.ExpiryHours = -1
+	expired := service.NewMinioService(service.Dependencies{Storage: store}, &expiredCfg)
+
+	return NewHttpServer(context.Background(), cfg, s, nil).server.Handler, s, expired
+}
+
+func uploadPNG(t *testing.T, s *service.MinioService) (string, []byte) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := range 16 {
+		for y := range 16 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 16), G: uint8(y * 16), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	uploaded, err := s.UploadPhotos(context.Background(), "alice", []models.PhotoData{{
+		Data:        bytes.NewReader(data),
+		FileSize:    int64(len(data)),
+		FileName:    "photo.png",
+		ContentType: "image/png",
+	}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uploaded[0].PhotoID, data
+}
+
+func get(handler http.Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+// withQuery returns path with one query parameter set to value.
+func withQuery(t *testing.T, path string, name string, value string) string {
+	t.Helper()
+
+	u, err := url.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set(name, value)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func queryValue(t *testing.T, path string, name string) string {
+	t.Helper()
+
+	u, err := url.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get(name)
+}
+
+func TestImageLinks(t *testing.T) {
+	handler, s, expired := newTestHTTP(t)
+	photoID, _ := uploadPNG(t, s)
+
+	path, _, err := s.SignImagePath("alice", photoID, models.ImageOptions{Width: 8, Height: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := get(handler, path, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("signed link returned %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("rendition served without an ETag")
+	}
+
+	// Served from the cached rendition this time, with the same validator.
+	if w := get(handler, path, http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match returned %d, want 304", w.Code)
+	}
+	if w := get(handler, path, http.Header{"If-None-Match": {`"stale"`}}); w.Code != http.StatusOK {
+		t.Fatalf("stale If-None-Match returned %d, want 200", w.Code)
+	}
+
+	for name, value := range map[string]string{"w": "16", "h": "4", "fmt": "jpeg", "fit": "contain"} {
+		if w := get(handler, withQuery(t, path, name, value), nil); w.Code != http.StatusForbidden {
+			t.Errorf("tampered %s returned %d, want 403", name, w.Code)
+		}
+	}
+
+	old, _, err := expired.SignImagePath("alice", photoID, models.ImageOptions{Width: 8, Height: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := get(handler, old, nil); w.Code != http.StatusGone {
+		t.Fatalf("expired link returned %d, want 410", w.Code)
+	}
+	// Pushing the expiry out breaks the signature.
+	if w := get(handler, withQuery(t, old, "exp", queryValue(t, path, "exp")), nil); w.Code != http.StatusForbidden {
+		t.Fatalf("extended link returned %d, want 403", w.Code)
+	}
+}
+
+func TestDownloadRanges(t *testing.T) {
+	handler, s, expired := newTestHTTP(t)
+	photoID, data := uploadPNG(t, s)
+
+	path, _, err := s.SignDownloadPath("alice", photoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := get(handler, path, nil)
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), data) {
+		t.Fatalf("download returned %d with %d bytes", w.Code, w.Body.Len())
+	}
+	etag := w.Header().Get("ETag")
+
+	w = get(handler, path, http.Header{"Range": {"bytes=0-9"}})
+	if w.Code != http.StatusPartialContent || !bytes.Equal(w.Body.Bytes(), data[:10]) {
+		t.Fatalf("range returned %d with %d bytes", w.Code, w.Body.Len())
+	}
+	if got, want := w.Header().Get("Content-Range"), "bytes 0-9/"+strconv.Itoa(len(data)); got != want {
+		t.Fatalf("Content-Range %q, want %q", got, want)
+	}
+
+	// If-Range only honours the range while the validator still matches.
+	if w := get(handler, path, http.Header{"Range": {"bytes=0-9"}, "If-Range": {etag}}); w.Code != http.StatusPartialContent {
+		t.Fatalf("matching If-Range returned %d, want 206", w.Code)
+	}
+	w = get(handler, path, http.Header{"Range": {"bytes=0-9"}, "If-Range": {`"stale"`}})
+	if w.Code != http.StatusOK || w.Body.Len() != len(data) {
+		t.Fatalf("stale If-Range returned %d with %d bytes, want the whole file", w.Code, w.Body.Len())
+	}
+
+	if w := get(handler, path, http.Header{"Range": {"bytes=100000-"}}); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("unsatisfiable range returned %d, want 416", w.Code)
+	}
+	if w := get(handler, path, http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match returned %d, want 304", w.Code)
+	}
+
+	tampered := strings.Replace(path, "/alice/", "/bob/", 1)
+	if w := get(handler, tampered, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("tampered link returned %d, want 403", w.Code)
+	}
+
+	old, _, err := expired.SignDownloadPath("alice", photoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := get(handler, old, nil); w.Code != http.StatusGone {
+		t.Fatalf("expired link returned %d, want 410", w.Code)
+	}
+}
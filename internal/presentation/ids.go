@@ -0,0 +1,51 @@
+package grpc_server
+
+import (
+	"context"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// idInterceptor rejects requests whose IDs could not name a single object
+// key segment, before any handler builds a key out of them. Empty IDs are
+// left to the handlers, which know whether they are required.
+func idInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if r, ok := req.(interface{ GetUserId() string }); ok {
+			if id := r.GetUserId(); id != "" && !service.ValidUserID(id) {
+				return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+			}
+		}
+
+		var ids []string
+		if r, ok := req.(interface{ GetPhotoId() string }); ok {
+			ids = append(ids, r.GetPhotoId())
+		}
+		if r, ok := req.(interface{ GetPhotoIds() []string }); ok {
+			ids = append(ids, r.GetPhotoIds()...)
+		}
+		if r, ok := req.(interface{ GetDocumentId() string }); ok {
+			ids = append(ids, r.GetDocumentId())
+		}
+		if r, ok := req.(interface{ GetErasureId() string }); ok {
+			ids = append(ids, r.GetErasureId())
+		}
+		if r, ok := req.(interface{ GetWebhookId() string }); ok {
+			ids = append(ids, r.GetWebhookId())
+		}
+		if r, ok := req.(interface{ GetDeliveryId() string }); ok {
+			ids = append(ids, r.GetDeliveryId())
+		}
+		for _, id := range ids {
+			if id != "" && !service.ValidID(id) {
+				return nil, status.Error(codes.InvalidArgument, "invalid id")
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,295 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/notifications"
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+	"github.com/acyushka/nbf-file-storage-service/internal/tenancy"
+
+	"github.com/hesoyamTM/nbf-auth/pkg/logger"
+)
+
+// StartBackgroundJobs launches the maintenance jobs enabled in cfg, for the
+// deployment's own product and each tenant. They stop when ctx is
+// cancelled.
+func StartBackgroundJobs(ctx context.Context, cfg *config.Config, fileStorageService *service.MinioService, tenants []*Tenant) {
+	startJobs(ctx, cfg, fileStorageService, routeBucketEvent(fileStorageService, tenants))
+	for _, t := range tenants {
+		startJobs(tenancy.WithID(ctx, t.ID), t.Config, t.Service, t.Service.HandleBucketEvent)
+	}
+}
+
+// startJobs launches the jobs of one service, passing the bucket events it
+// watches to handle.
+func startJobs(ctx context.Context, cfg *config.Config, fileStorageService *service.MinioService, handle notifications.Handler) {
+	const op = "grpc.StartBackgroundJobs"
+
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", op, err))
+	}
+
+	if cfg.Scanning.Enabled && cfg.Scanning.Mode == "async" {
+		go func() {
+			ticker := time.NewTicker(cfg.Scanning.PendingInterval)
+			defer ticker.Stop()
+
+			for {
+				report, err := fileStorageService.ScanPending(ctx)
+				if err != nil && ctx.Err() == nil {
+					log.Error(fmt.Sprintf("pending scan pass stopped: %v", err))
+				}
+				if report != nil && report.Scanned > 0 {
+					log.Info(fmt.Sprintf("pending scan pass finished: %s", report))
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+
+	if cfg.Notifications.Enabled {
+		go func() {
+			if err := fileStorageService.WatchBucket(ctx, handle); err != nil && ctx.Err() == nil {
+				log.Error(fmt.Sprintf("bucket notifications stopped: %v", err))
+			}
+		}()
+	}
+
+	if cfg.Notifications.Enabled && cfg.Notifications.DirectUploads {
+		go func() {
+			ticker := time.NewTicker(cfg.Notifications.IngestInterval)
+			defer ticker.Stop()
+
+			for {
+				ingested, err := fileStorageService.IngestStagedUploads(ctx, cfg.Notifications.IngestInterval)
+				if err != nil && ctx.Err() == nil {
+					log.Error(fmt.Sprintf("staged upload pass stopped: %v", err))
+				}
+				if ingested > 0 {
+					log.Info(fmt.Sprintf("picked up %d staged uploads", ingested))
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		resumed, err := fileStorageService.ResumeErasures(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error(fmt.Sprintf("erasure resume stopped: %v", err))
+		}
+		if resumed > 0 {
+			log.Info(fmt.Sprintf("finished %d interrupted erasures", resumed))
+		}
+	}()
+
+	if cfg.Webhooks.Enabled {
+		go func() {
+			if err := fileStorageService.DeliverWebhooks(ctx); err != nil && ctx.Err() == nil {
+				log.Error(fmt.Sprintf("webhook delivery stopped: %v", err))
+			}
+		}()
+	}
+
+	// Webhooks are fed from the outbox relay too.
+	if cfg.Outbox.Enabled || cfg.Webhooks.Enabled {
+		go func() {
+			ticker := time.NewTicker(cfg.Outbox.PollInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				case <-fileStorageService.EventsQueued():
+				}
+
+				published, err := fileStorageService.RelayEvents(ctx)
+				if err != nil && ctx.Err() == nil {
+					log.Error(fmt.Sprintf("event relay stopped after %d events: %v", published, err))
+				}
+			}
+		}()
+	}
+
+	if cfg.Moderation.Enabled {
+		go func() {
+			ticker := time.NewTicker(cfg.Moderation.PurgeInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				purged, err := fileStorageService.PurgeRejected(ctx)
+				if err != nil {
+					log.Error(fmt.Sprintf("rejected photo purge stopped: %v", err))
+				}
+				if purged > 0 {
+					log.Info(fmt.Sprintf("purged %d rejected photos", purged))
+				}
+			}
+		}()
+	}
+
+	if cfg.Trash.Enabled {
+		go func() {
+			ticker := time.NewTicker(cfg.Trash.PurgeInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				purged, err := fileStorageService.PurgeTrash(ctx)
+				if err != nil && ctx.Err() == nil {
+					log.Error(fmt.Sprintf("trash purge stopped: %v", err))
+				}
+				if purged > 0 {
+					log.Info(fmt.Sprintf("purged %d photos from the trash", purged))
+				}
+			}
+		}()
+	}
+
+	if cfg.GC.Enabled {
+		go func() {
+			ticker := time.NewTicker(cfg.GC.Interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				report, err := fileStorageService.CollectGarbage(ctx, GCOptions(cfg.GC))
+				if err != nil && ctx.Err() == nil {
+					log.Error(fmt.Sprintf("garbage collection stopped: %v", err))
+				}
+				if report != nil && report.Orphans > 0 {
+					log.Info(fmt.Sprintf("garbage collection finished: %s", report))
+				}
+			}
+		}()
+	}
+
+	if cfg.Lifecycle.Enabled {
+		go func() {
+			ticker := time.NewTicker(cfg.Lifecycle.FallbackInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				expired, err := fileStorageService.EnforceLifecycle(ctx)
+				if err != nil && ctx.Err() == nil {
+					log.Error(fmt.Sprintf("lifecycle enforcement stopped: %v", err))
+				}
+				if expired > 0 {
+					log.Info(fmt.Sprintf("expired %d objects by lifecycle rules", expired))
+				}
+			}
+		}()
+	}
+
+	if cfg.Replication.Enabled {
+		go func() {
+			ticker := time.NewTicker(cfg.Replication.PollInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				replicated, err := fileStorageService.Replicate(ctx)
+				if err != nil && ctx.Err() == nil {
+					log.Error(fmt.Sprintf("replication stopped after %d objects: %v", replicated, err))
+				}
+				if replicated > 0 {
+					log.Info(fmt.Sprintf("replicated %d objects", replicated))
+				}
+			}
+		}()
+	}
+
+	if cfg.Scrub.Enabled {
+		go func() {
+			ticker := time.NewTicker(cfg.Scrub.Interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				report, err := fileStorageService.Scrub(ctx, cfg.Scrub.ObjectsPerSecond)
+				if err != nil && ctx.Err() == nil {
+					log.Error(fmt.Sprintf("scrub stopped: %v", err))
+				}
+				switch {
+				case report == nil:
+				case report.Corrupt > 0:
+					log.Error(fmt.Sprintf("scrub found corrupt objects: %s", report))
+				default:
+					log.Info(fmt.Sprintf("scrub finished: %s", report))
+				}
+			}
+		}()
+	}
+
+	if cfg.Minio.Encryption.Rotate {
+		go func() {
+			log.Info("encryption rotation is starting")
+
+			report, err := fileStorageService.RotateEncryption(ctx)
+			if err != nil {
+				log.Error(fmt.Sprintf("encryption rotation stopped: %v", err))
+			}
+			if report != nil {
+				log.Info(fmt.Sprintf("encryption rotation finished: %s", report))
+			}
+		}()
+	}
+}
+
+// GCOptions turns the gc config into options for one pass.
+func GCOptions(cfg config.GC) models.GCOptions {
+	return models.GCOptions{
+		GracePeriod:      cfg.GracePeriod,
+		DryRun:           cfg.DryRun,
+		DeletesPerSecond: cfg.DeletesPerSecond,
+	}
+}
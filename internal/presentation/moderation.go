@@ -0,0 +1,116 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+	s3_v1 "github.com/acyushka/nbf-file-storage-service/pkg/pb/gen"
+
+	"github.com/hesoyamTM/nbf-auth/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPendingPageSize = 50
+	maxPendingPageSize     = 500
+)
+
+var fromPbDecision = map[s3_v1.ModerationDecision]models.ModerationState{
+	s3_v1.ModerationDecision_MODERATION_DECISION_APPROVE: models.ModerationApproved,
+	s3_v1.ModerationDecision_MODERATION_DECISION_REJECT:  models.ModerationRejected,
+}
+
+// ListPendingPhotos is the reviewer queue. Only internal callers may see it.
+func (s *MinioServer) ListPendingPhotos(ctx context.Context, req *s3_v1.ListPendingPhotosRequest) (*s3_v1.ListPendingPhotosResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: pending photos requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "moderation is reserved for internal callers")
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultPendingPageSize
+	}
+	pageSize = min(pageSize, maxPendingPageSize)
+
+	photos, nextPageToken, err := s.tenant(ctx).service.ListPendingPhotos(ctx, req.GetPageToken(), pageSize)
+	if errors.Is(err, service.ErrInvalidPageToken) {
+		log.Error("Error: invalid page token")
+		return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	if err != nil {
+		log.Error("Error: failed to list pending photos")
+		return nil, status.Errorf(codes.Internal, "failed to list pending photos: %v", err)
+	}
+
+	resp := &s3_v1.ListPendingPhotosResponse{
+		Photos:        make([]*s3_v1.PendingPhoto, 0, len(photos)),
+		NextPageToken: nextPageToken,
+	}
+	for _, photo := range photos {
+		resp.Photos = append(resp.Photos, &s3_v1.PendingPhoto{
+			UserId:      photo.UserID,
+			PhotoId:     photo.PhotoID,
+			Url:         photo.URL,
+			ContentType: photo.ContentType,
+			CreatedAt:   photo.CreatedAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+// ModeratePhoto records a reviewer's decision. Only internal callers may
+// make one.
+func (s *MinioServer) ModeratePhoto(ctx context.Context, req *s3_v1.ModeratePhotoRequest) (*s3_v1.ModeratePhotoResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: moderation requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "moderation is reserved for internal callers")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPhotoId() == "" {
+		log.Error("Error: photo_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "photo_id is required")
+	}
+
+	state, ok := fromPbDecision[req.GetDecision()]
+	if !ok {
+		log.Error("Error: decision is unspecified")
+		return nil, status.Error(codes.InvalidArgument, "decision must be approve or reject")
+	}
+
+	err = s.tenant(ctx).service.ModeratePhoto(ctx, req.GetUserId(), req.GetPhotoId(), state, req.GetReason())
+	if errors.Is(err, service.ErrInvalidModeration) {
+		log.Error("Error: invalid moderation decision")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, service.ErrPhotoNotFound) {
+		log.Error("Error: photo not found")
+		return nil, status.Error(codes.NotFound, "photo not found")
+	}
+	if err != nil {
+		log.Error("Error: failed to moderate photo")
+		return nil, status.Errorf(codes.Internal, "failed to moderate photo: %v", err)
+	}
+
+	log.Info("Photo moderated successfuly")
+
+	return &s3_v1.ModeratePhotoResponse{}, nil
+}
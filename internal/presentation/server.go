@@ -3,25 +3,74 @@ package grpc_server
 import (
 	"bytes"
 	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
 
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
 	"github.com/acyushka/nbf-file-storage-service/internal/models"
 	"github.com/acyushka/nbf-file-storage-service/internal/service"
+	"github.com/acyushka/nbf-file-storage-service/internal/tenancy"
 	s3_v1 "github.com/acyushka/nbf-file-storage-service/pkg/pb/gen"
 
 	"github.com/hesoyamTM/nbf-auth/pkg/logger"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 type MinioServer struct {
 	s3_v1.UnimplementedFileStorageServiceServer
-	service *service.MinioService
+	root          *tenantServer
+	tenants       map[string]*tenantServer
+	internalToken string
 }
 
-func NewMinioServer(service *service.MinioService) *MinioServer {
-	return &MinioServer{
-		service: service,
+// tenantServer is what requests acting for one tenant are served with.
+type tenantServer struct {
+	service   *service.MinioService
+	maxBatch  int
+	publicURL string
+	// maxUploadSize caps each uploaded file, or nothing when zero.
+	maxUploadSize int64
+}
+
+func NewMinioServer(service *service.MinioService, cfg *config.Config, tenants []*Tenant) *MinioServer {
+	s := &MinioServer{
+		root: &tenantServer{
+			service:   service,
+			maxBatch:  cfg.Batch.MaxPhotos,
+			publicURL: strings.TrimSuffix(cfg.HTTP.PublicURL, "/"),
+		},
+		tenants:       make(map[string]*tenantServer, len(tenants)),
+		internalToken: cfg.InternalToken,
+	}
+	for _, t := range tenants {
+		s.tenants[t.ID] = &tenantServer{
+			service:       t.Service,
+			maxBatch:      t.Config.Batch.MaxPhotos,
+			publicURL:     strings.TrimSuffix(t.Config.HTTP.PublicURL, "/"),
+			maxUploadSize: t.maxUploadSize,
+		}
+	}
+
+	return s
+}
+
+// tenant picks what to serve a request with by the tenant it acts for. A
+// tenant unknown here falls to root, whose storage refuses to act for it.
+func (s *MinioServer) tenant(ctx context.Context) *tenantServer {
+	if t, ok := s.tenants[tenancy.ID(ctx)]; ok {
+		return t
 	}
+
+	return s.root
+}
+
+// tooLarge reports whether an upload of size bytes is over the limit.
+func (t *tenantServer) tooLarge(size int) bool {
+	return t.maxUploadSize > 0 && int64(size) > t.maxUploadSize
 }
 
 func (s *MinioServer) UploadAvatar(ctx context.Context, req *s3_v1.UploadAvatarRequest) (*s3_v1.UploadAvatarResponse, error) {
@@ -40,9 +89,26 @@ func (s *MinioServer) UploadAvatar(ctx context.Context, req *s3_v1.UploadAvatarR
 		return nil, status.Error(codes.InvalidArgument, "file_data is required")
 	}
 
+	if s.tenant(ctx).tooLarge(len(req.FileData)) {
+		log.Error("Error: file_data is too large")
+		return nil, status.Error(codes.ResourceExhausted, "file_data is too large")
+	}
+
 	fileReader := bytes.NewReader(req.FileData)
 
-	photo_id, err := s.service.UploadAvatar(ctx, req.GetUserId(), fileReader, req.GetFileName(), int64(len(req.FileData)), req.GetContentType())
+	avatar, err := s.tenant(ctx).service.UploadAvatar(ctx, req.GetUserId(), fileReader, req.GetFileName(), int64(len(req.FileData)), req.GetContentType(), fromPbChecksums(req.GetChecksums()))
+	if err := checksumError(err); err != nil {
+		log.Error("Error: avatar failed checksum verification")
+		return nil, err
+	}
+	if err := scanError(err); err != nil {
+		log.Error("Error: avatar failed malware scan")
+		return nil, err
+	}
+	if errors.Is(err, service.ErrTooManyPixels) {
+		log.Error("Error: avatar has too many pixels")
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
 	if err != nil {
 		log.Error("Error: failed to upload avatar")
 		return nil, status.Errorf(codes.Internal, "failed to upload avatar: %v", err)
@@ -51,7 +117,8 @@ func (s *MinioServer) UploadAvatar(ctx context.Context, req *s3_v1.UploadAvatarR
 	log.Info("Avatar uploaded successfuly")
 
 	return &s3_v1.UploadAvatarResponse{
-		PhotoId: photo_id,
+		PhotoId:     avatar.URL,
+		Placeholder: toPbPlaceholder(avatar.Placeholder),
 	}, nil
 }
 
@@ -68,15 +135,36 @@ func (s *MinioServer) UploadPhotos(ctx context.Context, req *s3_v1.UploadPhotosR
 
 	photos := make([]models.PhotoData, len(req.Photos))
 	for i, pbPhoto := range req.Photos {
+		if s.tenant(ctx).tooLarge(len(pbPhoto.FileData)) {
+			log.Error("Error: file_data is too large")
+			return nil, status.Errorf(codes.ResourceExhausted, "photo %d is too large", i)
+		}
 		photos[i] = models.PhotoData{
 			Data:        bytes.NewReader(pbPhoto.FileData),
 			FileSize:    int64(len(pbPhoto.FileData)),
 			FileName:    pbPhoto.FileName,
 			ContentType: pbPhoto.ContentType,
+			Checksums:   fromPbChecksums(pbPhoto.GetChecksums()),
 		}
 	}
 
-	photo_ids, err := s.service.UploadPhotos(ctx, req.GetUserId(), photos)
+	uploaded, err := s.tenant(ctx).service.UploadPhotos(ctx, req.GetUserId(), photos, req.GetRejectDuplicates())
+	if err := checksumError(err); err != nil {
+		log.Error("Error: photos failed checksum verification")
+		return nil, err
+	}
+	if err := scanError(err); err != nil {
+		log.Error("Error: photos failed malware scan")
+		return nil, err
+	}
+	if errors.Is(err, service.ErrTooManyPixels) {
+		log.Error("Error: photo has too many pixels")
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if errors.Is(err, service.ErrNearDuplicate) {
+		log.Error("Error: near-duplicate photos in batch")
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
 	if err != nil {
 		log.Error("Error: failed to upload photos")
 		return nil, status.Errorf(codes.Internal, "failed to upload photos: %v", err)
@@ -84,8 +172,20 @@ func (s *MinioServer) UploadPhotos(ctx context.Context, req *s3_v1.UploadPhotosR
 
 	log.Info("All photos uploaded successfuly")
 
+	photo_ids := make([]string, len(uploaded))
+	placeholders := make([]*s3_v1.Placeholder, len(uploaded))
+	for i, photo := range uploaded {
+		photo_ids[i] = photo.PhotoID
+		// Repeated fields can't hold nil, so non-image uploads get an empty placeholder.
+		placeholders[i] = &s3_v1.Placeholder{}
+		if photo.Placeholder != nil {
+			placeholders[i] = toPbPlaceholder(photo.Placeholder)
+		}
+	}
+
 	return &s3_v1.UploadPhotosResponse{
-		PhotoIds: photo_ids,
+		PhotoIds:     photo_ids,
+		Placeholders: placeholders,
 	}, nil
 }
 
@@ -107,13 +207,450 @@ func (s *MinioServer) GetPhotoURL(ctx context.Context, req *s3_v1.GetPhotoURLReq
 		return nil, status.Error(codes.InvalidArgument, "photo_id is required")
 	}
 
-	url, err := s.service.GetPhotoURL(ctx, UserID, PhotoID)
+	ctx = service.WithRequester(ctx, req.GetRequesterId())
+	photoURL, err := s.tenant(ctx).service.GetPhotoURL(ctx, UserID, PhotoID, req.GetAcceptFormats())
+	if errors.Is(err, service.ErrPhotoNotFound) {
+		log.Error("Error: photo not found")
+		return nil, status.Error(codes.NotFound, "photo not found")
+	}
+	if errors.Is(err, service.ErrScanPending) {
+		log.Error("Error: photo is still being scanned")
+		return nil, status.Error(codes.FailedPrecondition, "photo is still being scanned")
+	}
+	if errors.Is(err, service.ErrModerationPending) {
+		log.Error("Error: photo is awaiting moderation")
+		return nil, status.Error(codes.FailedPrecondition, "photo is awaiting moderation")
+	}
 	if err != nil {
 		log.Error("Error: failed to get presigned url")
 		return nil, status.Errorf(codes.Internal, "failed to get presigned url: %v", err)
 	}
 
 	return &s3_v1.GetPhotoURLResponse{
-		Url: url,
+		Url:         photoURL.URL,
+		Placeholder: toPbPlaceholder(photoURL.Placeholder),
+		ContentType: photoURL.ContentType,
 	}, nil
 }
+
+func (s *MinioServer) DeletePhoto(ctx context.Context, req *s3_v1.DeletePhotoRequest) (*s3_v1.DeletePhotoResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPhotoId() == "" {
+		log.Error("Error: photo_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "photo_id is required")
+	}
+
+	err = s.tenant(ctx).service.DeletePhoto(ctx, req.GetUserId(), req.GetPhotoId())
+	if errors.Is(err, service.ErrPhotoNotFound) {
+		log.Error("Error: photo not found")
+		return nil, status.Error(codes.NotFound, "photo not found")
+	}
+	if err != nil {
+		log.Error("Error: failed to delete photo")
+		return nil, status.Errorf(codes.Internal, "failed to delete photo: %v", err)
+	}
+
+	log.Info("Photo deleted successfuly")
+
+	return &s3_v1.DeletePhotoResponse{}, nil
+}
+
+func (s *MinioServer) FindSimilarPhotos(ctx context.Context, req *s3_v1.FindSimilarPhotosRequest) (*s3_v1.FindSimilarPhotosResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPhotoId() == "" {
+		log.Error("Error: photo_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "photo_id is required")
+	}
+
+	similar, err := s.tenant(ctx).service.FindSimilarPhotos(ctx, req.GetUserId(), req.GetPhotoId(), int(req.GetThreshold()))
+	if errors.Is(err, service.ErrPhotoNotFound) {
+		log.Error("Error: photo not found")
+		return nil, status.Error(codes.NotFound, "photo not found")
+	}
+	if err != nil {
+		log.Error("Error: failed to find similar photos")
+		return nil, status.Errorf(codes.Internal, "failed to find similar photos: %v", err)
+	}
+
+	photos := make([]*s3_v1.SimilarPhoto, len(similar))
+	for i, photo := range similar {
+		photos[i] = &s3_v1.SimilarPhoto{
+			PhotoId:  photo.PhotoID,
+			Distance: int32(photo.Distance),
+		}
+	}
+
+	return &s3_v1.FindSimilarPhotosResponse{
+		Photos: photos,
+	}, nil
+}
+
+func (s *MinioServer) GetPhotoURLs(ctx context.Context, req *s3_v1.GetPhotoURLsRequest) (*s3_v1.GetPhotoURLsResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if len(req.GetPhotoIds()) == 0 {
+		log.Error("Error: photo_ids is empty")
+		return nil, status.Error(codes.InvalidArgument, "photo_ids is required")
+	}
+	if len(req.GetPhotoIds()) > s.tenant(ctx).maxBatch {
+		log.Error("Error: too many photo_ids")
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d photo_ids per request", s.tenant(ctx).maxBatch)
+	}
+
+	skipExistenceCheck := req.GetSkipExistenceCheck()
+	if skipExistenceCheck && !s.isInternalCaller(ctx) {
+		log.Error("Error: untrusted caller asked to skip existence check")
+		return nil, status.Error(codes.PermissionDenied, "skip_existence_check is reserved for internal callers")
+	}
+
+	ctx = service.WithRequester(ctx, req.GetRequesterId())
+	results := s.tenant(ctx).service.GetPhotoURLs(ctx, req.GetUserId(), req.GetPhotoIds(), req.GetAcceptFormats(), skipExistenceCheck)
+
+	pbResults := make([]*s3_v1.PhotoURLResult, len(results))
+	for i, result := range results {
+		pbResult := &s3_v1.PhotoURLResult{
+			PhotoId: result.PhotoID,
+			Code:    int32(codes.OK),
+		}
+
+		switch {
+		case errors.Is(result.Err, service.ErrPhotoNotFound):
+			pbResult.Code = int32(codes.NotFound)
+			pbResult.Error = "photo not found"
+		case errors.Is(result.Err, service.ErrScanPending):
+			pbResult.Code = int32(codes.FailedPrecondition)
+			pbResult.Error = "photo is still being scanned"
+		case errors.Is(result.Err, service.ErrModerationPending):
+			pbResult.Code = int32(codes.FailedPrecondition)
+			pbResult.Error = "photo is awaiting moderation"
+		case result.Err != nil:
+			pbResult.Code = int32(codes.Internal)
+			pbResult.Error = result.Err.Error()
+		default:
+			pbResult.Url = result.PhotoURL.URL
+			pbResult.ContentType = result.PhotoURL.ContentType
+			pbResult.Placeholder = toPbPlaceholder(result.PhotoURL.Placeholder)
+		}
+
+		pbResults[i] = pbResult
+	}
+
+	return &s3_v1.GetPhotoURLsResponse{
+		Results: pbResults,
+	}, nil
+}
+
+func (s *MinioServer) SetPhotoVisibility(ctx context.Context, req *s3_v1.SetPhotoVisibilityRequest) (*s3_v1.SetPhotoVisibilityResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPhotoId() == "" {
+		log.Error("Error: photo_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "photo_id is required")
+	}
+
+	visibility, ok := fromPbVisibility[req.GetVisibility()]
+	if !ok {
+		log.Error("Error: visibility is not set")
+		return nil, status.Error(codes.InvalidArgument, "visibility is required")
+	}
+
+	err = s.tenant(ctx).service.SetPhotoVisibility(ctx, req.GetUserId(), req.GetPhotoId(), visibility)
+	if errors.Is(err, service.ErrPhotoNotFound) {
+		log.Error("Error: photo not found")
+		return nil, status.Error(codes.NotFound, "photo not found")
+	}
+	if err != nil {
+		log.Error("Error: failed to set photo visibility")
+		return nil, status.Errorf(codes.Internal, "failed to set photo visibility: %v", err)
+	}
+
+	return &s3_v1.SetPhotoVisibilityResponse{}, nil
+}
+
+func (s *MinioServer) CreateShareLink(ctx context.Context, req *s3_v1.CreateShareLinkRequest) (*s3_v1.CreateShareLinkResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPhotoId() == "" {
+		log.Error("Error: photo_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "photo_id is required")
+	}
+	if req.GetExpiresInSeconds() < 0 || req.GetMaxAccesses() < 0 {
+		log.Error("Error: negative share link limits")
+		return nil, status.Error(codes.InvalidArgument, "expires_in_seconds and max_accesses must not be negative")
+	}
+
+	link, err := s.tenant(ctx).service.CreateShareLink(
+		ctx,
+		req.GetUserId(),
+		req.GetPhotoId(),
+		time.Duration(req.GetExpiresInSeconds())*time.Second,
+		int(req.GetMaxAccesses()),
+	)
+	if errors.Is(err, service.ErrPhotoNotFound) {
+		log.Error("Error: photo not found")
+		return nil, status.Error(codes.NotFound, "photo not found")
+	}
+	if errors.Is(err, service.ErrPhotoPrivate) {
+		log.Error("Error: photo is private")
+		return nil, status.Error(codes.FailedPrecondition, "photo is private, set visibility to link or public first")
+	}
+	if err != nil {
+		log.Error("Error: failed to create share link")
+		return nil, status.Errorf(codes.Internal, "failed to create share link: %v", err)
+	}
+
+	log.Info("Share link created successfuly")
+
+	resp := &s3_v1.CreateShareLinkResponse{
+		Token: link.Token,
+		Url:   s.tenant(ctx).publicURL + "/s/" + link.Token,
+	}
+	if !link.ExpiresAt.IsZero() {
+		resp.ExpiresAt = link.ExpiresAt.Unix()
+	}
+
+	return resp, nil
+}
+
+func (s *MinioServer) RevokeShareLink(ctx context.Context, req *s3_v1.RevokeShareLinkRequest) (*s3_v1.RevokeShareLinkResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetToken() == "" {
+		log.Error("Error: token is empty")
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	err = s.tenant(ctx).service.RevokeShareLink(ctx, req.GetUserId(), req.GetToken())
+	if errors.Is(err, service.ErrShareNotFound) {
+		log.Error("Error: share link not found")
+		return nil, status.Error(codes.NotFound, "share link not found")
+	}
+	if err != nil {
+		log.Error("Error: failed to revoke share link")
+		return nil, status.Errorf(codes.Internal, "failed to revoke share link: %v", err)
+	}
+
+	log.Info("Share link revoked successfuly")
+
+	return &s3_v1.RevokeShareLinkResponse{}, nil
+}
+
+func (s *MinioServer) GetImageURL(ctx context.Context, req *s3_v1.GetImageURLRequest) (*s3_v1.GetImageURLResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPhotoId() == "" {
+		log.Error("Error: photo_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "photo_id is required")
+	}
+
+	path, expiresAt, err := s.tenant(ctx).service.SignImagePath(req.GetUserId(), req.GetPhotoId(), models.ImageOptions{
+		Width:  int(req.GetWidth()),
+		Height: int(req.GetHeight()),
+		Fit:    req.GetFit(),
+		Format: req.GetFormat(),
+	})
+	if errors.Is(err, service.ErrInvalidImageOptions) {
+		log.Error("Error: invalid image options")
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if errors.Is(err, service.ErrProxyDisabled) {
+		log.Error("Error: url signing is disabled")
+		return nil, status.Error(codes.FailedPrecondition, "url signing is disabled")
+	}
+	if err != nil {
+		log.Error("Error: failed to sign image url")
+		return nil, status.Errorf(codes.Internal, "failed to sign image url: %v", err)
+	}
+
+	return &s3_v1.GetImageURLResponse{
+		Url:       s.tenant(ctx).publicURL + path,
+		ExpiresAt: expiresAt.Unix(),
+	}, nil
+}
+
+func (s *MinioServer) GetDownloadURL(ctx context.Context, req *s3_v1.GetDownloadURLRequest) (*s3_v1.GetDownloadURLResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPhotoId() == "" {
+		log.Error("Error: photo_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "photo_id is required")
+	}
+
+	path, expiresAt, err := s.tenant(ctx).service.SignDownloadPath(req.GetUserId(), req.GetPhotoId())
+	if errors.Is(err, service.ErrProxyDisabled) {
+		log.Error("Error: url signing is disabled")
+		return nil, status.Error(codes.FailedPrecondition, "url signing is disabled")
+	}
+	if err != nil {
+		log.Error("Error: failed to sign download url")
+		return nil, status.Errorf(codes.Internal, "failed to sign download url: %v", err)
+	}
+
+	return &s3_v1.GetDownloadURLResponse{
+		Url:       s.tenant(ctx).publicURL + path,
+		ExpiresAt: expiresAt.Unix(),
+	}, nil
+}
+
+// CreateUploadURL hands out a presigned URL for uploading a photo straight
+// to the bucket. The photo shows up once the upload has been processed.
+func (s *MinioServer) CreateUploadURL(ctx context.Context, req *s3_v1.CreateUploadURLRequest) (*s3_v1.CreateUploadURLResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	upload, err := s.tenant(ctx).service.CreateUploadURL(ctx, req.GetUserId(), req.GetFileName())
+	if errors.Is(err, service.ErrDirectUploadsDisabled) {
+		log.Error("Error: direct uploads are disabled")
+		return nil, status.Error(codes.FailedPrecondition, "direct uploads are disabled")
+	}
+	if err != nil {
+		log.Error("Error: failed to create upload url")
+		return nil, status.Errorf(codes.Internal, "failed to create upload url: %v", err)
+	}
+
+	return &s3_v1.CreateUploadURLResponse{
+		PhotoId:   upload.PhotoID,
+		Url:       upload.URL,
+		ExpiresAt: upload.ExpiresAt.Unix(),
+	}, nil
+}
+
+// checksumError maps upload checksum failures onto gRPC codes, returning
+// nil for other errors.
+func checksumError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidChecksum):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, service.ErrChecksumMismatch):
+		return status.Errorf(codes.DataLoss, "%v", err)
+	case errors.Is(err, service.ErrDamagedInTransit):
+		return status.Error(codes.Unavailable, "upload was damaged on its way to storage, retry")
+	default:
+		return nil
+	}
+}
+
+// scanError maps malware scan rejections onto gRPC errors, or returns
+// nil for anything else.
+func scanError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInfected), errors.Is(err, service.ErrTooLargeToScan):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, service.ErrScannerUnavailable):
+		return status.Error(codes.Unavailable, "virus scanner unavailable, retry later")
+	default:
+		return nil
+	}
+}
+
+var fromPbVisibility = map[s3_v1.Visibility]models.Visibility{
+	s3_v1.Visibility_VISIBILITY_PRIVATE: models.VisibilityPrivate,
+	s3_v1.Visibility_VISIBILITY_LINK:    models.VisibilityLink,
+	s3_v1.Visibility_VISIBILITY_PUBLIC:  models.VisibilityPublic,
+}
+
+// isInternalCaller reports whether the request carries the configured
+// internal token. With no token configured nobody is trusted.
+func (s *MinioServer) isInternalCaller(ctx context.Context) bool {
+	if s.internalToken == "" {
+		return false
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+
+	for _, token := range md.Get("x-internal-token") {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.internalToken)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toPbPlaceholder(p *models.Placeholder) *s3_v1.Placeholder {
+	if p == nil {
+		return nil
+	}
+
+	return &s3_v1.Placeholder{
+		Blurhash:      p.BlurHash,
+		DominantColor: p.DominantColor,
+		Width:         int32(p.Width),
+		Height:        int32(p.Height),
+	}
+}
+
+func fromPbChecksums(c *s3_v1.Checksums) models.Checksums {
+	return models.Checksums{
+		SHA256: c.GetSha256(),
+		CRC32C: c.GetCrc32C(),
+	}
+}
@@ -2,12 +2,21 @@ package grpc_server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 
+	"github.com/acyushka/nbf-file-storage-service/internal/broker"
 	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/events"
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/moderation"
+	"github.com/acyushka/nbf-file-storage-service/internal/notifications"
+	"github.com/acyushka/nbf-file-storage-service/internal/outbox"
+	"github.com/acyushka/nbf-file-storage-service/internal/scanner"
 	"github.com/acyushka/nbf-file-storage-service/internal/service"
 	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+	"github.com/acyushka/nbf-file-storage-service/internal/webhooks"
 	s3_v1 "github.com/acyushka/nbf-file-storage-service/pkg/pb/gen"
 
 	"github.com/hesoyamTM/nbf-auth/pkg/logger"
@@ -21,8 +30,39 @@ type GrpcServer struct {
 	port   int
 }
 
-func NewGrpcServer(ctx context.Context, cfg *config.Config) (*GrpcServer, error) {
-	const op = "grpc.NewGrpcServer"
+// NewService wires up the service of the deployment's own product and one
+// for each configured tenant, bringing bucket configuration in line with
+// cfg on the way.
+func NewService(ctx context.Context, cfg *config.Config) (*service.MinioService, []*Tenant, error) {
+	return newServices(ctx, cfg, true)
+}
+
+// NewToolService is NewService for one-shot commands, which must not
+// change bucket policy, encryption or lifecycle behind the server's back.
+func NewToolService(ctx context.Context, cfg *config.Config) (*service.MinioService, []*Tenant, error) {
+	return newServices(ctx, cfg, false)
+}
+
+func newServices(ctx context.Context, cfg *config.Config, reconcile bool) (*service.MinioService, []*Tenant, error) {
+	const op = "grpc.NewService"
+
+	encryption, err := service.EncryptionOptions(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	buckets, err := service.Buckets(cfg, encryption)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var tenants []config.Tenant
+	if cfg.Tenancy.Enabled {
+		if err := service.ValidateTenancy(cfg); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tenants = cfg.Tenancy.Tenants
+	}
 
 	//init storage
 	storageClient, err := storage.NewMinioClient(
@@ -32,16 +72,217 @@ func NewGrpcServer(ctx context.Context, cfg *config.Config) (*GrpcServer, error)
 		cfg.Minio.SecretKey,
 		cfg.Minio.UseSSL,
 		cfg.Minio.BucketName,
+		service.TenantPublicPaths(service.PublicPaths(cfg.Minio.Visibility), tenants),
+		encryption,
+		cfg.Minio.Versioning,
+		buckets,
 	)
 	if err != nil {
 		panic(fmt.Errorf("%s: %w", op, err))
 	}
+	if cfg.Tenancy.Enabled {
+		storageClient.ReserveForTenants(service.TenantsRoot)
+	}
+
+	var rules []storage.LifecycleRule
+	if cfg.Lifecycle.Enabled {
+		rules, err = service.LifecycleRules(cfg.Lifecycle)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var enforcedLifecycle []storage.LifecycleRule
+	if reconcile {
+		enforcedLifecycle, err = reconcileStorage(ctx, cfg, storageClient, service.TenantLifecycleRules(rules, tenants))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	shared, err := newCollaborators(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	shared.reconcile = reconcile
+
+	// Tenant views made below share the replication of the client.
+	if shared.replica != nil {
+		if err := storageClient.ReplicateTo(shared.replica, service.ReplicationOptions(cfg.Replication)); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var source notifications.Source
+	if cfg.Notifications.Enabled {
+		source, err = service.NewNotificationSource(cfg.Notifications, storageClient)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
 
 	//init service
-	fileStorageService := service.NewMinioService(storageClient, cfg.PresignedUrl.ExpiryHours)
+	fileStorageService, err := newService(ctx, cfg, shared, service.Dependencies{
+		Storage:       storageClient,
+		Notifications: source,
+		Lifecycle:     enforcedLifecycle,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	served := make([]*Tenant, 0, len(tenants))
+	for _, t := range tenants {
+		tenant, err := newTenant(ctx, cfg, t, storageClient, encryption, rules, shared)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: tenant %s: %w", op, t.ID, err)
+		}
+		served = append(served, tenant)
+	}
+
+	return fileStorageService, served, nil
+}
+
+// collaborators are shared by the services of every tenant.
+type collaborators struct {
+	documentKeys storage.KeyWrapper
+	scanner      scanner.Scanner
+	classifier   moderation.Classifier
+	broker       broker.Broker
+	replica      storage.Replica
+	// reconcile is unset for one-shot commands, which leave bucket
+	// configuration alone.
+	reconcile bool
+}
+
+func newCollaborators(cfg *config.Config) (*collaborators, error) {
+	var shared collaborators
+	var err error
+
+	if cfg.Documents.Enabled {
+		shared.documentKeys, err = service.DocumentKeys(cfg.Documents)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shared.scanner, err = service.NewScanner(cfg.Scanning)
+	if err != nil {
+		return nil, err
+	}
+
+	shared.classifier, err = service.NewClassifier(cfg.Moderation)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Outbox.Enabled {
+		shared.broker, err = service.NewBroker(cfg.Outbox)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shared.replica, err = service.NewReplica(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &shared, nil
+}
+
+// reconcileStorage brings bucket policy, encryption and lifecycle in line
+// with cfg, and returns the lifecycle rules left to enforce in-process.
+func reconcileStorage(ctx context.Context, cfg *config.Config, storageClient *storage.MinioClient, rules []storage.LifecycleRule) ([]storage.LifecycleRule, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policyChange, err := storageClient.ReconcilePolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if policyChange.Changed() {
+		log.Info(fmt.Sprintf("bucket policy drifted and was reconciled: %s", policyChange))
+	}
+
+	if err := storageClient.ReconcileEncryption(ctx); err != nil {
+		return nil, err
+	}
+
+	if !cfg.Lifecycle.Enabled {
+		return nil, nil
+	}
+
+	var enforcedLifecycle []storage.LifecycleRule
+	lifecycleChange, err := storageClient.ReconcileLifecycle(ctx, rules)
+	switch {
+	case errors.Is(err, storage.ErrLifecycleUnsupported) && cfg.Lifecycle.Fallback != "never":
+		log.Info("bucket lifecycle is not supported by the backend, expiring objects in-process")
+		enforcedLifecycle = rules
+	case err != nil:
+		return nil, err
+	case lifecycleChange.Changed():
+		log.Info(fmt.Sprintf("bucket lifecycle drifted and was reconciled: %s", lifecycleChange))
+	}
+	if cfg.Lifecycle.Fallback == "always" {
+		enforcedLifecycle = rules
+	}
+
+	return enforcedLifecycle, nil
+}
+
+// newService fills in the collaborators deps leaves out and builds the
+// service over them.
+func newService(ctx context.Context, cfg *config.Config, shared *collaborators, deps service.Dependencies) (*service.MinioService, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if shared.documentKeys != nil {
+		deps.Documents = storage.NewEnvelopeStore(deps.Storage, shared.documentKeys, cfg.Documents.ChunkSize)
+	}
+	deps.Scanner = shared.scanner
+	deps.Classifier = shared.classifier
+
+	bus := events.NewBus()
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		log.Info(fmt.Sprintf("event %s for %s: %+v", event.Type, event.Key, event.Payload))
+	})
+	deps.Events = bus
+
+	// Webhooks are fed from the outbox, so they need one even without a
+	// broker.
+	if shared.broker != nil || cfg.Webhooks.Enabled {
+		deps.Outbox = outbox.New(deps.Storage, shared.broker, cfg.Outbox.SubjectPrefix, cfg.Outbox.BatchSize, cfg.Outbox.CommitTimeout)
+	}
+
+	if cfg.Webhooks.Enabled {
+		dispatcher := webhooks.NewDispatcher(deps.Storage, cfg.Webhooks.Workers, cfg.Webhooks.Timeout, cfg.Webhooks.PollInterval, webhooks.RetryPolicy{
+			MaxAttempts:    cfg.Webhooks.MaxAttempts,
+			InitialBackoff: cfg.Webhooks.InitialBackoff,
+			MaxBackoff:     cfg.Webhooks.MaxBackoff,
+		})
+		deps.Outbox.Forward(func(ctx context.Context, entry outbox.Entry) error {
+			if err := dispatcher.Enqueue(ctx, entry.ID, entry.Subject, entry.CreatedAt, entry.Payload); err != nil {
+				metrics.WebhookEnqueueFailures.Add(1)
+				return err
+			}
+			return nil
+		})
+		deps.Webhooks = dispatcher
+	}
+
+	return service.NewMinioService(deps, cfg), nil
+}
+
+func NewGrpcServer(ctx context.Context, cfg *config.Config, fileStorageService *service.MinioService, tenants []*Tenant) (*GrpcServer, error) {
+	const op = "grpc.NewGrpcServer"
 
 	//init server
-	fileStorageServer := NewMinioServer(fileStorageService)
+	fileStorageServer := NewMinioServer(fileStorageService, cfg, tenants)
 
 	//create grpc server
 	logInterceptor, err := logger.NewLoggingInterceptor(ctx)
@@ -49,7 +290,12 @@ func NewGrpcServer(ctx context.Context, cfg *config.Config) (*GrpcServer, error)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	server := grpc.NewServer(grpc.UnaryInterceptor(logInterceptor))
+	interceptors := []grpc.UnaryServerInterceptor{logInterceptor, idInterceptor()}
+	if cfg.Tenancy.Enabled {
+		interceptors = append(interceptors, tenantInterceptor(cfg.Tenancy, tenants))
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
 
 	//init FileStorageService
 	s3_v1.RegisterFileStorageServiceServer(server, fileStorageServer)
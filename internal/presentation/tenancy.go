@@ -0,0 +1,376 @@
+package grpc_server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/notifications"
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+	"github.com/acyushka/nbf-file-storage-service/internal/tenancy"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Tenant is another product served from this deployment, with a config
+// and service of its own.
+type Tenant struct {
+	ID      string
+	Config  *config.Config
+	Service *service.MinioService
+	// Bucket is set for a tenant with a bucket of its own.
+	Bucket        string
+	token         string
+	maxUploadSize int64
+}
+
+func newTenant(ctx context.Context, cfg *config.Config, t config.Tenant, root *storage.MinioClient, encryption storage.Encryption, rules []storage.LifecycleRule, shared *collaborators) (*Tenant, error) {
+	tenantCfg := tenantConfig(cfg, t)
+
+	deps := service.Dependencies{Tenant: t.ID}
+	if t.Bucket == "" {
+		deps.Storage = root.ForTenant(t.ID, service.TenantPrefix(t.ID))
+	} else {
+		encryption.KeyScope = service.TenantKeyScope(t.ID)
+		storageClient, err := storage.NewMinioClient(
+			cfg.Minio.Endpoint,
+			cfg.Minio.PublicURL,
+			cfg.Minio.AccessKey,
+			cfg.Minio.SecretKey,
+			cfg.Minio.UseSSL,
+			t.Bucket,
+			service.PublicPaths(cfg.Minio.Visibility),
+			encryption,
+			cfg.Minio.Versioning,
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if shared.reconcile {
+			deps.Lifecycle, err = reconcileStorage(ctx, tenantCfg, storageClient, rules)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if shared.replica != nil {
+			if err := storageClient.ReplicateTo(shared.replica, service.ReplicationOptions(cfg.Replication)); err != nil {
+				return nil, err
+			}
+		}
+		deps.Storage = storageClient.ForTenant(t.ID, "")
+
+		// A replay file stands in for the deployment's own bucket only.
+		if cfg.Notifications.Enabled && cfg.Notifications.Source != "replay" {
+			deps.Notifications, err = service.NewNotificationSource(cfg.Notifications, deps.Storage)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tenantService, err := newService(ctx, tenantCfg, shared, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tenant{
+		ID:            t.ID,
+		Config:        tenantCfg,
+		Service:       tenantService,
+		Bucket:        t.Bucket,
+		token:         t.Token,
+		maxUploadSize: t.MaxUploadSize,
+	}, nil
+}
+
+// tenantConfig is cfg as it applies to one tenant.
+func tenantConfig(cfg *config.Config, t config.Tenant) *config.Config {
+	tenantCfg := *cfg
+	tenantCfg.HTTP.PublicURL = t.PublicURL
+	// URLs and receipts signed for one tenant must not verify for another.
+	tenantCfg.HTTP.SigningSecret = tenantSecret(cfg.HTTP.SigningSecret, t.ID)
+	tenantCfg.Privacy.ReceiptSecret = tenantSecret(cfg.Privacy.ReceiptSecret, t.ID)
+	tenantCfg.Outbox.SubjectPrefix = cfg.Outbox.SubjectPrefix + t.ID + "."
+	// Routed buckets hold the deployment's own objects only.
+	tenantCfg.Minio.Buckets = nil
+
+	if t.Bucket != "" {
+		tenantCfg.Minio.BucketName = t.Bucket
+	} else {
+		// Encryption rotation, lifecycle rules and replication of the
+		// shared bucket cover the tenant's prefix already.
+		tenantCfg.Minio.Encryption.Rotate = false
+		tenantCfg.Lifecycle.Enabled = false
+		tenantCfg.Replication.Enabled = false
+	}
+
+	if t.PresignedExpiryHours > 0 {
+		tenantCfg.PresignedUrl.ExpiryHours = t.PresignedExpiryHours
+	}
+	if t.MaxBatchPhotos > 0 {
+		tenantCfg.Batch.MaxPhotos = t.MaxBatchPhotos
+	}
+	if t.MaxUploadSize > 0 {
+		tenantCfg.Notifications.DirectUploadMaxSize = t.MaxUploadSize
+	}
+
+	return &tenantCfg
+}
+
+func tenantSecret(secret string, tenantID string) string {
+	if secret == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("tenant:" + tenantID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// tenantInterceptor resolves the tenant a request acts for and marks its
+// context with it. Requests naming no tenant act for the deployment's own
+// product unless a tenant is required.
+func tenantInterceptor(cfg config.Tenancy, tenants []*Tenant) grpc.UnaryServerInterceptor {
+	byID := make(map[string]*Tenant, len(tenants))
+	for _, t := range tenants {
+		byID[t.ID] = t
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var tenantID string
+		var err error
+		switch cfg.Source {
+		case "claims":
+			tenantID, err = claimsTenant(ctx, []byte(cfg.ClaimsSecret), cfg.Claim)
+		default:
+			tenantID, err = metadataTenant(ctx, byID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if tenantID == "" {
+			if cfg.RequireTenant {
+				return nil, status.Error(codes.Unauthenticated, "tenant is required")
+			}
+			return handler(ctx, req)
+		}
+
+		if _, ok := byID[tenantID]; !ok {
+			return nil, status.Error(codes.PermissionDenied, "unknown tenant")
+		}
+
+		return handler(tenancy.WithID(ctx, tenantID), req)
+	}
+}
+
+// metadataTenant reads the tenant from x-tenant-id, which only counts with
+// the tenant's token in x-tenant-token.
+func metadataTenant(ctx context.Context, tenants map[string]*Tenant) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+
+	ids := md.Get("x-tenant-id")
+	switch {
+	case len(ids) == 0:
+		return "", nil
+	case len(ids) > 1:
+		return "", status.Error(codes.InvalidArgument, "more than one tenant")
+	}
+
+	t, ok := tenants[ids[0]]
+	if !ok {
+		return "", status.Error(codes.PermissionDenied, "unknown tenant")
+	}
+
+	for _, token := range md.Get("x-tenant-token") {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.token)) == 1 {
+			return t.ID, nil
+		}
+	}
+
+	return "", status.Error(codes.Unauthenticated, "invalid tenant token")
+}
+
+// claimsTenant reads the tenant from a claim of the HS256 bearer token in
+// the authorization metadata key.
+func claimsTenant(ctx context.Context, secret []byte, claim string) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "authorization is not a bearer token")
+	}
+
+	claims, err := verifyClaims(token, secret, time.Now())
+	if err != nil {
+		return "", status.Errorf(codes.Unauthenticated, "invalid bearer token: %v", err)
+	}
+
+	tenantID, _ := claims[claim].(string)
+	return tenantID, nil
+}
+
+// verifyClaims checks the signature and validity period of an HS256 JSON
+// web token and returns its claims.
+func verifyClaims(token string, secret []byte, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, errors.New("unsupported signing algorithm")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New("signature mismatch")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if exp, ok := claims["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		return nil, errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return nil, errors.New("token is not valid yet")
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("malformed token")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("malformed token")
+	}
+
+	return nil
+}
+
+// routeBucketEvent passes each bucket event to the service of the tenant
+// whose namespace the object is in, or to root for the deployment's own.
+func routeBucketEvent(root *service.MinioService, tenants []*Tenant) notifications.Handler {
+	return func(ctx context.Context, event models.BucketEvent) error {
+		for _, t := range tenants {
+			prefix := service.TenantPrefix(t.ID)
+			switch {
+			case t.Bucket != "":
+				if event.Bucket != t.Bucket {
+					continue
+				}
+			case strings.HasPrefix(event.Key, prefix):
+				event.Key = strings.TrimPrefix(event.Key, prefix)
+			default:
+				continue
+			}
+
+			return t.Service.HandleBucketEvent(tenancy.WithID(ctx, t.ID), event)
+		}
+
+		// Whatever is left under the tenants root belongs to a tenant no
+		// longer configured.
+		if strings.HasPrefix(event.Key, service.TenantsRoot) {
+			return nil
+		}
+
+		return root.HandleBucketEvent(ctx, event)
+	}
+}
+
+type tenantMount struct {
+	tenantID string
+	host     string
+	path     string
+	handler  http.Handler
+}
+
+// tenantHandler serves the public routes of each tenant where its public
+// URL points: under its path, or on its host when the URL has no path.
+// Everything else goes to next.
+func tenantHandler(next http.Handler, tenants []*Tenant) http.Handler {
+	var mounts []tenantMount
+	for _, t := range tenants {
+		// ValidateTenancy has parsed the URL already.
+		publicURL, _ := url.Parse(t.Config.HTTP.PublicURL)
+
+		s := &HttpServer{
+			service:     t.Service,
+			cacheMaxAge: t.Config.ImageProxy.CacheMaxAge,
+		}
+		mux := http.NewServeMux()
+		s.routes(mux)
+
+		mount := tenantMount{
+			tenantID: t.ID,
+			path:     strings.TrimSuffix(publicURL.Path, "/"),
+			handler:  mux,
+		}
+		if mount.path == "" {
+			mount.host = publicURL.Host
+		} else {
+			mount.handler = http.StripPrefix(mount.path, mux)
+		}
+		mounts = append(mounts, mount)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, m := range mounts {
+			if m.host != "" && r.Host != m.host {
+				continue
+			}
+			if m.path != "" && !strings.HasPrefix(r.URL.Path, m.path+"/") {
+				continue
+			}
+
+			m.handler.ServeHTTP(w, r.WithContext(tenancy.WithID(r.Context(), m.tenantID)))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,134 @@
+package grpc_server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/tenancy"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var claimsSecret = []byte("claims secret")
+
+// signToken builds a JSON web token with the given header and claims,
+// signed with secret.
+func signToken(t *testing.T, header map[string]any, claims map[string]any, secret []byte) string {
+	t.Helper()
+
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	unsigned := segment(header) + "." + segment(claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// unsignedToken claims tenant a with "alg": "none" and no signature.
+func unsignedToken(t *testing.T) string {
+	t.Helper()
+
+	token := signToken(t, map[string]any{"alg": "none"}, map[string]any{"tenant": "a"}, nil)
+	return token[:strings.LastIndex(token, ".")+1]
+}
+
+func TestVerifyClaims(t *testing.T) {
+	now := time.Now()
+	hs256 := map[string]any{"alg": "HS256", "typ": "JWT"}
+	valid := signToken(t, hs256, map[string]any{"tenant": "a", "exp": now.Add(time.Hour).Unix()}, claimsSecret)
+
+	claims, err := verifyClaims(valid, claimsSecret, now)
+	if err != nil || claims["tenant"] != "a" {
+		t.Fatalf("valid token gave %v, %v", claims, err)
+	}
+
+	// b's claims under the signature of a's.
+	parts := strings.Split(valid, ".")
+	parts[1] = strings.Split(signToken(t, hs256, map[string]any{"tenant": "b"}, claimsSecret), ".")[1]
+	swapped := strings.Join(parts, ".")
+
+	for name, token := range map[string]string{
+		"other secret":   signToken(t, hs256, map[string]any{"tenant": "a"}, []byte("other secret")),
+		"swapped claims": swapped,
+		"unsigned":       unsignedToken(t),
+		"alg none":       signToken(t, map[string]any{"alg": "none"}, map[string]any{"tenant": "a"}, claimsSecret),
+		"expired":        signToken(t, hs256, map[string]any{"tenant": "a", "exp": now.Add(-time.Minute).Unix()}, claimsSecret),
+		"not yet valid":  signToken(t, hs256, map[string]any{"tenant": "a", "nbf": now.Add(time.Minute).Unix()}, claimsSecret),
+		"malformed":      "not-a-token",
+	} {
+		if _, err := verifyClaims(token, claimsSecret, now); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+}
+
+// intercept runs a request with md through the tenant interceptor and
+// reports the tenant the handler saw.
+func intercept(t *testing.T, cfg config.Tenancy, md metadata.MD) (string, error) {
+	t.Helper()
+
+	tenants := []*Tenant{{ID: "a", token: "token-a"}, {ID: "b", token: "token-b"}}
+	interceptor := tenantInterceptor(cfg, tenants)
+
+	var seen string
+	_, err := interceptor(metadata.NewIncomingContext(context.Background(), md), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		seen = tenancy.ID(ctx)
+		return nil, nil
+	})
+	return seen, err
+}
+
+func TestTenantInterceptor(t *testing.T) {
+	claims := config.Tenancy{Source: "claims", ClaimsSecret: string(claimsSecret), Claim: "tenant", RequireTenant: true}
+	hs256 := map[string]any{"alg": "HS256"}
+	bearer := func(token string) metadata.MD {
+		return metadata.Pairs("authorization", "Bearer "+token)
+	}
+
+	tenantID, err := intercept(t, claims, bearer(signToken(t, hs256, map[string]any{"tenant": "b"}, claimsSecret)))
+	if err != nil || tenantID != "b" {
+		t.Fatalf("signed claim acted for %q, %v", tenantID, err)
+	}
+
+	for name, test := range map[string]struct {
+		cfg  config.Tenancy
+		md   metadata.MD
+		code codes.Code
+	}{
+		"forged claim":   {claims, bearer(signToken(t, hs256, map[string]any{"tenant": "a"}, []byte("guess"))), codes.Unauthenticated},
+		"unsigned claim": {claims, bearer(unsignedToken(t)), codes.Unauthenticated},
+		"unknown tenant": {claims, bearer(signToken(t, hs256, map[string]any{"tenant": "c"}, claimsSecret)), codes.PermissionDenied},
+		"no tenant":      {claims, metadata.MD{}, codes.Unauthenticated},
+		"wrong token": {
+			config.Tenancy{Source: "metadata"},
+			metadata.Pairs("x-tenant-id", "a", "x-tenant-token", "token-b"),
+			codes.Unauthenticated,
+		},
+	} {
+		tenantID, err := intercept(t, test.cfg, test.md)
+		if status.Code(err) != test.code || tenantID != "" {
+			t.Errorf("%s: acted for %q with %v, want %s", name, tenantID, err, test.code)
+		}
+	}
+
+	tenantID, err = intercept(t, config.Tenancy{Source: "metadata"}, metadata.Pairs("x-tenant-id", "a", "x-tenant-token", "token-a"))
+	if err != nil || tenantID != "a" {
+		t.Fatalf("tenant token acted for %q, %v", tenantID, err)
+	}
+}
@@ -0,0 +1,77 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+	s3_v1 "github.com/acyushka/nbf-file-storage-service/pkg/pb/gen"
+
+	"github.com/hesoyamTM/nbf-auth/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *MinioServer) ListDeletedPhotos(ctx context.Context, req *s3_v1.ListDeletedPhotosRequest) (*s3_v1.ListDeletedPhotosResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	photos, err := s.tenant(ctx).service.ListDeletedPhotos(ctx, req.GetUserId())
+	if err != nil {
+		log.Error("Error: failed to list deleted photos")
+		return nil, status.Errorf(codes.Internal, "failed to list deleted photos: %v", err)
+	}
+
+	resp := &s3_v1.ListDeletedPhotosResponse{
+		Photos: make([]*s3_v1.DeletedPhoto, 0, len(photos)),
+	}
+	for _, photo := range photos {
+		resp.Photos = append(resp.Photos, &s3_v1.DeletedPhoto{
+			PhotoId:     photo.PhotoID,
+			ContentType: photo.ContentType,
+			FileSize:    photo.FileSize,
+			Placeholder: toPbPlaceholder(photo.Placeholder),
+			DeletedAt:   photo.DeletedAt.Unix(),
+			PurgeAt:     photo.PurgeAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *MinioServer) RestorePhoto(ctx context.Context, req *s3_v1.RestorePhotoRequest) (*s3_v1.RestorePhotoResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetPhotoId() == "" {
+		log.Error("Error: photo_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "photo_id is required")
+	}
+
+	err = s.tenant(ctx).service.RestorePhoto(ctx, req.GetUserId(), req.GetPhotoId())
+	if errors.Is(err, service.ErrPhotoNotFound) {
+		log.Error("Error: photo not found in trash")
+		return nil, status.Error(codes.NotFound, "photo not found in trash")
+	}
+	if err != nil {
+		log.Error("Error: failed to restore photo")
+		return nil, status.Errorf(codes.Internal, "failed to restore photo: %v", err)
+	}
+
+	log.Info("Photo restored successfuly")
+
+	return &s3_v1.RestorePhotoResponse{}, nil
+}
@@ -0,0 +1,138 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+	s3_v1 "github.com/acyushka/nbf-file-storage-service/pkg/pb/gen"
+
+	"github.com/hesoyamTM/nbf-auth/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Erasure and export act on everything a user has stored, so they are
+// reserved for internal callers handling a user's request.
+
+func (s *MinioServer) DeleteAllUserData(ctx context.Context, req *s3_v1.DeleteAllUserDataRequest) (*s3_v1.DeleteAllUserDataResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: user data erasure requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "user data erasure is reserved for internal callers")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	erasure, err := s.tenant(ctx).service.DeleteAllUserData(ctx, req.GetUserId())
+	if err := userDataError(err); err != nil {
+		log.Error("Error: failed to start user data erasure")
+		return nil, err
+	}
+
+	log.Info("User data erasure started successfuly")
+
+	return &s3_v1.DeleteAllUserDataResponse{
+		ErasureId: erasure.ID,
+	}, nil
+}
+
+func (s *MinioServer) GetErasureStatus(ctx context.Context, req *s3_v1.GetErasureStatusRequest) (*s3_v1.GetErasureStatusResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: erasure status requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "user data erasure is reserved for internal callers")
+	}
+
+	if req.GetErasureId() == "" {
+		log.Error("Error: erasure_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "erasure_id is required")
+	}
+
+	erasure, err := s.tenant(ctx).service.GetErasure(ctx, req.GetErasureId())
+	if err := userDataError(err); err != nil {
+		log.Error("Error: failed to get erasure status")
+		return nil, err
+	}
+
+	resp := &s3_v1.GetErasureStatusResponse{
+		UserId:  erasure.UserID,
+		Status:  string(erasure.Status),
+		Stage:   erasure.Stage,
+		Deleted: int64(erasure.Deleted),
+		Error:   erasure.Error,
+	}
+	if receipt := erasure.Receipt; receipt != nil {
+		resp.Receipt = &s3_v1.ErasureReceipt{
+			ErasureId:   receipt.ErasureID,
+			UserId:      receipt.UserID,
+			Deleted:     int64(receipt.Deleted),
+			StartedAt:   receipt.StartedAt.Unix(),
+			CompletedAt: receipt.CompletedAt.Unix(),
+			Signature:   receipt.Signature,
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *MinioServer) ExportUserData(ctx context.Context, req *s3_v1.ExportUserDataRequest) (*s3_v1.ExportUserDataResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: user data export requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "user data export is reserved for internal callers")
+	}
+
+	if req.GetUserId() == "" {
+		log.Error("Error: user_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	export, err := s.tenant(ctx).service.ExportUserData(ctx, req.GetUserId())
+	if err := userDataError(err); err != nil {
+		log.Error("Error: failed to export user data")
+		return nil, err
+	}
+
+	log.Info("User data exported successfuly")
+
+	return &s3_v1.ExportUserDataResponse{
+		Url:       export.URL,
+		Size:      export.Size,
+		ExpiresAt: export.ExpiresAt.Unix(),
+	}, nil
+}
+
+// userDataError maps erasure and export errors onto gRPC errors, or
+// returns nil for success.
+func userDataError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrInvalidUserID):
+		return status.Error(codes.InvalidArgument, "invalid user_id")
+	case errors.Is(err, service.ErrErasureDisabled):
+		return status.Error(codes.FailedPrecondition, "user data erasure is disabled")
+	case errors.Is(err, service.ErrProxyDisabled):
+		return status.Error(codes.FailedPrecondition, "url signing is disabled")
+	case errors.Is(err, service.ErrErasureNotFound):
+		return status.Error(codes.NotFound, "erasure not found")
+	default:
+		return status.Errorf(codes.Internal, "user data operation failed: %v", err)
+	}
+}
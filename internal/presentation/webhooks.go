@@ -0,0 +1,180 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/service"
+	s3_v1 "github.com/acyushka/nbf-file-storage-service/pkg/pb/gen"
+
+	"github.com/hesoyamTM/nbf-auth/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Webhook management is reserved for internal callers, like moderation.
+
+func (s *MinioServer) CreateWebhook(ctx context.Context, req *s3_v1.CreateWebhookRequest) (*s3_v1.CreateWebhookResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: webhook creation requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "webhooks are reserved for internal callers")
+	}
+
+	subscription, err := s.tenant(ctx).service.CreateWebhook(ctx, req.GetUrl(), req.GetEvents())
+	if err := webhookError(err); err != nil {
+		log.Error("Error: failed to create webhook")
+		return nil, err
+	}
+
+	log.Info("Webhook created successfuly")
+
+	return &s3_v1.CreateWebhookResponse{
+		WebhookId: subscription.ID,
+		Secret:    subscription.Secret,
+	}, nil
+}
+
+func (s *MinioServer) DeleteWebhook(ctx context.Context, req *s3_v1.DeleteWebhookRequest) (*s3_v1.DeleteWebhookResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: webhook deletion requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "webhooks are reserved for internal callers")
+	}
+
+	if req.GetWebhookId() == "" {
+		log.Error("Error: webhook_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "webhook_id is required")
+	}
+
+	err = s.tenant(ctx).service.DeleteWebhook(ctx, req.GetWebhookId())
+	if err := webhookError(err); err != nil {
+		log.Error("Error: failed to delete webhook")
+		return nil, err
+	}
+
+	log.Info("Webhook deleted successfuly")
+
+	return &s3_v1.DeleteWebhookResponse{}, nil
+}
+
+func (s *MinioServer) ListWebhooks(ctx context.Context, req *s3_v1.ListWebhooksRequest) (*s3_v1.ListWebhooksResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: webhooks requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "webhooks are reserved for internal callers")
+	}
+
+	subscriptions, err := s.tenant(ctx).service.ListWebhooks(ctx)
+	if err := webhookError(err); err != nil {
+		log.Error("Error: failed to list webhooks")
+		return nil, err
+	}
+
+	resp := &s3_v1.ListWebhooksResponse{
+		Webhooks: make([]*s3_v1.Webhook, 0, len(subscriptions)),
+	}
+	for _, subscription := range subscriptions {
+		resp.Webhooks = append(resp.Webhooks, &s3_v1.Webhook{
+			WebhookId: subscription.ID,
+			Url:       subscription.URL,
+			Events:    subscription.Events,
+			CreatedAt: subscription.CreatedAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *MinioServer) ListDeadWebhookDeliveries(ctx context.Context, req *s3_v1.ListDeadWebhookDeliveriesRequest) (*s3_v1.ListDeadWebhookDeliveriesResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: dead webhook deliveries requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "webhooks are reserved for internal callers")
+	}
+
+	deliveries, err := s.tenant(ctx).service.ListDeadWebhookDeliveries(ctx)
+	if err := webhookError(err); err != nil {
+		log.Error("Error: failed to list dead webhook deliveries")
+		return nil, err
+	}
+
+	resp := &s3_v1.ListDeadWebhookDeliveriesResponse{
+		Deliveries: make([]*s3_v1.WebhookDelivery, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, &s3_v1.WebhookDelivery{
+			DeliveryId: delivery.ID,
+			WebhookId:  delivery.SubscriptionID,
+			EventId:    delivery.EventID,
+			EventType:  delivery.EventType,
+			Attempts:   int32(delivery.Attempts),
+			LastError:  delivery.LastError,
+			CreatedAt:  delivery.CreatedAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *MinioServer) ReplayWebhook(ctx context.Context, req *s3_v1.ReplayWebhookRequest) (*s3_v1.ReplayWebhookResponse, error) {
+	log, err := logger.LoggerFromCtx(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to init logger")
+	}
+
+	if !s.isInternalCaller(ctx) {
+		log.Error("Error: webhook replay requested by external caller")
+		return nil, status.Error(codes.PermissionDenied, "webhooks are reserved for internal callers")
+	}
+
+	if req.GetDeliveryId() == "" {
+		log.Error("Error: delivery_id is empty")
+		return nil, status.Error(codes.InvalidArgument, "delivery_id is required")
+	}
+
+	err = s.tenant(ctx).service.ReplayWebhook(ctx, req.GetDeliveryId())
+	if err := webhookError(err); err != nil {
+		log.Error("Error: failed to replay webhook")
+		return nil, err
+	}
+
+	log.Info("Webhook delivery requeued successfuly")
+
+	return &s3_v1.ReplayWebhookResponse{}, nil
+}
+
+// webhookError maps webhook service errors onto gRPC errors, or returns
+// nil for success.
+func webhookError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrWebhooksDisabled):
+		return status.Error(codes.FailedPrecondition, "webhooks are disabled")
+	case errors.Is(err, service.ErrInvalidWebhookURL):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrWebhookNotFound):
+		return status.Error(codes.NotFound, "webhook not found")
+	case errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		return status.Error(codes.NotFound, "dead webhook delivery not found")
+	default:
+		return status.Errorf(codes.Internal, "webhook operation failed: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrScannerUnavailable = errors.New("virus scanner unavailable")
+	ErrTooLarge           = errors.New("file exceeds the scanner's size limit")
+)
+
+// Clamd scans streams with a clamd-protocol daemon using INSTREAM: content
+// is sent as length-prefixed chunks terminated by an empty one, and the
+// daemon answers with a single null-terminated verdict line.
+type Clamd struct {
+	network   string
+	address   string
+	timeout   time.Duration
+	chunkSize int
+}
+
+// NewClamd accepts "tcp://host:port" or "unix:///path/to/clamd.sock".
+func NewClamd(address string, timeout time.Duration, chunkSize int) (*Clamd, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clamd address: %w", err)
+	}
+
+	c := &Clamd{
+		network:   u.Scheme,
+		timeout:   timeout,
+		chunkSize: chunkSize,
+	}
+	switch u.Scheme {
+	case "tcp":
+		c.address = u.Host
+	case "unix":
+		c.address = u.Path
+	default:
+		return nil, fmt.Errorf("clamd address must be tcp:// or unix://, got %q", address)
+	}
+
+	return c, nil
+}
+
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrScannerUnavailable, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return Result{}, fmt.Errorf("failed to set scan deadline: %w", err)
+	}
+
+	if err := c.stream(conn, r); err != nil {
+		return Result{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Result{}, fmt.Errorf("%w: failed to read verdict: %v", ErrScannerUnavailable, err)
+	}
+
+	return parseReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+func (c *Clamd) stream(conn net.Conn, r io.Reader) error {
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("%w: %v", ErrScannerUnavailable, err)
+	}
+
+	chunk := make([]byte, 4+c.chunkSize)
+	for {
+		n, readErr := io.ReadFull(r, chunk[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(chunk, uint32(n))
+			if _, err := conn.Write(chunk[:4+n]); err != nil {
+				// clamd hangs up once the stream exceeds its size limit;
+				// the verdict explains why.
+				break
+			}
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read upload: %w", readErr)
+		}
+	}
+
+	// A failed write usually means clamd already hung up; the verdict read
+	// next says why.
+	_, _ = conn.Write([]byte{0, 0, 0, 0})
+
+	return nil
+}
+
+// parseReply reads "stream: OK", "stream: <signature> FOUND" or
+// "<message> ERROR".
+func parseReply(reply string) (Result, error) {
+	verdict := strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	case strings.Contains(verdict, "size limit exceeded"):
+		return Result{}, ErrTooLarge
+	case strings.HasSuffix(verdict, " ERROR"):
+		return Result{}, fmt.Errorf("%w: %s", ErrScannerUnavailable, strings.TrimSuffix(verdict, " ERROR"))
+	default:
+		return Result{}, fmt.Errorf("%w: unexpected reply %q", ErrScannerUnavailable, reply)
+	}
+}
@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// EICAR is the industry standard antivirus test string. Real scanners and
+// FakeClamd alike report it as Eicar-Test-Signature.
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// FakeClamd speaks enough of the clamd protocol (PING and INSTREAM) to
+// stand in for a real daemon in tests and local development. Streams
+// containing one of its signature patterns are reported as infected.
+type FakeClamd struct {
+	listener  net.Listener
+	maxStream int
+
+	mu         sync.RWMutex
+	signatures map[string][]byte
+}
+
+// NewFakeClamd listens on address, given in the same form NewClamd takes.
+func NewFakeClamd(address string, maxStream int) (*FakeClamd, error) {
+	network, addr, ok := strings.Cut(address, "://")
+	if !ok || (network != "tcp" && network != "unix") {
+		return nil, fmt.Errorf("fake clamd address must be tcp:// or unix://, got %q", address)
+	}
+
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	return &FakeClamd{
+		listener:   listener,
+		maxStream:  maxStream,
+		signatures: map[string][]byte{"Eicar-Test-Signature": []byte(EICAR)},
+	}, nil
+}
+
+// Address is where clients reach the fake, in NewClamd form.
+func (f *FakeClamd) Address() string {
+	return f.listener.Addr().Network() + "://" + f.listener.Addr().String()
+}
+
+func (f *FakeClamd) AddSignature(name string, pattern []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.signatures[name] = pattern
+}
+
+// Serve accepts connections until Close is called.
+func (f *FakeClamd) Serve() error {
+	for {
+		conn, err := f.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to accept: %w", err)
+		}
+
+		go f.handle(conn)
+	}
+}
+
+func (f *FakeClamd) Close() error {
+	return f.listener.Close()
+}
+
+func (f *FakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	terminator := byte('\n')
+	if prefix == 'z' {
+		terminator = 0
+	}
+
+	command, err := r.ReadString(terminator)
+	if err != nil {
+		return
+	}
+
+	reply := func(msg string) {
+		_, _ = conn.Write(append([]byte(msg), terminator))
+	}
+
+	switch strings.TrimSuffix(command, string(terminator)) {
+	case "PING":
+		reply("PONG")
+	case "INSTREAM":
+		data, err := f.readStream(r)
+		if err != nil {
+			reply(err.Error() + " ERROR")
+			return
+		}
+		if name, found := f.match(data); found {
+			reply("stream: " + name + " FOUND")
+			return
+		}
+		reply("stream: OK")
+	default:
+		reply("UNKNOWN COMMAND")
+	}
+}
+
+func (f *FakeClamd) readStream(r io.Reader) ([]byte, error) {
+	var data bytes.Buffer
+	var size [4]byte
+
+	for {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, fmt.Errorf("INSTREAM: can't read chunk size")
+		}
+
+		n := binary.BigEndian.Uint32(size[:])
+		if n == 0 {
+			return data.Bytes(), nil
+		}
+		if data.Len()+int(n) > f.maxStream {
+			return nil, fmt.Errorf("INSTREAM size limit exceeded.")
+		}
+
+		if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+			return nil, fmt.Errorf("INSTREAM: can't read chunk")
+		}
+	}
+}
+
+func (f *FakeClamd) match(data []byte) (string, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for name, pattern := range f.signatures {
+		if bytes.Contains(data, pattern) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,18 @@
+package scanner
+
+import (
+	"context"
+	"io"
+)
+
+// Result is a scanner's verdict on one stream.
+type Result struct {
+	Infected bool
+	// Signature names what was found, e.g. "Eicar-Test-Signature".
+	Signature string
+}
+
+// Scanner checks content for malware.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
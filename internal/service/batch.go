@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+)
+
+// GetPhotoURLs resolves many photos at once with bounded concurrency. A
+// failure on one photo is reported in its own result and never fails the
+// batch. skipExistenceCheck must only be set for trusted callers.
+func (s *MinioService) GetPhotoURLs(ctx context.Context, userID string, photoIDs []string, acceptFormats []string, skipExistenceCheck bool) []models.PhotoURLResult {
+	results := make([]models.PhotoURLResult, len(photoIDs))
+	sem := make(chan struct{}, max(1, s.batchConcurrency))
+
+	var wg sync.WaitGroup
+	for i, photoID := range photoIDs {
+		results[i].PhotoID = photoID
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i].PhotoURL, results[i].Err = s.cachedPhotoURL(ctx, userID, photoID, acceptFormats, !skipExistenceCheck)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/notifications"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var ErrDirectUploadsDisabled = errors.New("direct uploads are disabled")
+
+// Direct uploads are staged here until their notification is processed.
+const uploadsRoot = "uploads/"
+
+func stagedUploadKey(userID string, photoID string) string {
+	return uploadsRoot + userID + "/" + photoID
+}
+
+// NewNotificationSource builds the configured source of bucket events. The
+// webhook has none: MinIO pushes to the HTTP server instead.
+func NewNotificationSource(cfg config.Notifications, store *storage.MinioClient) (notifications.Source, error) {
+	switch cfg.Source {
+	case "listen":
+		return notifications.NewListener(store, cfg.RetryDelay), nil
+	case "webhook":
+		if cfg.WebhookToken == "" {
+			return nil, fmt.Errorf("webhook notifications require a webhook token")
+		}
+		return nil, nil
+	case "replay":
+		f, err := os.Open(cfg.ReplayFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open replay file: %w", err)
+		}
+		defer f.Close()
+		return notifications.LoadFake(f)
+	default:
+		return nil, fmt.Errorf("unknown notification source %q", cfg.Source)
+	}
+}
+
+// WatchBucket passes bucket events to handle until ctx is cancelled. The
+// handler is HandleBucketEvent, or a router when tenants share the bucket.
+func (s *MinioService) WatchBucket(ctx context.Context, handle notifications.Handler) error {
+	if s.notifications == nil {
+		return nil
+	}
+
+	return s.notifications.Run(ctx, handle)
+}
+
+// CreateUploadURL reserves a photo ID and presigns an upload of it straight
+// to the bucket. The photo becomes available once the upload's
+// notification has been processed.
+func (s *MinioService) CreateUploadURL(ctx context.Context, userID string, fileName string) (*models.DirectUpload, error) {
+	photoID := uuid.New().String() + filepath.Ext(fileName)
+
+	// Presigned uploads cannot carry SSE-C keys.
+	if !s.directUploads || s.storage.CustomerKeys(stagedUploadKey(userID, photoID)) {
+		return nil, ErrDirectUploadsDisabled
+	}
+
+	expiresAt := time.Now().Add(s.directUploadExpiry)
+
+	url, err := s.storage.GetPresignedPutURL(ctx, stagedUploadKey(userID, photoID), s.directUploadExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DirectUpload{
+		PhotoID:   photoID,
+		URL:       url,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// HandleBucketEvent brings the catalog in line with a change made to the
+// bucket around the service. Changes the service made itself are skipped,
+// and every change is safe to handle twice.
+func (s *MinioService) HandleBucketEvent(ctx context.Context, event models.BucketEvent) error {
+	metrics.BucketEvents.Add(1)
+	// Direct uploads are presigned with the service's key but made by
+	// clients; the service itself only ever removes staged uploads.
+	if !strings.HasPrefix(event.Key, uploadsRoot) && s.storage.OwnPrincipal(event.Principal) {
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(event.Key, uploadsRoot):
+		if event.Created() {
+			return s.ingestUpload(ctx, event.Key)
+		}
+	case strings.HasPrefix(event.Key, "blobs/"):
+		if event.Removed() {
+			return s.blobRemoved(ctx, event.Key)
+		}
+	default:
+		userID, photoID, ok := parseLegacyObjectName(event.Key)
+		if ok && event.Removed() {
+			if err := s.recordDeleted(ctx, userID, photoID, models.KindPhoto, event.Key); err != nil {
+				return err
+			}
+			s.publishDeleted(ctx, userID, photoID, models.KindPhoto)
+			return s.invalidateURLs(ctx, userID, photoID)
+		}
+	}
+
+	return nil
+}
+
+// IngestStagedUploads processes direct uploads whose notification never
+// arrived, and reports how many were found. Uploads staged more recently
+// than olderThan are left to their notification.
+func (s *MinioService) IngestStagedUploads(ctx context.Context, olderThan time.Duration) (int, error) {
+	objects, err := s.storage.List(ctx, uploadsRoot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list staged uploads: %w", err)
+	}
+
+	ingested := 0
+	for _, object := range objects {
+		if time.Since(object.LastModified) < olderThan {
+			continue
+		}
+		if err := s.ingestUpload(ctx, object.Key); err != nil {
+			return ingested, err
+		}
+		ingested++
+	}
+
+	return ingested, nil
+}
+
+// ingestUpload runs a direct upload through the same pipeline as a gRPC
+// upload and drops the staged object. Uploads that can never be accepted
+// are dropped too; only transient failures are returned, to be retried.
+func (s *MinioService) ingestUpload(ctx context.Context, key string) error {
+	userID, photoID, ok := strings.Cut(strings.TrimPrefix(key, uploadsRoot), "/")
+	if !ok || userID == "" || photoID == "" || strings.Contains(photoID, "/") {
+		return s.rejectUpload(ctx, key)
+	}
+
+	unlock := s.metaLocks.Lock(photoMetaKey(userID, photoID))
+	defer unlock()
+
+	exists, err := s.storage.Exists(ctx, photoMetaKey(userID, photoID))
+	if err != nil {
+		return err
+	}
+	if exists {
+		// Redelivered after an earlier ingest got as far as the record.
+		return s.storage.Delete(ctx, key)
+	}
+
+	obj, info, err := s.storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open staged upload: %w", err)
+	}
+	defer obj.Close()
+
+	if info.Size > s.directUploadMaxSize {
+		return s.rejectUpload(ctx, key)
+	}
+
+	prepared, err := preparePhoto(models.PhotoData{
+		Data:        obj,
+		FileSize:    info.Size,
+		FileName:    photoID,
+		ContentType: info.ContentType,
+	}, s.maxPixels)
+	if errors.Is(err, ErrTooManyPixels) {
+		return s.rejectUpload(ctx, key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read staged upload: %w", err)
+	}
+	prepared.photoID = photoID
+
+	if err := s.scanUpload(ctx, userID, models.KindPhoto, prepared); err != nil {
+		if errors.Is(err, ErrInfected) || errors.Is(err, ErrTooLargeToScan) {
+			return s.rejectUpload(ctx, key)
+		}
+		return err
+	}
+
+	if _, err := s.storePhoto(ctx, userID, models.KindPhoto, prepared); err != nil {
+		return fmt.Errorf("failed to store direct upload: %w", err)
+	}
+
+	if err := s.storage.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to remove staged upload: %w", err)
+	}
+	metrics.DirectUploads.Add(1)
+
+	return nil
+}
+
+func (s *MinioService) rejectUpload(ctx context.Context, key string) error {
+	metrics.RejectedDirectUploads.Add(1)
+
+	if err := s.storage.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to remove rejected upload: %w", err)
+	}
+
+	return nil
+}
+
+// blobRemoved handles a blob or rendition deleted or expired behind the
+// service's back. A lost rendition is simply no longer offered; a lost
+// original takes every photo sharing it down with it.
+func (s *MinioService) blobRemoved(ctx context.Context, key string) error {
+	kindName, name, ok := strings.Cut(strings.TrimPrefix(key, "blobs/"), "/")
+	if !ok {
+		return nil
+	}
+	kind := models.PhotoKind(kindName)
+	hash, _, rendition := strings.Cut(name, ".")
+
+	unlock := s.blobLocks.Lock(blobKey(kind, hash))
+	defer unlock()
+
+	var ref models.BlobRef
+	err := s.storage.GetJSON(ctx, blobRefKey(kind, hash), &ref)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load blob ref: %w", err)
+	}
+	if claimed(&ref) {
+		// Removed by the service itself.
+		return nil
+	}
+
+	photos, err := s.photosWithBlob(ctx, kind, hash)
+	if err != nil {
+		return err
+	}
+
+	if rendition {
+		return s.renditionRemoved(ctx, kind, &ref, key, photos)
+	}
+
+	for _, meta := range photos {
+		if err := s.forgetPhoto(ctx, meta); err != nil {
+			return err
+		}
+	}
+
+	renditions, err := s.storage.List(ctx, key+".")
+	if err != nil {
+		return err
+	}
+	for _, object := range renditions {
+		if err := s.storage.Delete(ctx, object.Key); err != nil {
+			return err
+		}
+	}
+	return s.storage.Delete(ctx, blobRefKey(kind, hash))
+}
+
+func (s *MinioService) renditionRemoved(ctx context.Context, kind models.PhotoKind, ref *models.BlobRef, key string, photos []*models.PhotoMeta) error {
+	_, err := s.updateBlobRef(ctx, kind, ref.SHA256, func(ref *models.BlobRef) (*models.BlobRef, error) {
+		if ref == nil {
+			return nil, storage.ErrObjectNotFound
+		}
+		for format, renditionKey := range ref.Renditions {
+			if renditionKey == key {
+				delete(ref.Renditions, format)
+			}
+		}
+		return ref, nil
+	})
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, meta := range photos {
+		if err := s.dropRendition(ctx, meta.UserID, meta.PhotoID, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MinioService) dropRendition(ctx context.Context, userID string, photoID string, key string) error {
+	unlock := s.metaLocks.Lock(photoMetaKey(userID, photoID))
+	defer unlock()
+
+	_, err := s.updatePhotoMeta(ctx, userID, photoID, func(meta *models.PhotoMeta) error {
+		for format, renditionKey := range meta.Renditions {
+			if renditionKey == key {
+				delete(meta.Renditions, format)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.invalidateURLs(ctx, userID, photoID)
+}
+
+// photosWithBlob walks the whole catalog, which is fine for the rare blob
+// lost outside the service.
+func (s *MinioService) photosWithBlob(ctx context.Context, kind models.PhotoKind, hash string) ([]*models.PhotoMeta, error) {
+	objects, err := s.storage.List(ctx, photoMetaRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photo records: %w", err)
+	}
+
+	var photos []*models.PhotoMeta
+	for _, object := range objects {
+		if !strings.HasSuffix(object.Key, ".json") {
+			continue
+		}
+
+		var meta models.PhotoMeta
+		if err := s.storage.GetJSON(ctx, object.Key, &meta); err != nil {
+			if errors.Is(err, storage.ErrObjectNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to load photo record: %w", err)
+		}
+		if meta.Kind == kind && meta.SHA256 == hash {
+			photos = append(photos, &meta)
+		}
+	}
+
+	return photos, nil
+}
+
+// parseLegacyObjectName is the inverse of legacyObjectName.
+func parseLegacyObjectName(key string) (string, string, bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 || parts[1] != "photos" || parts[0] == "" || parts[2] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[2], true
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/notifications"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// replay runs the fake's whole history through the service once.
+func replay(t *testing.T, s *MinioService, source *notifications.Fake, events int) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handled := 0
+	err := source.Run(ctx, func(ctx context.Context, event models.BucketEvent) error {
+		if err := s.HandleBucketEvent(ctx, event); err != nil {
+			return err
+		}
+		if handled++; handled == events {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+}
+
+func directUploadConfig(maxSize int64) *config.Config {
+	cfg := &config.Config{}
+	cfg.Notifications.Enabled = true
+	cfg.Notifications.DirectUploads = true
+	cfg.Notifications.DirectUploadMaxSize = maxSize
+	return cfg
+}
+
+func TestBucketEventsIngestDirectUploads(t *testing.T) {
+	ctx := context.Background()
+	photo := testImage(t, 1)
+	source := notifications.NewFake()
+	s, fake := newTestService(t, directUploadConfig(int64(len(photo))), Dependencies{Notifications: source})
+
+	accepted := stagedUploadKey("alice", "accepted.png")
+	tooLarge := stagedUploadKey("alice", "large.png")
+	for key, data := range map[string][]byte{
+		accepted: photo,
+		tooLarge: append(bytes.Clone(photo), 0),
+	} {
+		if err := s.storage.Upload(ctx, key, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source.Emit(
+		notifications.Created(accepted, int64(len(photo)), "image/png"),
+		notifications.Created(tooLarge, int64(len(photo)+1), "image/png"),
+		// MinIO delivers at least once.
+		notifications.Created(accepted, int64(len(photo)), "image/png"),
+	)
+	replay(t, s, source, 3)
+
+	meta, err := s.getPhotoMeta(ctx, "alice", "accepted.png")
+	if err != nil {
+		t.Fatalf("direct upload was not ingested: %v", err)
+	}
+	if meta.FileSize != int64(len(photo)) || !slices.Contains(fake.Keys(testBucket), meta.BlobKey) {
+		t.Fatalf("ingested record %+v", meta)
+	}
+	if _, err := s.getPhotoMeta(ctx, "alice", "large.png"); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Fatalf("oversized upload: %v, want no record", err)
+	}
+	for _, key := range []string{accepted, tooLarge} {
+		if slices.Contains(fake.Keys(testBucket), key) {
+			t.Errorf("staged upload %s left behind", key)
+		}
+	}
+
+	// Replaying the history changes nothing.
+	replay(t, s, source, 3)
+	objects, err := s.storage.List(ctx, photoMetaPrefix("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("%d records after a replay, want 1", len(objects))
+	}
+}
+
+func TestBucketEventsForgetPhotosWhoseBlobWasRemoved(t *testing.T) {
+	ctx := context.Background()
+	source := notifications.NewFake()
+	s, fake := newTestService(t, directUploadConfig(1<<20), Dependencies{Notifications: source})
+
+	photo := testImage(t, 1)
+	first := uploadTestPhoto(t, s, "alice", photo)
+	second := uploadTestPhoto(t, s, "bob", photo)
+	if first.BlobKey != second.BlobKey {
+		t.Fatal("identical uploads were not deduplicated")
+	}
+
+	// The service's own deletes come back as events too, and are skipped.
+	own := notifications.Removed(first.BlobKey)
+	own.Principal = "access"
+	source.Emit(own)
+	replay(t, s, source, 1)
+	if _, err := s.getPhotoMeta(ctx, "alice", first.PhotoID); err != nil {
+		t.Fatalf("own event took the photo down: %v", err)
+	}
+
+	// Removed around the service, say by an operator.
+	if err := s.storage.Delete(ctx, first.BlobKey); err != nil {
+		t.Fatal(err)
+	}
+	source.Emit(notifications.Removed(first.BlobKey))
+	replay(t, s, source, 2)
+
+	for _, meta := range []*models.PhotoMeta{first, second} {
+		if _, err := s.getPhotoMeta(ctx, meta.UserID, meta.PhotoID); !errors.Is(err, storage.ErrObjectNotFound) {
+			t.Errorf("%s/%s: %v, want the record gone", meta.UserID, meta.PhotoID, err)
+		}
+	}
+	if slices.Contains(fake.Keys(testBucket), blobRefKey(models.KindPhoto, first.SHA256)) {
+		t.Error("blob ref left behind")
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+const blobsRoot = "blobs/"
+
+func blobPrefix(kind models.PhotoKind) string {
+	return blobsRoot + string(kind) + "/"
+}
+
+func blobKey(kind models.PhotoKind, hash string) string {
+	return blobPrefix(kind) + hash
+}
+
+func blobRefKey(kind models.PhotoKind, hash string) string {
+	return fmt.Sprintf("_meta/blobs/%s/%s.json", kind, hash)
+}
+
+const photoMetaRoot = "_meta/photos/"
+
+func photoMetaPrefix(userID string) string {
+	return photoMetaRoot + userID + "/"
+}
+
+func photoMetaKey(userID string, photoID string) string {
+	return photoMetaPrefix(userID) + photoID + ".json"
+}
+
+// keyedMutex serializes work on a blob within the process. Other instances
+// are kept in line by conditional writes to the blob's ref. A key's lock is
+// dropped once nobody holds or waits for it.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	users int
+}
+
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.users++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		k.mu.Lock()
+		l.users--
+		if l.users == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// A blob losing its last reference is claimed for removal in its ref
+// first, so that no instance deduplicates against it while it goes. A
+// claim older than blobClaimTimeout is taken to be abandoned.
+const blobClaimTimeout = time.Minute
+
+// Conditional ref updates that lose a race are retried after
+// refRetryDelay, up to maxRefAttempts times.
+const (
+	refRetryDelay  = 50 * time.Millisecond
+	maxRefAttempts = 20
+)
+
+var errBlobClaimed = errors.New("blob is being removed")
+
+func claimed(ref *models.BlobRef) bool {
+	return ref.Claimed != nil && time.Since(*ref.Claimed) < blobClaimTimeout
+}
+
+// updateBlobRef rewrites a blob's ref through change with a conditional
+// write, so no instance's update is lost to another's. change gets nil for
+// a missing ref and returns the ref to write; it runs again on the fresh
+// state whenever another writer came first.
+func (s *MinioService) updateBlobRef(ctx context.Context, kind models.PhotoKind, hash string, change func(ref *models.BlobRef) (*models.BlobRef, error)) (*models.BlobRef, error) {
+	key := blobRefKey(kind, hash)
+
+	for attempt := 1; ; attempt++ {
+		var ref models.BlobRef
+		current := &ref
+		etag, err := s.storage.GetJSONVersion(ctx, key, current)
+		switch {
+		case errors.Is(err, storage.ErrObjectNotFound):
+			current = nil
+		case err != nil:
+			return nil, fmt.Errorf("failed to load blob ref: %w", err)
+		}
+
+		updated, err := change(current)
+		if err != nil {
+			return nil, err
+		}
+		updated.UpdatedAt = time.Now().UTC()
+
+		err = s.storage.PutJSONIf(ctx, key, updated, etag)
+		switch {
+		case err == nil:
+			return updated, nil
+		case !errors.Is(err, storage.ErrPreconditionFailed) || attempt == maxRefAttempts:
+			return nil, fmt.Errorf("failed to save blob ref: %w", err)
+		}
+
+		if err := sleep(ctx, refRetryDelay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// putBlob stores data under its content address, or bumps the reference count
+// if an identical blob already exists. A blob being removed is waited out
+// and then stored anew.
+func (s *MinioService) putBlob(ctx context.Context, kind models.PhotoKind, photo *preparedPhoto) (*models.BlobRef, error) {
+	data := photo.data
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := blobKey(kind, hash)
+	size := int64(len(data))
+
+	unlock := s.blobLocks.Lock(key)
+	defer unlock()
+
+	metrics.UploadedBytes.Add(size)
+
+	for {
+		uploaded, hit := false, false
+		ref, err := s.updateBlobRef(ctx, kind, hash, func(ref *models.BlobRef) (*models.BlobRef, error) {
+			switch {
+			case ref != nil && claimed(ref):
+				return nil, errBlobClaimed
+			case ref != nil && ref.Claimed == nil && ref.Refs > 0:
+				hit = true
+				ref.Refs++
+				return ref, nil
+			}
+
+			hit = false
+			if !uploaded {
+				if err := s.storage.UploadWithChecksum(ctx, key, bytes.NewReader(data), size, photo.contentType, photo.placeholder.UserMetadata(), sum[:]); err != nil {
+					return nil, err
+				}
+				uploaded = true
+			}
+
+			return &models.BlobRef{
+				BlobKey:  key,
+				SHA256:   hash,
+				FileSize: size,
+				Refs:     1,
+			}, nil
+		})
+		if errors.Is(err, errBlobClaimed) {
+			// Whatever was uploaded goes with the blob; store it again
+			// once the removal is done.
+			if err := sleep(ctx, refRetryDelay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hit {
+			metrics.DedupHits.Add(1)
+			metrics.DedupBytesSaved.Add(size)
+		}
+
+		return ref, nil
+	}
+}
+
+// releaseBlob drops one reference and removes the blob once nothing points at
+// it, old versions included.
+func (s *MinioService) releaseBlob(ctx context.Context, kind models.PhotoKind, hash string) error {
+	key := blobKey(kind, hash)
+
+	unlock := s.blobLocks.Lock(key)
+	defer unlock()
+
+	ref, err := s.updateBlobRef(ctx, kind, hash, func(ref *models.BlobRef) (*models.BlobRef, error) {
+		if ref == nil {
+			return nil, storage.ErrObjectNotFound
+		}
+		ref.Refs--
+		if ref.Refs <= 0 {
+			now := time.Now().UTC()
+			ref.Refs = 0
+			ref.Claimed = &now
+		}
+		return ref, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release blob: %w", err)
+	}
+	if ref.Refs > 0 {
+		metrics.DedupBytesSaved.Add(-ref.FileSize)
+		return nil
+	}
+
+	// A versioned bucket would keep the bytes in old versions for as long
+	// as its lifecycle rules say, out of reach of erasure; nothing refers
+	// to them any more, so they go for good. The blob key prefixes its
+	// renditions too.
+	if s.storage.Versioning() {
+		if _, err := s.storage.PurgePrefix(ctx, key); err != nil {
+			return err
+		}
+		return s.storage.Purge(ctx, blobRefKey(kind, hash))
+	}
+
+	// Transcoded and proxy renditions all live under "<blob key>.".
+	renditions, err := s.storage.List(ctx, key+".")
+	if err != nil {
+		return err
+	}
+	for _, rendition := range renditions {
+		if err := s.storage.Delete(ctx, rendition.Key); err != nil {
+			return err
+		}
+	}
+	if err := s.storage.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	return s.storage.Delete(ctx, blobRefKey(kind, hash))
+}
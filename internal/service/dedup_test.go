@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage/storagetest"
+)
+
+func blobRef(t *testing.T, s *MinioService, meta *models.PhotoMeta) *models.BlobRef {
+	t.Helper()
+
+	var ref models.BlobRef
+	if err := s.storage.GetJSON(context.Background(), blobRefKey(meta.Kind, meta.SHA256), &ref); err != nil {
+		t.Fatal(err)
+	}
+	return &ref
+}
+
+func TestIdenticalUploadsShareABlob(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, &config.Config{}, Dependencies{})
+	data := testImage(t, 1)
+
+	alice := uploadTestPhoto(t, s, "alice", data)
+	bob := uploadTestPhoto(t, s, "bob", data)
+	if alice.BlobKey != bob.BlobKey {
+		t.Fatalf("blobs %s and %s for the same bytes", alice.BlobKey, bob.BlobKey)
+	}
+	if ref := blobRef(t, s, alice); ref.Refs != 2 {
+		t.Fatalf("blob has %d refs, want 2", ref.Refs)
+	}
+
+	if err := s.DeletePhoto(ctx, "alice", alice.PhotoID); err != nil {
+		t.Fatal(err)
+	}
+	if ref := blobRef(t, s, bob); ref.Refs != 1 {
+		t.Fatalf("blob has %d refs after one delete, want 1", ref.Refs)
+	}
+	if !s.storage.ObjectExists(ctx, bob.BlobKey) {
+		t.Fatal("blob removed while bob still refers to it")
+	}
+	if _, err := s.getPhotoMeta(ctx, "bob", bob.PhotoID); err != nil {
+		t.Fatalf("bob's photo after alice's delete: %v", err)
+	}
+}
+
+func TestLastDeleteRemovesTheBlobAndItsVersions(t *testing.T) {
+	ctx := context.Background()
+	store, fake := storagetest.NewVersionedClient(t, testBucket)
+	s := NewMinioService(Dependencies{Storage: store}, &config.Config{})
+	data := testImage(t, 1)
+
+	alice := uploadTestPhoto(t, s, "alice", data)
+	bob := uploadTestPhoto(t, s, "bob", data)
+	rendition := renditionKey(alice.BlobKey, "webp")
+	if err := s.storage.PutJSON(ctx, rendition, struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeletePhoto(ctx, "alice", alice.PhotoID); err != nil {
+		t.Fatal(err)
+	}
+	if !s.storage.ObjectExists(ctx, bob.BlobKey) {
+		t.Fatal("blob removed while bob still refers to it")
+	}
+	// Alice's record is only hidden behind a delete marker.
+	if n := fake.Versions(testBucket, photoMetaKey("alice", alice.PhotoID)); n != 2 {
+		t.Fatalf("alice's record has %d versions, want the record and a delete marker", n)
+	}
+
+	if err := s.DeletePhoto(ctx, "bob", bob.PhotoID); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{alice.BlobKey, rendition, blobRefKey(models.KindPhoto, alice.SHA256)} {
+		if n := fake.Versions(testBucket, key); n != 0 {
+			t.Fatalf("%s kept %d versions after the last delete", key, n)
+		}
+	}
+}
+
+func TestBlobRefUpdatesRetryLostRaces(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, &config.Config{}, Dependencies{})
+	meta := uploadTestPhoto(t, s, "alice", testImage(t, 1))
+
+	calls := 0
+	ref, err := s.updateBlobRef(ctx, meta.Kind, meta.SHA256, func(ref *models.BlobRef) (*models.BlobRef, error) {
+		calls++
+		if calls == 1 {
+			// Another instance takes a reference in between.
+			raced := *ref
+			raced.Refs++
+			if err := s.storage.PutJSON(ctx, blobRefKey(meta.Kind, meta.SHA256), &raced); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ref.Refs++
+		return ref, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || ref.Refs != 3 {
+		t.Fatalf("change ran %d times for %d refs, want a retry ending at 3", calls, ref.Refs)
+	}
+	if stored := blobRef(t, s, meta); stored.Refs != 3 {
+		t.Fatalf("stored ref has %d refs, want 3", stored.Refs)
+	}
+}
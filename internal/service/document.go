@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDocumentsDisabled = errors.New("document storage is disabled")
+	ErrDocumentNotFound  = errors.New("document does not exist")
+)
+
+const documentsRoot = "documents"
+
+func documentKey(userID string, documentID string) string {
+	return fmt.Sprintf("%s/%s/%s", documentsRoot, userID, documentID)
+}
+
+// maxDocumentChunkSize bounds the plaintext each envelope chunk holds,
+// which is read into memory whole.
+const maxDocumentChunkSize = 16 << 20
+
+// DocumentKeys builds the key wrapper protecting document data keys: either
+// master keys straight from config or the local KMS stand-in. It refuses a
+// documents config the envelope store could not work with.
+func DocumentKeys(cfg config.Documents) (storage.KeyWrapper, error) {
+	if cfg.ChunkSize <= 0 || cfg.ChunkSize > maxDocumentChunkSize {
+		return nil, fmt.Errorf("documents.chunk_size must be between 1 and %d", maxDocumentChunkSize)
+	}
+
+	switch cfg.KeyProvider {
+	case "config":
+		keys := make(map[string][]byte, len(cfg.MasterKeys))
+		for id, encoded := range cfg.MasterKeys {
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode master key %q: %w", id, err)
+			}
+			keys[id] = key
+		}
+		return storage.NewMasterKeys(cfg.CurrentKeyID, keys)
+	case "local-kms":
+		return storage.NewLocalKMS(cfg.LocalKMSPath)
+	default:
+		return nil, fmt.Errorf("unknown document key provider %q", cfg.KeyProvider)
+	}
+}
+
+// UploadDocument stores a sensitive document envelope encrypted, so the
+// storage provider never sees its plaintext. The checksums of the
+// plaintext go into its metadata: the client's, which the upload is
+// verified against on its way to storage, or those computed when it is
+// read in full for scanning.
+func (s *MinioService) UploadDocument(ctx context.Context, userID string, data io.Reader, fileName string, fileSize int64, contentType string, checksums models.Checksums) (string, error) {
+	if s.documents == nil {
+		return "", ErrDocumentsDisabled
+	}
+
+	verified, err := newChecksumReader(data, fileSize, checksums)
+	if err != nil {
+		return "", err
+	}
+	data, checksums = verified, verified.want
+
+	// Documents have no catalog record to carry a pending scan, so they
+	// are always scanned inline.
+	if s.scanner != nil {
+		content, err := io.ReadAll(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to read document: %w", err)
+		}
+		if err := s.scanInline(ctx, userID, fileName, contentType, content); err != nil {
+			return "", err
+		}
+		data, fileSize, checksums = bytes.NewReader(content), int64(len(content)), verified.sums()
+	}
+
+	documentID := uuid.New().String() + filepath.Ext(fileName)
+	if err := s.documents.UploadWithMetadata(ctx, documentKey(userID, documentID), data, fileSize, contentType, checksumMetadata(checksums)); err != nil {
+		// The encryption in between may not keep the reader's error.
+		if verified.mismatch != nil {
+			return "", verified.mismatch
+		}
+		return "", fmt.Errorf("failed to upload document: %w", err)
+	}
+
+	return documentID, nil
+}
+
+// SignDocumentPath returns an expiring signed path to a document, relative
+// to the HTTP server root. Documents are never presigned; the HTTP server
+// decrypts them on the way out.
+func (s *MinioService) SignDocumentPath(userID string, documentID string) (string, time.Time, error) {
+	if s.documents == nil {
+		return "", time.Time{}, ErrDocumentsDisabled
+	}
+	if len(s.signingSecret) == 0 {
+		return "", time.Time{}, ErrProxyDisabled
+	}
+
+	expiresAt := time.Now().Add(s.downloadExpiry).Truncate(time.Second)
+
+	return fmt.Sprintf("/docs/%s/%s/%s?exp=%d",
+		s.expiringSignature("document", userID, documentID, expiresAt.Unix()),
+		url.PathEscape(userID),
+		url.PathEscape(documentID),
+		expiresAt.Unix(),
+	), expiresAt, nil
+}
+
+// OpenDocument verifies a signed document path and opens the document for
+// streaming as plaintext. Range requests decrypt only the chunks they
+// touch.
+func (s *MinioService) OpenDocument(ctx context.Context, signature string, userID string, documentID string, expires int64) (*models.Download, error) {
+	if s.documents == nil {
+		return nil, ErrDocumentsDisabled
+	}
+	if len(s.signingSecret) == 0 {
+		return nil, ErrProxyDisabled
+	}
+
+	expected := s.expiringSignature("document", userID, documentID, expires)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return nil, ErrDownloadExpired
+	}
+
+	content, info, err := s.documents.Get(ctx, documentKey(userID, documentID))
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Download{
+		Content:     content,
+		ContentType: info.ContentType,
+		ETag:        `"` + info.ETag + `"`,
+		ModTime:     info.LastModified,
+		Size:        info.Size,
+		ExpiresAt:   time.Unix(expires, 0),
+	}, nil
+}
+
+func (s *MinioService) DeleteDocument(ctx context.Context, userID string, documentID string) error {
+	if s.documents == nil {
+		return ErrDocumentsDisabled
+	}
+
+	key := documentKey(userID, documentID)
+	if !s.documents.ObjectExists(ctx, key) {
+		return ErrDocumentNotFound
+	}
+
+	if err := s.documents.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+var ErrDownloadExpired = errors.New("download link expired")
+
+// SignDownloadPath returns an expiring signed path to the original upload,
+// relative to the HTTP server root, and its expiry. The link is signed for
+// the owner, so it opens photos still awaiting review as well.
+func (s *MinioService) SignDownloadPath(userID string, photoID string) (string, time.Time, error) {
+	return s.signDownloadPath(userID, userID, photoID, s.downloadExpiry)
+}
+
+// signDownloadPath signs a download for requester, who is empty when the
+// link may end up with anyone.
+func (s *MinioService) signDownloadPath(requester string, userID string, photoID string, expiry time.Duration) (string, time.Time, error) {
+	if len(s.signingSecret) == 0 {
+		return "", time.Time{}, ErrProxyDisabled
+	}
+
+	expiresAt := time.Now().Add(expiry).Truncate(time.Second)
+
+	path := fmt.Sprintf("/files/%s/%s/%s?exp=%d",
+		s.downloadSignature(requester, userID, photoID, expiresAt.Unix()),
+		url.PathEscape(userID),
+		url.PathEscape(photoID),
+		expiresAt.Unix(),
+	)
+	if requester != "" {
+		path += "&by=" + url.QueryEscape(requester)
+	}
+
+	return path, expiresAt, nil
+}
+
+// OpenDownload verifies a signed download path and opens the original for
+// streaming. Content length, ETag and modification time come from the
+// object's stat data so range and conditional requests can be answered
+// without reading the body. Photos held back by scanning or moderation are
+// refused unless the link was signed for their owner, who may still see
+// photos awaiting review.
+func (s *MinioService) OpenDownload(ctx context.Context, signature string, requester string, userID string, photoID string, expires int64) (*models.Download, error) {
+	if len(s.signingSecret) == 0 {
+		return nil, ErrProxyDisabled
+	}
+
+	expected := s.downloadSignature(requester, userID, photoID, expires)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return nil, ErrDownloadExpired
+	}
+
+	objectName := legacyObjectName(userID, photoID)
+	public := false
+	meta, err := s.getPhotoMeta(ctx, userID, photoID)
+	switch {
+	case err == nil:
+		if err := servable(meta, requester == meta.UserID); err != nil {
+			return nil, err
+		}
+		objectName = meta.BlobKey
+		public = visibilityOf(meta) == models.VisibilityPublic
+	case !errors.Is(err, storage.ErrObjectNotFound):
+		return nil, fmt.Errorf("failed to load photo record: %w", err)
+	}
+
+	obj, info, err := s.storage.Get(ctx, objectName)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Download{
+		Content:     obj,
+		ContentType: info.ContentType,
+		ETag:        `"` + info.ETag + `"`,
+		ModTime:     info.LastModified,
+		Size:        info.Size,
+		ExpiresAt:   time.Unix(expires, 0),
+		Public:      public,
+	}, nil
+}
+
+// objectURL hands out a time-limited URL for one of a photo's objects.
+// Presigned URLs cannot carry SSE-C keys, so under customer keys it signs a
+// download through the HTTP server instead, which serves the original. So
+// it does while the primary is down and reads come from the replica. The
+// signed download is for requester, as in signDownloadPath.
+func (s *MinioService) objectURL(ctx context.Context, requester string, userID string, photoID string, objectName string, expiry time.Duration) (string, error) {
+	if s.presignable(objectName) {
+		return s.storage.GetPresignedUrlFor(ctx, objectName, expiry)
+	}
+
+	path, _, err := s.signDownloadPath(requester, userID, photoID, expiry)
+	if err != nil {
+		return "", err
+	}
+
+	return s.downloadBaseURL + path, nil
+}
+
+// presignable tells whether clients can be sent to the bucket for
+// objectName rather than through the HTTP server.
+func (s *MinioService) presignable(objectName string) bool {
+	return !s.storage.CustomerKeys(objectName) && !s.storage.PrimaryDown()
+}
+
+// downloadSignature binds the requester into the purpose; links for
+// nobody in particular keep the plain one.
+func (s *MinioService) downloadSignature(requester string, userID string, photoID string, expires int64) string {
+	purpose := "download"
+	if requester != "" {
+		purpose += " for " + strconv.Quote(requester)
+	}
+
+	return s.expiringSignature(purpose, userID, photoID, expires)
+}
+
+// expiringSignature signs an expiring path. The purpose keeps signatures
+// for one endpoint from being replayed against another.
+func (s *MinioService) expiringSignature(purpose string, userID string, id string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	fmt.Fprintf(mac, "%s:%s/%s:%s", purpose, userID, id, strconv.FormatInt(expires, 10))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+)
+
+func newDownloadService(t *testing.T) *MinioService {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.HTTP.SigningSecret = "secret"
+	cfg.Moderation.Enabled = true
+	cfg.Moderation.Classifier = "none"
+	s, _ := newTestService(t, cfg, Dependencies{})
+	return s
+}
+
+// openSigned follows a signed /files path the way the HTTP handler does.
+func openSigned(t *testing.T, s *MinioService, path string) (*models.Download, error) {
+	t.Helper()
+
+	u, err := url.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/files/"), "/")
+	if len(parts) != 3 {
+		t.Fatalf("unexpected download path %s", path)
+	}
+	expires, err := strconv.ParseInt(u.Query().Get("exp"), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s.OpenDownload(context.Background(), parts[0], u.Query().Get("by"), parts[1], parts[2], expires)
+}
+
+func TestSignedDownloadsLetOwnersSeePendingPhotos(t *testing.T) {
+	s := newDownloadService(t)
+	data := testImage(t, 1)
+	meta := uploadTestPhoto(t, s, "alice", data)
+	if moderationOf(meta) != models.ModerationPending {
+		t.Fatalf("photo is %s, want pending", moderationOf(meta))
+	}
+
+	owner, _, err := s.signDownloadPath("alice", "alice", meta.PhotoID, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	download, err := openSigned(t, s, owner)
+	if err != nil {
+		t.Fatalf("owner's link refused: %v", err)
+	}
+	got, err := io.ReadAll(download.Content)
+	download.Content.Close()
+	if err != nil || string(got) != string(data) {
+		t.Fatalf("owner's link served %d bytes, %v", len(got), err)
+	}
+
+	anyone, _, err := s.signDownloadPath("", "alice", meta.PhotoID, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openSigned(t, s, anyone); !errors.Is(err, ErrModerationPending) {
+		t.Fatalf("link for anyone returned %v, want ErrModerationPending", err)
+	}
+
+	// Claiming to be the owner on a link signed for someone else fails.
+	if _, err := openSigned(t, s, anyone+"&by=alice"); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("forged requester returned %v, want ErrInvalidSignature", err)
+	}
+	bob, _, err := s.signDownloadPath("bob", "alice", meta.PhotoID, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openSigned(t, s, strings.Replace(bob, "by=bob", "by=alice", 1)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("swapped requester returned %v, want ErrInvalidSignature", err)
+	}
+	if _, err := openSigned(t, s, bob); !errors.Is(err, ErrModerationPending) {
+		t.Fatalf("another user's link returned %v, want ErrModerationPending", err)
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/events"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+)
+
+// Types of the upload and delete events published on the event bus,
+// alongside EventModerationChanged.
+const (
+	EventPhotoUploaded = "photo.uploaded"
+	EventAvatarChanged = "avatar.changed"
+	EventPhotoDeleted  = "photo.deleted"
+)
+
+// publishStored announces a committed upload. The photo is stored either
+// way, so a failed publish does not fail the upload.
+func (s *MinioService) publishStored(ctx context.Context, meta *models.PhotoMeta) {
+	eventType := EventPhotoUploaded
+	if meta.Kind == models.KindAvatar {
+		eventType = EventAvatarChanged
+	}
+
+	_ = s.publish(ctx, events.Event{
+		Type: eventType,
+		Key:  meta.UserID,
+		Payload: models.PhotoEvent{
+			UserID:      meta.UserID,
+			PhotoID:     meta.PhotoID,
+			Kind:        meta.Kind,
+			ContentType: meta.ContentType,
+			FileSize:    meta.FileSize,
+		},
+		OccurredAt: meta.CreatedAt,
+	})
+}
+
+// publishDeleted announces a committed delete.
+func (s *MinioService) publishDeleted(ctx context.Context, userID string, photoID string, kind models.PhotoKind) {
+	_ = s.publish(ctx, events.Event{
+		Type: EventPhotoDeleted,
+		Key:  userID,
+		Payload: models.PhotoEvent{
+			UserID:  userID,
+			PhotoID: photoID,
+			Kind:    kind,
+		},
+		OccurredAt: time.Now().UTC(),
+	})
+}
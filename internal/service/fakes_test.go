@@ -0,0 +1,66 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage/storagetest"
+)
+
+const testBucket = "test"
+
+// newTestService builds a service over an in-memory bucket, with deps
+// filled in around the storage client.
+func newTestService(t *testing.T, cfg *config.Config, deps Dependencies) (*MinioService, *storagetest.FakeS3) {
+	t.Helper()
+
+	store, fake := storagetest.NewClient(t, testBucket)
+	deps.Storage = store
+
+	return NewMinioService(deps, cfg), fake
+}
+
+// testImage encodes a small PNG, a different one for every shade.
+func testImage(t *testing.T, shade uint8) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := range 8 {
+		for y := range 8 {
+			img.Set(x, y, color.RGBA{R: shade, G: uint8(x * 16), B: uint8(y * 16), A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func uploadTestPhoto(t *testing.T, s *MinioService, userID string, data []byte) *models.PhotoMeta {
+	t.Helper()
+	ctx := context.Background()
+
+	uploaded, err := s.UploadPhotos(ctx, userID, []models.PhotoData{{
+		Data:        bytes.NewReader(data),
+		FileSize:    int64(len(data)),
+		FileName:    "photo.png",
+		ContentType: "image/png",
+	}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := s.getPhotoMeta(ctx, userID, uploaded[0].PhotoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return meta
+}
@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+const gcPageSize = 1000
+
+// CollectGarbage removes objects the catalog no longer accounts for: blobs
+// and renditions no photo record points at, blob refs left without a
+// photo, staged direct uploads that were never ingested, moderation
+// markers of photos that are gone and user data exports past their expiry.
+// Legacy objects, documents, quarantine and the event queues are left
+// alone.
+//
+// Only orphans older than the grace period are removed, so uploads still
+// between writing their blob and their record are safe. A completed pass
+// measures what the blobs left take up.
+func (s *MinioService) CollectGarbage(ctx context.Context, opts models.GCOptions) (*models.GCReport, error) {
+	c := &collector{
+		s:      s,
+		cutoff: time.Now().Add(-opts.GracePeriod),
+		// Exports are only needed until their URL expires.
+		exportCutoff: time.Now().Add(-s.exportExpiry),
+		dryRun:       opts.DryRun,
+		report:       &models.GCReport{DryRun: opts.DryRun},
+
+		reported:  make(map[string]bool),
+		collected: make(map[string]bool),
+	}
+	if opts.DeletesPerSecond > 0 && !opts.DryRun {
+		ticker := time.NewTicker(time.Second / time.Duration(opts.DeletesPerSecond))
+		defer ticker.Stop()
+		c.limit = ticker.C
+	}
+
+	// The catalog is read first: anything written after it is younger than
+	// the grace period.
+	if err := c.loadCatalog(ctx); err != nil {
+		return c.report, err
+	}
+
+	steps := []struct {
+		prefix string
+		visit  func(ctx context.Context, object gcObject) error
+	}{
+		{"blobs/", c.blob},
+		{"_meta/blobs/", c.blobRef},
+		{uploadsRoot, c.stagedUpload},
+		{"_meta/moderation/", c.moderationMarker},
+		{exportsRoot, c.export},
+	}
+	for _, step := range steps {
+		if err := c.walk(ctx, step.prefix, c.pastGrace(step.visit)); err != nil {
+			return c.report, err
+		}
+	}
+
+	stored, err := s.storedBytes(ctx)
+	if err != nil {
+		return c.report, err
+	}
+	metrics.StoredBytes.Set(stored)
+
+	return c.report, nil
+}
+
+// storedBytes sums up the size of every blob and rendition.
+func (s *MinioService) storedBytes(ctx context.Context) (int64, error) {
+	var total int64
+	startAfter := ""
+	for {
+		objects, err := s.storage.ListPage(ctx, "blobs/", startAfter, gcPageSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, object := range objects {
+			total += object.Size
+		}
+
+		if len(objects) < gcPageSize {
+			return total, nil
+		}
+		startAfter = objects[len(objects)-1].Key
+	}
+}
+
+// gcObject is the part of a listing entry the collector looks at.
+type gcObject struct {
+	key      string
+	size     int64
+	modified time.Time
+}
+
+type collector struct {
+	s            *MinioService
+	cutoff       time.Time
+	exportCutoff time.Time
+	dryRun       bool
+	limit        <-chan time.Time
+	report       *models.GCReport
+
+	// blobs holds the blob key of every photo record, photos the record
+	// keys themselves.
+	blobs  map[string]bool
+	photos map[string]bool
+	// collected holds the blobs already dealt with in this pass.
+	collected map[string]bool
+	// reported keeps a dry run from counting a blob ref both with its
+	// blob and on its own.
+	reported map[string]bool
+}
+
+// loadCatalog collects the blobs referenced from the catalog and the
+// trash; only catalog records count as photos.
+func (c *collector) loadCatalog(ctx context.Context) error {
+	c.blobs = make(map[string]bool)
+	c.photos = make(map[string]bool)
+
+	for _, root := range []string{photoMetaRoot, trashRoot} {
+		err := c.walk(ctx, root, func(ctx context.Context, object gcObject) error {
+			if !strings.HasSuffix(object.key, ".json") {
+				return nil
+			}
+
+			var meta models.PhotoMeta
+			if err := c.s.storage.GetJSON(ctx, object.key, &meta); err != nil {
+				if errors.Is(err, storage.ErrObjectNotFound) {
+					return nil
+				}
+				return fmt.Errorf("failed to load photo record: %w", err)
+			}
+
+			if root == photoMetaRoot {
+				c.photos[object.key] = true
+			}
+			c.blobs[meta.BlobKey] = true
+			c.blobs[blobKey(meta.Kind, meta.SHA256)] = true
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walk pages through prefix, visiting every object.
+func (c *collector) walk(ctx context.Context, prefix string, visit func(ctx context.Context, object gcObject) error) error {
+	startAfter := ""
+	for {
+		objects, err := c.s.storage.ListPage(ctx, prefix, startAfter, gcPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		for _, object := range objects {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			c.report.Scanned++
+			if err := visit(ctx, gcObject{key: object.Key, size: object.Size, modified: object.LastModified}); err != nil {
+				return err
+			}
+		}
+
+		if len(objects) < gcPageSize {
+			return nil
+		}
+		startAfter = objects[len(objects)-1].Key
+	}
+}
+
+// pastGrace skips objects younger than the grace period.
+func (c *collector) pastGrace(visit func(ctx context.Context, object gcObject) error) func(ctx context.Context, object gcObject) error {
+	return func(ctx context.Context, object gcObject) error {
+		if !object.modified.Before(c.cutoff) {
+			return nil
+		}
+		return visit(ctx, object)
+	}
+}
+
+// blob collects an unreferenced blob or rendition.
+func (c *collector) blob(ctx context.Context, object gcObject) error {
+	kindName, name, ok := strings.Cut(strings.TrimPrefix(object.key, "blobs/"), "/")
+	if !ok {
+		return nil
+	}
+	hash, _, _ := strings.Cut(name, ".")
+
+	return c.collectBlob(ctx, models.PhotoKind(kindName), hash)
+}
+
+// blobRef collects a ref whose blob is gone or was collected in an earlier
+// pass.
+func (c *collector) blobRef(ctx context.Context, object gcObject) error {
+	kindName, name, ok := strings.Cut(strings.TrimPrefix(object.key, "_meta/blobs/"), "/")
+	if !ok || !strings.HasSuffix(name, ".json") {
+		return nil
+	}
+
+	return c.collectBlob(ctx, models.PhotoKind(kindName), strings.TrimSuffix(name, ".json"))
+}
+
+// collectBlob removes a blob no photo record points at, together with its
+// renditions and its ref, unless any of them is younger than the grace
+// period. Once its turn under the rate limit comes, the ref is re-read and
+// claimed with a conditional write: an upload deduplicating against the
+// blob on any instance either lands its reference first, which calls the
+// collection off, or waits for the blob to be gone and stores it anew.
+func (c *collector) collectBlob(ctx context.Context, kind models.PhotoKind, hash string) error {
+	key := blobKey(kind, hash)
+	if c.blobs[key] || c.collected[key] {
+		return nil
+	}
+	c.collected[key] = true
+
+	unlock := c.s.blobLocks.Lock(key)
+	defer unlock()
+
+	// The blob key prefixes its renditions too.
+	objects, err := c.s.storage.List(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to list blob: %w", err)
+	}
+	refs, err := c.s.storage.List(ctx, blobRefKey(kind, hash))
+	if err != nil {
+		return fmt.Errorf("failed to check blob ref: %w", err)
+	}
+	// The ref goes last, so a failed pass leaves the claim in place for
+	// uploads to wait out.
+	objects = append(objects, refs...)
+	for _, object := range objects {
+		if !object.LastModified.Before(c.cutoff) {
+			return nil
+		}
+	}
+
+	if !c.dryRun {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+
+		claimed, err := c.claim(ctx, kind, hash)
+		if err != nil {
+			c.report.Failed++
+			return nil
+		}
+		if !claimed {
+			return nil
+		}
+	}
+
+	for _, object := range objects {
+		removed, err := c.remove(ctx, object.Key, object.Size)
+		if err != nil || !removed {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var errBlobInUse = errors.New("blob is in use")
+
+// claim marks a blob's ref for removal, or reports false when a reference
+// has been taken since the catalog was read or the blob is being removed
+// elsewhere already.
+func (c *collector) claim(ctx context.Context, kind models.PhotoKind, hash string) (bool, error) {
+	_, err := c.s.updateBlobRef(ctx, kind, hash, func(ref *models.BlobRef) (*models.BlobRef, error) {
+		now := time.Now().UTC()
+		switch {
+		case ref == nil:
+			return &models.BlobRef{BlobKey: blobKey(kind, hash), SHA256: hash, Claimed: &now}, nil
+		case claimed(ref), ref.Refs > 0 && !ref.UpdatedAt.Before(c.cutoff):
+			return nil, errBlobInUse
+		}
+
+		ref.Refs = 0
+		ref.Claimed = &now
+		return ref, nil
+	})
+	if errors.Is(err, errBlobInUse) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// stagedUpload collects a direct upload that outlived its grace period
+// without being ingested.
+func (c *collector) stagedUpload(ctx context.Context, object gcObject) error {
+	userID, photoID, ok := strings.Cut(strings.TrimPrefix(object.key, uploadsRoot), "/")
+	if ok {
+		unlock := c.s.metaLocks.Lock(photoMetaKey(userID, photoID))
+		defer unlock()
+	}
+
+	_, err := c.remove(ctx, object.key, object.size)
+	return err
+}
+
+// moderationMarker collects the index entry of a photo that no longer
+// exists.
+func (c *collector) moderationMarker(ctx context.Context, object gcObject) error {
+	parts := strings.Split(strings.TrimPrefix(object.key, "_meta/moderation/"), "/")
+	if len(parts) != 3 {
+		return nil
+	}
+	if c.photos[photoMetaKey(parts[1], parts[2])] {
+		return nil
+	}
+
+	_, err := c.remove(ctx, object.key, object.size)
+	return err
+}
+
+// export collects a user data export whose URL has expired.
+func (c *collector) export(ctx context.Context, object gcObject) error {
+	if !object.modified.Before(c.exportCutoff) {
+		return nil
+	}
+
+	_, err := c.remove(ctx, object.key, object.size)
+	return err
+}
+
+// remove deletes one orphan, at most at the configured rate, and reports
+// whether it is gone (or would be, in a dry run). Failures are counted
+// rather than returned, so one bad object does not stop the pass.
+func (c *collector) remove(ctx context.Context, key string, size int64) (bool, error) {
+	if c.dryRun && c.reported[key] {
+		return true, nil
+	}
+
+	c.report.Orphans++
+	metrics.GCOrphans.Add(1)
+
+	if c.dryRun {
+		c.reported[key] = true
+		c.report.ReclaimedBytes += size
+		return true, nil
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return false, err
+	}
+
+	// On a versioned bucket a plain delete would reclaim nothing.
+	if err := c.s.storage.Purge(ctx, key); err != nil {
+		c.report.Failed++
+		return false, nil
+	}
+
+	c.report.Deleted++
+	c.report.ReclaimedBytes += size
+	metrics.GCDeleted.Add(1)
+	metrics.GCReclaimedBytes.Add(size)
+
+	return true, nil
+}
+
+// wait holds a write back to the configured rate.
+func (c *collector) wait(ctx context.Context) error {
+	if c.limit == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.limit:
+		return nil
+	}
+}
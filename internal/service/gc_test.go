@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage/storagetest"
+)
+
+// age backdates everything in the bucket, blob refs' update times
+// included.
+func age(t *testing.T, s *MinioService, fake *storagetest.FakeS3, by time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, key := range fake.Keys(testBucket) {
+		if !strings.HasPrefix(key, "_meta/blobs/") {
+			continue
+		}
+		var ref models.BlobRef
+		if err := s.storage.GetJSON(ctx, key, &ref); err != nil {
+			t.Fatal(err)
+		}
+		ref.UpdatedAt = ref.UpdatedAt.Add(-by)
+		if err := s.storage.PutJSON(ctx, key, &ref); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, key := range fake.Keys(testBucket) {
+		fake.Age(testBucket, key, by)
+	}
+}
+
+func TestCollectGarbageSelectsOldOrphans(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newTestService(t, &config.Config{}, Dependencies{})
+
+	kept := uploadTestPhoto(t, s, "alice", testImage(t, 1))
+	orphan := uploadTestPhoto(t, s, "alice", testImage(t, 2))
+	if err := s.storage.Delete(ctx, photoMetaKey("alice", orphan.PhotoID)); err != nil {
+		t.Fatal(err)
+	}
+	for key, content := range map[string]string{
+		stagedUploadKey("alice", "abandoned.png"):                    "never ingested",
+		moderationKey(models.ModerationPending, "alice", "gone.png"): "{}",
+	} {
+		if err := s.storage.Upload(ctx, key, strings.NewReader(content), int64(len(content)), "application/octet-stream"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	age(t, s, fake, 2*time.Hour)
+
+	// Orphaned as well, but too recently to tell from an upload in flight.
+	recent := uploadTestPhoto(t, s, "alice", testImage(t, 3))
+	if err := s.storage.Delete(ctx, photoMetaKey("alice", recent.PhotoID)); err != nil {
+		t.Fatal(err)
+	}
+
+	before := fake.Keys(testBucket)
+	opts := models.GCOptions{GracePeriod: time.Hour, DryRun: true}
+
+	report, err := s.CollectGarbage(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The orphaned blob and its ref, the staged upload and the marker.
+	if report.Orphans != 4 || report.Deleted != 0 {
+		t.Fatalf("dry run report %+v, want 4 orphans and nothing deleted", report)
+	}
+	if !slices.Equal(fake.Keys(testBucket), before) {
+		t.Fatal("dry run removed objects")
+	}
+
+	opts.DryRun = false
+	report, err = s.CollectGarbage(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Deleted != 4 || report.Failed != 0 {
+		t.Fatalf("report %+v, want 4 deleted", report)
+	}
+
+	for key, want := range map[string]bool{
+		kept.BlobKey: true,
+		blobRefKey(models.KindPhoto, kept.SHA256):                    true,
+		photoMetaKey("alice", kept.PhotoID):                          true,
+		recent.BlobKey:                                               true,
+		blobRefKey(models.KindPhoto, recent.SHA256):                  true,
+		orphan.BlobKey:                                               false,
+		blobRefKey(models.KindPhoto, orphan.SHA256):                  false,
+		stagedUploadKey("alice", "abandoned.png"):                    false,
+		moderationKey(models.ModerationPending, "alice", "gone.png"): false,
+	} {
+		if got := slices.Contains(fake.Keys(testBucket), key); got != want {
+			t.Errorf("%s present %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestCollectGarbageLeavesBlobsTakenSinceTheCatalogWasRead(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newTestService(t, &config.Config{}, Dependencies{})
+
+	orphan := uploadTestPhoto(t, s, "alice", testImage(t, 1))
+	if err := s.storage.Delete(ctx, photoMetaKey("alice", orphan.PhotoID)); err != nil {
+		t.Fatal(err)
+	}
+	age(t, s, fake, 2*time.Hour)
+
+	// Another instance deduplicates against the blob after this pass
+	// listed it: its ref update lands before the claim.
+	c := &collector{
+		s:         s,
+		cutoff:    time.Now().Add(-time.Hour),
+		report:    &models.GCReport{},
+		blobs:     map[string]bool{},
+		collected: map[string]bool{},
+		reported:  map[string]bool{},
+	}
+	if _, err := s.updateBlobRef(ctx, models.KindPhoto, orphan.SHA256, func(ref *models.BlobRef) (*models.BlobRef, error) {
+		ref.Refs++
+		return ref, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := c.claim(ctx, models.KindPhoto, orphan.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed {
+		t.Fatal("claimed a blob whose ref was just taken")
+	}
+
+	var ref models.BlobRef
+	if err := s.storage.GetJSON(ctx, blobRefKey(models.KindPhoto, orphan.SHA256), &ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref.Claimed != nil || ref.Refs != 2 {
+		t.Fatalf("ref %+v after a refused claim", ref)
+	}
+	if !slices.Contains(fake.Keys(testBucket), orphan.BlobKey) {
+		t.Fatal("removed a blob whose ref was just taken")
+	}
+}
+
+func TestReplacedAvatarsAreRetired(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.PresignedUrl.ExpiryHours = 1
+	s, _ := newTestService(t, cfg, Dependencies{})
+
+	photo := uploadTestPhoto(t, s, "alice", testImage(t, 1))
+
+	var avatars []*models.PhotoMeta
+	for shade := range uint8(2) {
+		data := testImage(t, 10+shade)
+		avatar, err := s.UploadAvatar(ctx, "alice", bytes.NewReader(data), "avatar.png", int64(len(data)), "image/png", models.Checksums{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta, err := s.getPhotoMeta(ctx, "alice", avatar.PhotoID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		avatars = append(avatars, meta)
+	}
+
+	if _, err := s.getPhotoMeta(ctx, "alice", avatars[0].PhotoID); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Fatalf("replaced avatar still in the catalog: %v", err)
+	}
+	if s.storage.ObjectExists(ctx, avatars[0].BlobKey) {
+		t.Fatal("replaced avatar's blob was kept")
+	}
+
+	for _, photoID := range []string{avatars[1].PhotoID, photo.PhotoID} {
+		if _, err := s.getPhotoMeta(ctx, "alice", photoID); err != nil {
+			t.Fatalf("photo %s: %v", photoID, err)
+		}
+	}
+}
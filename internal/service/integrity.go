@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+var (
+	ErrInvalidChecksum  = errors.New("checksums must be hex sha256 and crc32c")
+	ErrChecksumMismatch = errors.New("upload does not match its checksum")
+	// ErrDamagedInTransit means storage received something other than the
+	// service sent; the upload may be retried.
+	ErrDamagedInTransit = storage.ErrChecksumMismatch
+)
+
+// Stored objects that fail a scrub are flagged here, under their own key.
+const corruptRoot = "_meta/corrupt/"
+
+func corruptKey(objectName string) string {
+	return corruptRoot + objectName + ".json"
+}
+
+// Documents keep the checksums of their plaintext in user metadata, keyed
+// as minio-go reports them back.
+const (
+	sha256Meta = "Sha256"
+	crc32cMeta = "Crc32c"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// checksumReader computes the checksums of an upload as it streams
+// through. The read that completes the upload fails instead when they
+// differ from the ones the client sent, withholding the last bytes, so a
+// damaged upload never reaches storage whole.
+type checksumReader struct {
+	r      io.Reader
+	size   int64
+	read   int64
+	want   models.Checksums
+	sha256 hash.Hash
+	crc32c hash.Hash32
+	// mismatch is set once the upload has failed verification.
+	mismatch error
+}
+
+// newChecksumReader reads size bytes, or with size unknown (-1), up to EOF.
+func newChecksumReader(r io.Reader, size int64, want models.Checksums) (*checksumReader, error) {
+	want = models.Checksums{
+		SHA256: strings.ToLower(want.SHA256),
+		CRC32C: strings.ToLower(want.CRC32C),
+	}
+	if !validChecksum(want.SHA256, sha256.Size) || !validChecksum(want.CRC32C, crc32.Size) {
+		return nil, ErrInvalidChecksum
+	}
+
+	return &checksumReader{
+		r:      r,
+		size:   size,
+		want:   want,
+		sha256: sha256.New(),
+		crc32c: crc32.New(castagnoli),
+	}, nil
+}
+
+func validChecksum(sum string, size int) bool {
+	if sum == "" {
+		return true
+	}
+
+	decoded, err := hex.DecodeString(sum)
+	return err == nil && len(decoded) == size
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.sha256.Write(p[:n])
+	c.crc32c.Write(p[:n])
+
+	c.read += int64(n)
+
+	if errors.Is(err, io.EOF) || c.size >= 0 && c.read >= c.size {
+		if err := c.verify(); err != nil {
+			c.mismatch = err
+			return 0, err
+		}
+	}
+
+	return n, err
+}
+
+func (c *checksumReader) verify() error {
+	sums := c.sums()
+	if c.want.SHA256 != "" && c.want.SHA256 != sums.SHA256 || c.want.CRC32C != "" && c.want.CRC32C != sums.CRC32C {
+		metrics.ChecksumMismatches.Add(1)
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+// sums are the checksums of what has been read so far.
+func (c *checksumReader) sums() models.Checksums {
+	return models.Checksums{
+		SHA256: hex.EncodeToString(c.sha256.Sum(nil)),
+		CRC32C: hex.EncodeToString(c.crc32c.Sum(nil)),
+	}
+}
+
+func checksumMetadata(sums models.Checksums) map[string]string {
+	metadata := make(map[string]string, 2)
+	if sums.SHA256 != "" {
+		metadata[sha256Meta] = sums.SHA256
+	}
+	if sums.CRC32C != "" {
+		metadata[crc32cMeta] = sums.CRC32C
+	}
+
+	return metadata
+}
+
+// Scrub re-reads stored photos and documents and checks them against the
+// checksums they were stored with: a blob's key is its SHA-256, and a
+// document is authenticated by its encryption besides any checksum it
+// carries. Renditions and legacy objects have nothing to check against and
+// are skipped. Corrupt objects are counted and flagged under _meta/corrupt/
+// but left in place, to be restored by an operator; the flag goes once the
+// object checks out again. Reads are held to objectsPerSecond; 0 means
+// unlimited.
+func (s *MinioService) Scrub(ctx context.Context, objectsPerSecond int) (*models.ScrubReport, error) {
+	report := &models.ScrubReport{}
+
+	// Clearing flags only where they exist spares a delete, and on a
+	// versioned bucket a delete marker, for every healthy object.
+	flags, err := s.storage.List(ctx, corruptRoot)
+	if err != nil {
+		return report, fmt.Errorf("failed to list corrupt objects: %w", err)
+	}
+	flagged := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		flagged[flag.Key] = true
+	}
+
+	var limit <-chan time.Time
+	if objectsPerSecond > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(objectsPerSecond))
+		defer ticker.Stop()
+		limit = ticker.C
+	}
+
+	prefixes := []string{"blobs/"}
+	if s.documents != nil {
+		prefixes = append(prefixes, documentsRoot+"/")
+	}
+	for _, prefix := range prefixes {
+		startAfter := ""
+		for {
+			objects, err := s.storage.ListPage(ctx, prefix, startAfter, gcPageSize)
+			if err != nil {
+				return report, fmt.Errorf("failed to list objects: %w", err)
+			}
+
+			for _, object := range objects {
+				if limit != nil {
+					select {
+					case <-ctx.Done():
+						return report, ctx.Err()
+					case <-limit:
+					}
+				}
+				if err := ctx.Err(); err != nil {
+					return report, err
+				}
+
+				if err := s.scrubObject(ctx, report, object.Key, flagged); err != nil {
+					return report, err
+				}
+			}
+
+			if len(objects) < gcPageSize {
+				break
+			}
+			startAfter = objects[len(objects)-1].Key
+		}
+	}
+
+	return report, nil
+}
+
+func (s *MinioService) scrubObject(ctx context.Context, report *models.ScrubReport, key string, flagged map[string]bool) error {
+	var content io.ReadCloser
+	var expected string
+	var err error
+	if strings.HasPrefix(key, documentsRoot+"/") {
+		obj, info, getErr := s.documents.Get(ctx, key)
+		content, expected, err = obj, info.UserMetadata[sha256Meta], getErr
+	} else {
+		// Renditions are named after their blob with a format suffix.
+		expected = path.Base(key)
+		if strings.Contains(expected, ".") || !validChecksum(expected, sha256.Size) {
+			report.Skipped++
+			return nil
+		}
+		content, _, err = s.storage.Get(ctx, key)
+	}
+
+	var actual string
+	switch {
+	case errors.Is(err, storage.ErrObjectNotFound):
+		// Deleted since it was listed.
+		return nil
+	case errors.Is(err, storage.ErrObjectCorrupt):
+	case err != nil:
+		return fmt.Errorf("failed to open %s: %w", key, err)
+	default:
+		sum := sha256.New()
+		_, err := io.Copy(sum, content)
+		content.Close()
+		switch {
+		case errors.Is(err, storage.ErrObjectCorrupt):
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", key, err)
+		default:
+			actual = hex.EncodeToString(sum.Sum(nil))
+		}
+	}
+
+	metrics.ScrubbedObjects.Add(1)
+	report.Checked++
+	// Documents stored without a checksum only have their encryption to
+	// vouch for them.
+	if actual != "" && (expected == "" || actual == expected) {
+		if !flagged[corruptKey(key)] {
+			return nil
+		}
+		// Restored by an operator since the last pass.
+		if err := s.storage.Delete(ctx, corruptKey(key)); err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+			return fmt.Errorf("failed to clear flag on %s: %w", key, err)
+		}
+		return nil
+	}
+
+	report.Corrupt++
+	metrics.CorruptObjects.Add(1)
+	if err := s.storage.PutJSON(ctx, corruptKey(key), models.CorruptObject{
+		Key:        key,
+		Expected:   expected,
+		Actual:     actual,
+		DetectedAt: time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("failed to flag %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+)
+
+func checksumsOf(data []byte) models.Checksums {
+	sum := sha256.Sum256(data)
+	crc := crc32.New(castagnoli)
+	crc.Write(data)
+
+	return models.Checksums{
+		SHA256: hex.EncodeToString(sum[:]),
+		CRC32C: hex.EncodeToString(crc.Sum(nil)),
+	}
+}
+
+func TestChecksumReaderAcceptsMatchingUpload(t *testing.T) {
+	data := []byte("the quick brown fox")
+	want := checksumsOf(data)
+	// Clients may send either case.
+	want.SHA256 = strings.ToUpper(want.SHA256)
+
+	for _, size := range []int64{int64(len(data)), -1} {
+		r, err := newChecksumReader(iotest.OneByteReader(bytes.NewReader(data)), size, want)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("size %d: read %q", size, got)
+		}
+		if r.sums() != checksumsOf(data) {
+			t.Fatalf("size %d: sums %+v", size, r.sums())
+		}
+	}
+}
+
+func TestChecksumReaderWithholdsTheLastReadOnMismatch(t *testing.T) {
+	data := []byte("the quick brown fox")
+	want := checksumsOf([]byte("the quick brown cat"))
+
+	for _, field := range []string{"sha256", "crc32c"} {
+		sent := models.Checksums{SHA256: want.SHA256}
+		if field == "crc32c" {
+			sent = models.Checksums{CRC32C: want.CRC32C}
+		}
+
+		r, err := newChecksumReader(bytes.NewReader(data), int64(len(data)), sent)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := io.ReadAll(r)
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("%s: read returned %v, want ErrChecksumMismatch", field, err)
+		}
+		if len(got) == len(data) {
+			t.Fatalf("%s: the whole upload was handed on", field)
+		}
+		if !errors.Is(r.mismatch, ErrChecksumMismatch) {
+			t.Fatalf("%s: mismatch not recorded", field)
+		}
+	}
+}
+
+func TestChecksumReaderWithoutChecksums(t *testing.T) {
+	data := []byte("anything goes")
+
+	r, err := newChecksumReader(bytes.NewReader(data), int64(len(data)), models.Checksums{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(r); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("read %q, %v", got, err)
+	}
+}
+
+func TestChecksumReaderRejectsMalformedChecksums(t *testing.T) {
+	for _, sums := range []models.Checksums{
+		{SHA256: "abc"},
+		{SHA256: strings.Repeat("zz", sha256.Size)},
+		{CRC32C: "0102030405"},
+	} {
+		if _, err := newChecksumReader(bytes.NewReader(nil), 0, sums); !errors.Is(err, ErrInvalidChecksum) {
+			t.Fatalf("%+v: got %v, want ErrInvalidChecksum", sums, err)
+		}
+	}
+}
+
+func TestScrubFlagsCorruptBlobsUntilRestored(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, &config.Config{}, Dependencies{})
+
+	data := testImage(t, 1)
+	meta := uploadTestPhoto(t, s, "alice", data)
+
+	damaged := bytes.Clone(data)
+	damaged[len(damaged)/2] ^= 1
+	if err := s.storage.Upload(ctx, meta.BlobKey, bytes.NewReader(damaged), int64(len(damaged)), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := s.Scrub(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Corrupt != 1 || !s.storage.ObjectExists(ctx, corruptKey(meta.BlobKey)) {
+		t.Fatalf("report %+v, want the blob flagged", report)
+	}
+
+	if err := s.storage.Upload(ctx, meta.BlobKey, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = s.Scrub(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Corrupt != 0 || s.storage.ObjectExists(ctx, corruptKey(meta.BlobKey)) {
+		t.Fatalf("report %+v, want the flag cleared after the restore", report)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// LifecycleRules turns the lifecycle config into bucket rules. A rule's
+// kind stands for the blob prefix of that kind.
+func LifecycleRules(cfg config.Lifecycle) ([]storage.LifecycleRule, error) {
+	switch cfg.Fallback {
+	case "auto", "always", "never":
+	default:
+		return nil, fmt.Errorf("unknown lifecycle fallback %q", cfg.Fallback)
+	}
+
+	rules := make([]storage.LifecycleRule, 0, len(cfg.Rules))
+	seen := make(map[string]bool, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		prefix := rule.Prefix
+		switch models.PhotoKind(rule.Kind) {
+		case "":
+		case models.KindAvatar, models.KindPhoto:
+			if prefix != "" {
+				return nil, fmt.Errorf("lifecycle rule %d sets both prefix and kind", i+1)
+			}
+			prefix = blobPrefix(models.PhotoKind(rule.Kind))
+		default:
+			return nil, fmt.Errorf("lifecycle rule %d has unknown kind %q", i+1, rule.Kind)
+		}
+
+		id := rule.ID
+		if id == "" {
+			id = prefix
+		}
+		switch {
+		case id == "":
+			return nil, fmt.Errorf("lifecycle rule %d needs an id", i+1)
+		case seen[id]:
+			return nil, fmt.Errorf("lifecycle rule id %q is used twice", id)
+		case rule.ExpireAfter < 0 || rule.NoncurrentExpireAfter < 0 || rule.TransitionAfter < 0:
+			return nil, fmt.Errorf("lifecycle rule %q has a negative age", id)
+		case rule.ExpireAfter == 0 && rule.NoncurrentExpireAfter == 0 && rule.TransitionAfter == 0:
+			return nil, fmt.Errorf("lifecycle rule %q has nothing to do", id)
+		case rule.TransitionAfter > 0 && rule.StorageClass == "":
+			return nil, fmt.Errorf("lifecycle rule %q transitions without a storage class", id)
+		case rule.ExpireAfter > 0 && coversBlobs(prefix):
+			// Blobs are shared between photos and counted; expiring one
+			// behind its references would break every photo using it.
+			return nil, fmt.Errorf("lifecycle rule %q expires stored blobs; only versions and transitions may apply to them", id)
+		}
+		seen[id] = true
+
+		rules = append(rules, storage.LifecycleRule{
+			ID:                    id,
+			Prefix:                prefix,
+			ExpireAfter:           rule.ExpireAfter,
+			NoncurrentExpireAfter: rule.NoncurrentExpireAfter,
+			TransitionAfter:       rule.TransitionAfter,
+			StorageClass:          rule.StorageClass,
+		})
+	}
+
+	return rules, nil
+}
+
+// coversBlobs reports whether a rule prefix takes in stored blobs.
+func coversBlobs(prefix string) bool {
+	return strings.HasPrefix(prefix, blobsRoot) || strings.HasPrefix(blobsRoot, prefix)
+}
+
+// EnforceLifecycle does the expiry half of the lifecycle rules the bucket
+// does not run itself: objects past their age are deleted, and with
+// versioning on, old versions past theirs are removed. Transitions are
+// left to the backend. It reports how many objects and versions went.
+func (s *MinioService) EnforceLifecycle(ctx context.Context) (int, error) {
+	expired := 0
+	for _, rule := range s.lifecycle {
+		if rule.ExpireAfter > 0 {
+			n, err := s.expireObjects(ctx, rule.Prefix, rule.ExpireAfter)
+			expired += n
+			if err != nil {
+				return expired, fmt.Errorf("failed to apply lifecycle rule %s: %w", rule.ID, err)
+			}
+		}
+
+		if rule.NoncurrentExpireAfter > 0 {
+			n, err := s.storage.ExpireNoncurrent(ctx, rule.Prefix, rule.NoncurrentExpireAfter)
+			expired += n
+			metrics.LifecycleExpired.Add(int64(n))
+			if err != nil {
+				return expired, fmt.Errorf("failed to apply lifecycle rule %s: %w", rule.ID, err)
+			}
+		}
+	}
+
+	return expired, nil
+}
+
+func (s *MinioService) expireObjects(ctx context.Context, prefix string, age time.Duration) (int, error) {
+	objects, err := s.storage.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-age)
+	expired := 0
+	for _, object := range objects {
+		if err := ctx.Err(); err != nil {
+			return expired, err
+		}
+		if object.LastModified.After(cutoff) {
+			continue
+		}
+
+		if err := s.storage.Delete(ctx, object.Key); err != nil {
+			return expired, err
+		}
+		expired++
+		metrics.LifecycleExpired.Add(1)
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/events"
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/moderation"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+var (
+	ErrInvalidModeration = errors.New("moderation decision must be approve or reject")
+	ErrModerationPending = errors.New("photo is awaiting moderation")
+	ErrInvalidPageToken  = errors.New("invalid page token")
+)
+
+const EventModerationChanged = "photo.moderation_changed"
+
+// maxModerationEvents bounds the event IDs kept on a photo record; older
+// events have long been relayed.
+const maxModerationEvents = 16
+
+const (
+	actorClassifier = "classifier"
+	actorReviewer   = "reviewer"
+)
+
+// Photos awaiting review and rejected photos awaiting purge are indexed by
+// marker objects, so neither listing has to walk the whole catalog.
+func moderationPrefix(state models.ModerationState) string {
+	return fmt.Sprintf("_meta/moderation/%s/", state)
+}
+
+func moderationKey(state models.ModerationState, userID string, photoID string) string {
+	return moderationPrefix(state) + userID + "/" + photoID
+}
+
+// NewClassifier builds the configured classifier, or returns nil when every
+// upload goes straight to human review.
+func NewClassifier(cfg config.Moderation) (moderation.Classifier, error) {
+	switch cfg.Classifier {
+	case "", "none":
+		return nil, nil
+	case "http":
+		if cfg.ClassifierURL == "" {
+			return nil, fmt.Errorf("http classifier requires a classifier url")
+		}
+		return moderation.NewHTTPClassifier(cfg.ClassifierURL, cfg.ClassifierTimeout), nil
+	default:
+		return nil, fmt.Errorf("unknown classifier %q", cfg.Classifier)
+	}
+}
+
+// moderationOf reads a photo's state; photos stored before moderation
+// existed count as approved.
+func moderationOf(meta *models.PhotoMeta) models.ModerationState {
+	if meta.Moderation == "" {
+		return models.ModerationApproved
+	}
+
+	return meta.Moderation
+}
+
+type requesterKey struct{}
+
+// WithRequester marks ctx as made on behalf of a user, who is shown their
+// own photos while review is pending.
+func WithRequester(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, requesterKey{}, userID)
+}
+
+// requestedBy reports whether ctx was made on behalf of userID. Requests
+// naming no requester act for nobody in particular.
+func requestedBy(ctx context.Context, userID string) bool {
+	requester, _ := ctx.Value(requesterKey{}).(string)
+	return requester != "" && requester == userID
+}
+
+// servable rejects photos that may not be handed out yet. Owners see their
+// own photos while review is pending; everyone else only sees approved
+// ones. Rejected photos are hidden from all.
+func servable(meta *models.PhotoMeta, owner bool) error {
+	if meta.ScanStatus == models.ScanPending {
+		return ErrScanPending
+	}
+
+	switch moderationOf(meta) {
+	case models.ModerationRejected:
+		return ErrPhotoNotFound
+	case models.ModerationPending:
+		if !owner {
+			return ErrModerationPending
+		}
+	}
+
+	return nil
+}
+
+// classifyLater runs the classifier over a new upload in the background.
+// Photos it cannot decide, or fails on, stay pending for a reviewer.
+func (s *MinioService) classifyLater(ctx context.Context, meta *models.PhotoMeta, data []byte) {
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		s.classifySlots <- struct{}{}
+		defer func() { <-s.classifySlots }()
+
+		verdict, err := s.classifier.Classify(ctx, data, meta.ContentType)
+		if err != nil {
+			metrics.ClassifierFailures.Add(1)
+			return
+		}
+
+		switch verdict.Decision {
+		case moderation.DecisionApprove:
+			_ = s.moderate(ctx, meta.UserID, meta.PhotoID, models.ModerationApproved, verdict.Reason, actorClassifier)
+		case moderation.DecisionReject:
+			_ = s.moderate(ctx, meta.UserID, meta.PhotoID, models.ModerationRejected, verdict.Reason, actorClassifier)
+		}
+	}()
+}
+
+// ModeratePhoto records a reviewer's decision. Reviewers may also reverse
+// an earlier decision, which restores a rejected photo before its purge.
+func (s *MinioService) ModeratePhoto(ctx context.Context, userID string, photoID string, state models.ModerationState, reason string) error {
+	if state != models.ModerationApproved && state != models.ModerationRejected {
+		return ErrInvalidModeration
+	}
+
+	return s.moderate(ctx, userID, photoID, state, reason, actorReviewer)
+}
+
+func (s *MinioService) moderate(ctx context.Context, userID string, photoID string, to models.ModerationState, reason string, actor string) error {
+	unlock := s.metaLocks.Lock(photoMetaKey(userID, photoID))
+	defer unlock()
+
+	// The event is staged before the record is written, and committed by
+	// its ID landing in the record. A retry reuses it unless the
+	// transition itself changed.
+	var change, staged models.ModerationChanged
+	var eventID string
+	changed := false
+	_, err := s.updatePhotoMeta(ctx, userID, photoID, func(meta *models.PhotoMeta) error {
+		from := moderationOf(meta)
+		changed = false
+		// The classifier never overrides a reviewer who got there first.
+		if from == to || (actor == actorClassifier && from != models.ModerationPending) {
+			return errMetaUnchanged
+		}
+
+		change = models.ModerationChanged{
+			UserID:  userID,
+			PhotoID: photoID,
+			From:    from,
+			To:      to,
+			Reason:  reason,
+			Actor:   actor,
+		}
+		if eventID == "" || change != staged {
+			id, err := s.recordModerated(ctx, change)
+			if err != nil {
+				return err
+			}
+			eventID, staged = id, change
+		}
+		if eventID != "" {
+			meta.ModerationEvents = append(meta.ModerationEvents, eventID)
+			if len(meta.ModerationEvents) > maxModerationEvents {
+				meta.ModerationEvents = meta.ModerationEvents[len(meta.ModerationEvents)-maxModerationEvents:]
+			}
+		}
+
+		meta.Moderation = to
+		meta.ModerationReason = reason
+		meta.DeleteAfter = time.Time{}
+		if to == models.ModerationRejected {
+			meta.DeleteAfter = time.Now().UTC().Add(s.rejectedRetention)
+			if err := s.storage.PutJSON(ctx, moderationKey(to, userID, photoID), struct{}{}); err != nil {
+				return fmt.Errorf("failed to index rejected photo: %w", err)
+			}
+		}
+		changed = true
+		return nil
+	})
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return ErrPhotoNotFound
+	}
+	if err != nil || !changed {
+		return err
+	}
+	from := change.From
+
+	if from != models.ModerationApproved {
+		if err := s.storage.Delete(ctx, moderationKey(from, userID, photoID)); err != nil {
+			return fmt.Errorf("failed to update moderation index: %w", err)
+		}
+	}
+
+	if err := s.invalidateURLs(ctx, userID, photoID); err != nil {
+		return err
+	}
+
+	metrics.ModerationTransitions.Add(1)
+
+	return s.publish(ctx, events.Event{
+		Type:       EventModerationChanged,
+		Key:        userID,
+		Payload:    change,
+		OccurredAt: time.Now().UTC(),
+	})
+}
+
+// ListPendingPhotos pages through photos awaiting review in user and
+// photo ID order. Each comes with a URL the reviewer can open.
+func (s *MinioService) ListPendingPhotos(ctx context.Context, pageToken string, pageSize int) ([]models.PendingPhoto, string, error) {
+	prefix := moderationPrefix(models.ModerationPending)
+
+	startAfter := ""
+	if pageToken != "" {
+		last, err := base64.RawURLEncoding.DecodeString(pageToken)
+		if err != nil {
+			return nil, "", ErrInvalidPageToken
+		}
+		startAfter = prefix + string(last)
+	}
+
+	objects, err := s.storage.ListPage(ctx, prefix, startAfter, pageSize)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list pending photos: %w", err)
+	}
+
+	var pending []models.PendingPhoto
+	for _, object := range objects {
+		userID, photoID, ok := strings.Cut(strings.TrimPrefix(object.Key, prefix), "/")
+		if !ok {
+			continue
+		}
+
+		meta, err := s.getPhotoMeta(ctx, userID, photoID)
+		if errors.Is(err, storage.ErrObjectNotFound) || (err == nil && moderationOf(meta) != models.ModerationPending) {
+			// Stale entry left by an interrupted transition or delete.
+			_ = s.storage.Delete(ctx, object.Key)
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load photo record: %w", err)
+		}
+
+		// Reviewers see pending photos as their owner does.
+		url, err := s.objectURL(ctx, userID, userID, photoID, meta.BlobKey, s.expiry())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get url for %s: %w", photoID, err)
+		}
+
+		pending = append(pending, models.PendingPhoto{
+			UserID:      userID,
+			PhotoID:     photoID,
+			URL:         url,
+			ContentType: meta.ContentType,
+			CreatedAt:   meta.CreatedAt,
+		})
+	}
+
+	nextPageToken := ""
+	if len(objects) == pageSize {
+		last := strings.TrimPrefix(objects[len(objects)-1].Key, prefix)
+		nextPageToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+	}
+
+	return pending, nextPageToken, nil
+}
+
+// PurgeRejected deletes rejected photos whose retention has run out and
+// reports how many went.
+func (s *MinioService) PurgeRejected(ctx context.Context) (int, error) {
+	prefix := moderationPrefix(models.ModerationRejected)
+
+	objects, err := s.storage.List(ctx, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list rejected photos: %w", err)
+	}
+
+	purged := 0
+	for _, object := range objects {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+
+		userID, photoID, ok := strings.Cut(strings.TrimPrefix(object.Key, prefix), "/")
+		if !ok {
+			continue
+		}
+
+		meta, err := s.getPhotoMeta(ctx, userID, photoID)
+		if errors.Is(err, storage.ErrObjectNotFound) || (err == nil && moderationOf(meta) != models.ModerationRejected) {
+			_ = s.storage.Delete(ctx, object.Key)
+			continue
+		}
+		if err != nil || time.Now().Before(meta.DeleteAfter) {
+			continue
+		}
+
+		if err := s.purgePhoto(ctx, userID, photoID); err != nil && !errors.Is(err, ErrPhotoNotFound) {
+			continue
+		}
+
+		purged++
+		metrics.PurgedRejected.Add(1)
+	}
+
+	return purged, nil
+}
+
+// clearModerationIndex drops the index entry of a photo being deleted.
+func (s *MinioService) clearModerationIndex(ctx context.Context, meta *models.PhotoMeta) error {
+	state := moderationOf(meta)
+	if state == models.ModerationApproved {
+		return nil
+	}
+
+	if err := s.storage.Delete(ctx, moderationKey(state, meta.UserID, meta.PhotoID)); err != nil {
+		return fmt.Errorf("failed to update moderation index: %w", err)
+	}
+
+	return nil
+}
+
+func (s *MinioService) publish(ctx context.Context, event events.Event) error {
+	if s.events == nil {
+		return nil
+	}
+
+	if err := s.events.Publish(ctx, event); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", event.Type, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/broker"
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/outbox"
+)
+
+func TestModerationEventsCommitWithTheRecord(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.Moderation.Enabled = true
+	cfg.Moderation.Classifier = "none"
+	s, _ := newTestService(t, cfg, Dependencies{})
+	b := broker.NewMemory()
+	s.outbox = outbox.New(s.storage, b, "", 10, time.Hour)
+
+	meta := uploadTestPhoto(t, s, "alice", testImage(t, 1))
+	if _, err := s.RelayEvents(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// An event staged for a write that never happened stays held back.
+	if _, err := s.recordModerated(ctx, models.ModerationChanged{
+		UserID:  "alice",
+		PhotoID: meta.PhotoID,
+		From:    models.ModerationPending,
+		To:      models.ModerationApproved,
+		Actor:   actorReviewer,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if published, err := s.RelayEvents(ctx); err != nil || published != 0 {
+		t.Fatalf("relayed %d, %v for an uncommitted decision", published, err)
+	}
+
+	if err := s.ModeratePhoto(ctx, "alice", meta.PhotoID, models.ModerationRejected, "spam"); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := s.getPhotoMeta(ctx, "alice", meta.PhotoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.ModerationEvents) != 1 {
+		t.Fatalf("record carries events %v, want the rejection", meta.ModerationEvents)
+	}
+
+	// The orphan still holds alice's queue until it times out.
+	s.outbox = outbox.New(s.storage, b, "", 10, 0)
+	if _, err := s.RelayEvents(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := b.Messages()
+	last := messages[len(messages)-1]
+	if last.Subject != SubjectModerationChanged || last.ID != meta.ModerationEvents[0] {
+		t.Fatalf("last event %s %s, want the committed rejection", last.Subject, last.ID)
+	}
+	for _, msg := range messages {
+		if msg.Subject == SubjectModerationChanged && msg.ID != last.ID {
+			t.Fatalf("relayed the uncommitted decision %s", msg.ID)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/broker"
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/outbox"
+	s3_v1 "github.com/acyushka/nbf-file-storage-service/pkg/pb/gen"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// Subjects of the events relayed to the broker, below the configured
+// prefix. Payloads are the matching messages in events.proto.
+const (
+	SubjectPhotoUploaded = "photo.uploaded"
+	SubjectAvatarChanged = "avatar.changed"
+	SubjectPhotoDeleted  = "photo.deleted"
+	// Moderation events share their subject with EventModerationChanged.
+	SubjectModerationChanged = EventModerationChanged
+)
+
+// NewBroker connects the configured event broker.
+func NewBroker(cfg config.Outbox) (broker.Broker, error) {
+	switch cfg.Broker {
+	case "memory":
+		return broker.NewMemory(), nil
+	case "nats":
+		return broker.NewNATS(cfg.NATSURL, cfg.PublishTimeout)
+	case "kafka":
+		if cfg.KafkaRESTURL == "" {
+			return nil, fmt.Errorf("kafka broker requires a rest proxy url")
+		}
+		return broker.NewKafka(cfg.KafkaRESTURL, cfg.PublishTimeout), nil
+	default:
+		return nil, fmt.Errorf("unknown broker %q", cfg.Broker)
+	}
+}
+
+// recordStored queues the upload event for a photo about to be committed.
+// It must be called before the photo record is written.
+func (s *MinioService) recordStored(ctx context.Context, meta *models.PhotoMeta) error {
+	if s.outbox == nil {
+		return nil
+	}
+
+	id := uuid.New().String()
+
+	subject := SubjectPhotoUploaded
+	var event proto.Message = &s3_v1.PhotoUploaded{
+		EventId:     id,
+		UserId:      meta.UserID,
+		PhotoId:     meta.PhotoID,
+		ContentType: meta.ContentType,
+		FileSize:    meta.FileSize,
+		Sha256:      meta.SHA256,
+		OccurredAt:  meta.CreatedAt.Unix(),
+	}
+	if meta.Kind == models.KindAvatar {
+		subject = SubjectAvatarChanged
+		event = &s3_v1.AvatarChanged{
+			EventId:     id,
+			UserId:      meta.UserID,
+			PhotoId:     meta.PhotoID,
+			ContentType: meta.ContentType,
+			OccurredAt:  meta.CreatedAt.Unix(),
+		}
+	}
+
+	return s.enqueue(ctx, id, subject, meta.UserID, event, models.PhotoEvent{
+		UserID:      meta.UserID,
+		PhotoID:     meta.PhotoID,
+		Kind:        meta.Kind,
+		ContentType: meta.ContentType,
+		FileSize:    meta.FileSize,
+	}, photoMetaKey(meta.UserID, meta.PhotoID), false, "")
+}
+
+// recordDeleted queues the delete event for a photo about to be removed.
+// commit is the object whose removal completes the delete.
+func (s *MinioService) recordDeleted(ctx context.Context, userID string, photoID string, kind models.PhotoKind, commit string) error {
+	if s.outbox == nil {
+		return nil
+	}
+
+	id := uuid.New().String()
+
+	return s.enqueue(ctx, id, SubjectPhotoDeleted, userID, &s3_v1.PhotoDeleted{
+		EventId:    id,
+		UserId:     userID,
+		PhotoId:    photoID,
+		OccurredAt: time.Now().Unix(),
+	}, models.PhotoEvent{
+		UserID:  userID,
+		PhotoID: photoID,
+		Kind:    kind,
+	}, commit, true, "")
+}
+
+// recordModerated queues the event for a moderation decision about to be
+// written to the photo record, and returns its ID, which the record must
+// carry in ModerationEvents to commit it. The ID is empty without an
+// outbox.
+func (s *MinioService) recordModerated(ctx context.Context, change models.ModerationChanged) (string, error) {
+	if s.outbox == nil {
+		return "", nil
+	}
+
+	id := uuid.New().String()
+
+	return id, s.enqueue(ctx, id, SubjectModerationChanged, change.UserID, &s3_v1.ModerationChanged{
+		EventId:    id,
+		UserId:     change.UserID,
+		PhotoId:    change.PhotoID,
+		From:       string(change.From),
+		To:         string(change.To),
+		Reason:     change.Reason,
+		Actor:      change.Actor,
+		OccurredAt: time.Now().Unix(),
+	}, change, photoMetaKey(change.UserID, change.PhotoID), false, id)
+}
+
+// enqueue queues event for the broker, and payload, as JSON, for the
+// forwarders.
+func (s *MinioService) enqueue(ctx context.Context, id string, subject string, userID string, event proto.Message, payload any, commit string, deleted bool, marker string) error {
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", subject, err)
+	}
+	payloadData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", subject, err)
+	}
+
+	return s.outbox.Add(ctx, outbox.Entry{
+		ID:      id,
+		Subject: subject,
+		Key:     userID,
+		Data:    data,
+		Payload: payloadData,
+		Commit:  commit,
+		Deleted: deleted,
+		Marker:  marker,
+	})
+}
+
+// RelayEvents publishes queued events to the broker and webhooks and
+// reports how many went out.
+func (s *MinioService) RelayEvents(ctx context.Context) (int, error) {
+	if s.outbox == nil {
+		return 0, nil
+	}
+
+	return s.outbox.Relay(ctx)
+}
+
+// EventsQueued fires when new events are waiting to be relayed.
+func (s *MinioService) EventsQueued() <-chan struct{} {
+	if s.outbox == nil {
+		return nil
+	}
+
+	return s.outbox.Queued()
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/imaging"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+var (
+	ErrInvalidSignature    = errors.New("invalid image signature")
+	ErrInvalidImageOptions = errors.New("invalid image options")
+	ErrTooManyPixels       = imaging.ErrTooManyPixels
+	ErrProxyDisabled       = errors.New("url signing is disabled")
+)
+
+// SignImagePath returns the signed proxy path for a rendition of a photo,
+// relative to the HTTP server root, and its expiry.
+func (s *MinioService) SignImagePath(userID string, photoID string, opts models.ImageOptions) (string, time.Time, error) {
+	if len(s.signingSecret) == 0 {
+		return "", time.Time{}, ErrProxyDisabled
+	}
+
+	opts, err := s.normalizeImageOptions(opts)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(s.expiry()).Truncate(time.Second)
+
+	query := url.Values{}
+	if opts.Width > 0 {
+		query.Set("w", strconv.Itoa(opts.Width))
+	}
+	if opts.Height > 0 {
+		query.Set("h", strconv.Itoa(opts.Height))
+	}
+	query.Set("fit", opts.Fit)
+	if opts.Format != "" {
+		query.Set("fmt", opts.Format)
+	}
+	query.Set("exp", strconv.FormatInt(expiresAt.Unix(), 10))
+
+	return fmt.Sprintf("/img/%s/%s/%s?%s",
+		s.imageSignature(userID, photoID, opts, expiresAt.Unix()),
+		url.PathEscape(userID),
+		url.PathEscape(photoID),
+		query.Encode(),
+	), expiresAt, nil
+}
+
+// RenderImage verifies the request signature and returns the requested
+// rendition, producing and caching it in the bucket on first use. The
+// download is marked public only for public photos.
+func (s *MinioService) RenderImage(ctx context.Context, signature string, userID string, photoID string, opts models.ImageOptions, expires int64) (*models.Download, error) {
+	if len(s.signingSecret) == 0 {
+		return nil, ErrProxyDisabled
+	}
+
+	opts, err := s.normalizeImageOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := s.imageSignature(userID, photoID, opts, expires)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return nil, ErrDownloadExpired
+	}
+
+	meta, err := s.getPhotoMeta(ctx, userID, photoID)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load photo record: %w", err)
+	}
+	if err := servable(meta, false); err != nil {
+		return nil, err
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = sourceFormat(meta.ContentType)
+	}
+
+	key := fmt.Sprintf("%s.%dx%d-%s.%s", meta.BlobKey, opts.Width, opts.Height, opts.Fit, format)
+	etag := renditionETag(key)
+	public := visibilityOf(meta) == models.VisibilityPublic
+	expiresAt := time.Unix(expires, 0)
+
+	unlock := s.blobLocks.Lock(key)
+	defer unlock()
+
+	obj, info, err := s.storage.Get(ctx, key)
+	if err == nil {
+		return &models.Download{
+			Content:     obj,
+			ContentType: info.ContentType,
+			ETag:        etag,
+			ModTime:     meta.CreatedAt,
+			Size:        info.Size,
+			ExpiresAt:   expiresAt,
+			Public:      public,
+		}, nil
+	}
+	if !errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, err
+	}
+
+	encoded, err := s.renderRendition(ctx, meta.BlobKey, opts, format)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := imaging.ContentType(format)
+	if err := s.storage.Upload(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), contentType); err != nil {
+		return nil, fmt.Errorf("failed to cache rendition: %w", err)
+	}
+
+	return &models.Download{
+		Content:     nopSeekCloser{bytes.NewReader(encoded)},
+		ContentType: contentType,
+		ETag:        etag,
+		ModTime:     meta.CreatedAt,
+		Size:        int64(len(encoded)),
+		ExpiresAt:   expiresAt,
+		Public:      public,
+	}, nil
+}
+
+func (s *MinioService) renderRendition(ctx context.Context, blobKey string, opts models.ImageOptions, format string) ([]byte, error) {
+	obj, _, err := s.storage.Get(ctx, blobKey)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read original: %w", err)
+	}
+
+	img, _, err := imaging.Decode(data, s.maxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	return imaging.Encode(ctx, imaging.Fit(img, opts.Width, opts.Height, opts.Fit), format, s.proxyQuality, s.transcoder)
+}
+
+func (s *MinioService) normalizeImageOptions(opts models.ImageOptions) (models.ImageOptions, error) {
+	if opts.Fit == "" {
+		opts.Fit = imaging.FitCover
+	}
+	opts.Format = imaging.NormalizeFormat(opts.Format)
+
+	if opts.Width < 0 || opts.Height < 0 || opts.Width > s.proxyMaxDimension || opts.Height > s.proxyMaxDimension {
+		return opts, fmt.Errorf("%w: dimensions must be between 0 and %d", ErrInvalidImageOptions, s.proxyMaxDimension)
+	}
+	if !imaging.ValidFit(opts.Fit) {
+		return opts, fmt.Errorf("%w: unknown fit %q", ErrInvalidImageOptions, opts.Fit)
+	}
+	switch opts.Format {
+	case "", "jpeg", "png":
+	case imaging.FormatWebP, imaging.FormatAVIF:
+		if s.transcoder == nil {
+			return opts, fmt.Errorf("%w: format %q needs transcoding enabled", ErrInvalidImageOptions, opts.Format)
+		}
+	default:
+		return opts, fmt.Errorf("%w: unknown format %q", ErrInvalidImageOptions, opts.Format)
+	}
+
+	return opts, nil
+}
+
+// imageSignature MACs the photo, every rendition parameter and the expiry,
+// so clients can't mint arbitrary sizes and burn CPU or bucket space, nor
+// keep a link working for good.
+func (s *MinioService) imageSignature(userID string, photoID string, opts models.ImageOptions, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	fmt.Fprintf(mac, "%s/%s?w=%d&h=%d&fit=%s&fmt=%s&exp=%d", userID, photoID, opts.Width, opts.Height, opts.Fit, opts.Format, expires)
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// renditionETag derives the ETag from the rendition key, which already pins
+// both the source content hash and the parameters.
+func renditionETag(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func sourceFormat(contentType string) string {
+	if contentType == "image/png" || contentType == "image/gif" {
+		return "png"
+	}
+	return "jpeg"
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
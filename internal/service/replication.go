@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// NewReplica builds the configured replica, or returns nil when replication
+// is off.
+func NewReplica(cfg *config.Config) (storage.Replica, error) {
+	if !cfg.Replication.Enabled {
+		return nil, nil
+	}
+
+	// Presigned URLs would send clients to the primary that is down.
+	if cfg.Replication.Failover && cfg.HTTP.SigningSecret == "" {
+		return nil, fmt.Errorf("replica failover requires http.signing_secret")
+	}
+
+	switch cfg.Replication.Target {
+	case "s3":
+		if cfg.Replication.Endpoint == "" {
+			return nil, fmt.Errorf("s3 replica requires an endpoint")
+		}
+		return storage.NewS3Replica(cfg.Replication.Endpoint, cfg.Replication.AccessKey, cfg.Replication.SecretKey, cfg.Replication.UseSSL)
+	case "fs":
+		if cfg.Replication.Directory == "" {
+			return nil, fmt.Errorf("fs replica requires a directory")
+		}
+		// The directory would hold the plaintext of every encrypted object.
+		if encrypted(cfg) {
+			return nil, fmt.Errorf("fs replica cannot be used with server-side encryption")
+		}
+		return storage.NewFSReplica(cfg.Replication.Directory)
+	default:
+		return nil, fmt.Errorf("unknown replication target %q", cfg.Replication.Target)
+	}
+}
+
+// encrypted reports whether any bucket is configured for server-side
+// encryption.
+func encrypted(cfg *config.Config) bool {
+	if mode := cfg.Minio.Encryption.Mode; mode != "" && mode != string(storage.EncryptionNone) {
+		return true
+	}
+	for _, b := range cfg.Minio.Buckets {
+		if mode := b.Encryption.Mode; mode != "" && mode != string(storage.EncryptionNone) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReplicationOptions turns the replication config into storage options.
+func ReplicationOptions(cfg config.Replication) storage.ReplicationOptions {
+	return storage.ReplicationOptions{
+		Failover:            cfg.Failover,
+		HealthCheckInterval: cfg.HealthCheckInterval,
+		BatchSize:           cfg.BatchSize,
+		InitialBackoff:      cfg.InitialBackoff,
+		MaxBackoff:          cfg.MaxBackoff,
+	}
+}
+
+// Replicate copies the objects changed since the last pass to the replica
+// and reports how many it brought up to date.
+func (s *MinioService) Replicate(ctx context.Context) (int, error) {
+	return s.storage.Replicate(ctx)
+}
+
+// VerifyReplica compares the bucket with its replica, queueing what differs
+// for another copy if asked to.
+func (s *MinioService) VerifyReplica(ctx context.Context, opts storage.VerifyOptions) (*storage.ReplicaReport, error) {
+	return s.storage.VerifyReplica(ctx, opts)
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/scanner"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInfected           = errors.New("upload rejected: malware detected")
+	ErrScannerUnavailable = scanner.ErrScannerUnavailable
+	ErrTooLargeToScan     = scanner.ErrTooLarge
+	ErrScanPending        = errors.New("photo is still being scanned")
+)
+
+const quarantineRoot = "quarantine"
+
+func quarantineKey(userID string, id string) string {
+	return fmt.Sprintf("%s/%s/%s", quarantineRoot, userID, id)
+}
+
+// NewScanner connects the configured clamd scanner, or returns nil when
+// scanning is off.
+func NewScanner(cfg config.Scanning) (scanner.Scanner, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.Mode {
+	case "sync", "async":
+	default:
+		return nil, fmt.Errorf("unknown scanning mode %q", cfg.Mode)
+	}
+
+	return scanner.NewClamd(cfg.Address, cfg.Timeout, cfg.ChunkSize)
+}
+
+// scanInline scans content before it is stored, quarantining it and
+// returning ErrInfected when the scanner flags it.
+func (s *MinioService) scanInline(ctx context.Context, userID string, fileName string, contentType string, data []byte) error {
+	result, err := s.scan(ctx, data)
+	if err != nil {
+		if s.scanFailOpen && errors.Is(err, ErrScannerUnavailable) {
+			return nil
+		}
+		return err
+	}
+	if !result.Infected {
+		return nil
+	}
+
+	if err := s.quarantine(ctx, userID, filepath.Ext(fileName), contentType, data, result.Signature); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: %s", ErrInfected, result.Signature)
+}
+
+// scanUpload is the inline scan for photos, skipped in async mode where
+// storePhoto hands them to scanLater instead. Avatars are public as soon
+// as they are stored, so they are always scanned inline.
+func (s *MinioService) scanUpload(ctx context.Context, userID string, kind models.PhotoKind, photo *preparedPhoto) error {
+	if s.scanner == nil || s.asyncScan && kind == models.KindPhoto {
+		return nil
+	}
+
+	return s.scanInline(ctx, userID, photo.fileName, photo.contentType, photo.data)
+}
+
+func (s *MinioService) scan(ctx context.Context, data []byte) (scanner.Result, error) {
+	result, err := s.scanner.Scan(ctx, bytes.NewReader(data))
+	if err != nil {
+		metrics.ScanFailures.Add(1)
+		return scanner.Result{}, fmt.Errorf("failed to scan upload: %w", err)
+	}
+
+	metrics.ScannedUploads.Add(1)
+	if result.Infected {
+		metrics.InfectedUploads.Add(1)
+	}
+
+	return result, nil
+}
+
+// quarantine keeps infected content for inspection under a prefix that is
+// never public and never served.
+func (s *MinioService) quarantine(ctx context.Context, userID string, ext string, contentType string, data []byte, signature string) error {
+	key := quarantineKey(userID, uuid.New().String()+ext)
+	if err := s.storage.UploadWithMetadata(ctx, key, bytes.NewReader(data), int64(len(data)), contentType, map[string]string{
+		"Scan-Signature": signature,
+	}); err != nil {
+		return fmt.Errorf("failed to quarantine upload: %w", err)
+	}
+
+	return nil
+}
+
+// scanLater scans a stored photo in the background. The upload context
+// only lends its values; the scan outlives the request. With every scan
+// slot taken, or when the scan fails, the photo is left pending for
+// ScanPending to pick up, so waiting uploads never pile up in memory.
+func (s *MinioService) scanLater(ctx context.Context, meta *models.PhotoMeta, data []byte) {
+	select {
+	case s.scanSlots <- struct{}{}:
+	default:
+		metrics.ScansDeferred.Add(1)
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer func() { <-s.scanSlots }()
+
+		_, _ = s.finishScan(ctx, meta, data)
+	}()
+}
+
+// finishScan settles a pending photo: clean photos become servable,
+// infected ones are quarantined and removed from the catalog.
+func (s *MinioService) finishScan(ctx context.Context, meta *models.PhotoMeta, data []byte) (scanner.Result, error) {
+	result, err := s.scan(ctx, data)
+	if err != nil {
+		return scanner.Result{}, err
+	}
+
+	if result.Infected {
+		if err := s.quarantine(ctx, meta.UserID, filepath.Ext(meta.PhotoID), meta.ContentType, data, result.Signature); err != nil {
+			return result, err
+		}
+		if err := s.purgePhoto(ctx, meta.UserID, meta.PhotoID); err != nil && !errors.Is(err, ErrPhotoNotFound) {
+			return result, fmt.Errorf("failed to remove infected photo: %w", err)
+		}
+		return result, nil
+	}
+
+	unlock := s.metaLocks.Lock(photoMetaKey(meta.UserID, meta.PhotoID))
+	defer unlock()
+
+	_, err = s.updatePhotoMeta(ctx, meta.UserID, meta.PhotoID, func(current *models.PhotoMeta) error {
+		current.ScanStatus = models.ScanClean
+		return nil
+	})
+	if err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// ScanPending scans every photo still marked pending, picking up scans
+// lost to a restart or a scanner outage.
+func (s *MinioService) ScanPending(ctx context.Context) (*models.ScanReport, error) {
+	if s.scanner == nil {
+		return &models.ScanReport{}, nil
+	}
+
+	objects, err := s.storage.List(ctx, photoMetaRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photo records: %w", err)
+	}
+
+	report := &models.ScanReport{}
+	for _, object := range objects {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if !strings.HasSuffix(object.Key, ".json") {
+			continue
+		}
+
+		var meta models.PhotoMeta
+		if err := s.storage.GetJSON(ctx, object.Key, &meta); err != nil || meta.ScanStatus != models.ScanPending {
+			continue
+		}
+
+		report.Scanned++
+
+		data, err := s.readObject(ctx, meta.BlobKey)
+		if err != nil {
+			report.Failed++
+			continue
+		}
+
+		result, err := s.finishScan(ctx, &meta, data)
+		switch {
+		case err != nil:
+			report.Failed++
+		case result.Infected:
+			report.Infected++
+		default:
+			report.Clean++
+		}
+	}
+
+	return report, nil
+}
+
+func (s *MinioService) readObject(ctx context.Context, objectName string) ([]byte, error) {
+	obj, _, err := s.storage.Get(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", objectName, err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/scanner"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// newScanService wires the service to the clamd client, talking to a fake
+// daemon; with down set, nothing listens at the address any more.
+func newScanService(t *testing.T, mode string, failOpen bool, down bool) *MinioService {
+	t.Helper()
+
+	clamd, err := scanner.NewFakeClamd("tcp://127.0.0.1:0", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go clamd.Serve()
+	t.Cleanup(func() { clamd.Close() })
+	if down {
+		clamd.Close()
+	}
+
+	cfg := &config.Config{}
+	cfg.Scanning = config.Scanning{
+		Enabled:   true,
+		Address:   clamd.Address(),
+		Mode:      mode,
+		Timeout:   time.Second,
+		ChunkSize: 1024,
+		FailOpen:  failOpen,
+		// Enough that no background scan is deferred.
+		Concurrency: 4,
+	}
+	scan, err := NewScanner(cfg.Scanning)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := newTestService(t, cfg, Dependencies{Scanner: scan})
+	return s
+}
+
+func uploadRaw(s *MinioService, userID string, data []byte) ([]models.UploadedPhoto, error) {
+	return s.UploadPhotos(context.Background(), userID, []models.PhotoData{{
+		Data:        bytes.NewReader(data),
+		FileSize:    int64(len(data)),
+		FileName:    "file.txt",
+		ContentType: "text/plain",
+	}}, false)
+}
+
+func quarantined(t *testing.T, s *MinioService, userID string) int {
+	t.Helper()
+
+	objects, err := s.storage.List(context.Background(), quarantineRoot+"/"+userID+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(objects)
+}
+
+func TestInlineScanQuarantinesInfectedUploads(t *testing.T) {
+	s := newScanService(t, "sync", false, false)
+
+	_, err := uploadRaw(s, "alice", []byte("prefix "+scanner.EICAR))
+	if !errors.Is(err, ErrInfected) || !strings.Contains(err.Error(), "Eicar-Test-Signature") {
+		t.Fatalf("upload returned %v, want ErrInfected naming the signature", err)
+	}
+	if quarantined(t, s, "alice") != 1 {
+		t.Fatal("infected upload was not quarantined")
+	}
+
+	objects, err := s.storage.List(context.Background(), photoMetaPrefix("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 0 {
+		t.Fatal("infected upload reached the catalog")
+	}
+
+	if _, err := uploadRaw(s, "alice", []byte("harmless")); err != nil {
+		t.Fatalf("clean upload: %v", err)
+	}
+}
+
+func waitScanned(t *testing.T, s *MinioService) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.scanSlots) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("background scan did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBackgroundScanSettlesPendingPhotos(t *testing.T) {
+	ctx := context.Background()
+	s := newScanService(t, "async", false, false)
+
+	clean, err := uploadRaw(s, "alice", []byte("harmless"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	infected, err := uploadRaw(s, "alice", []byte(scanner.EICAR))
+	if err != nil {
+		t.Fatalf("async uploads are scanned after storing, got %v", err)
+	}
+	waitScanned(t, s)
+
+	meta, err := s.getPhotoMeta(ctx, "alice", clean[0].PhotoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.ScanStatus != models.ScanClean {
+		t.Fatalf("clean photo left %q", meta.ScanStatus)
+	}
+
+	if _, err := s.getPhotoMeta(ctx, "alice", infected[0].PhotoID); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Fatalf("infected photo still in the catalog: %v", err)
+	}
+	if quarantined(t, s, "alice") != 1 {
+		t.Fatal("infected photo was not quarantined")
+	}
+}
+
+func TestScannerOutage(t *testing.T) {
+	closed := newScanService(t, "sync", false, true)
+	if _, err := uploadRaw(closed, "alice", []byte("harmless")); !errors.Is(err, ErrScannerUnavailable) {
+		t.Fatalf("fail-closed upload returned %v, want ErrScannerUnavailable", err)
+	}
+
+	open := newScanService(t, "sync", true, true)
+	if _, err := uploadRaw(open, "alice", []byte("harmless")); err != nil {
+		t.Fatalf("fail-open upload returned %v", err)
+	}
+}
@@ -2,79 +2,686 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/events"
+	"github.com/acyushka/nbf-file-storage-service/internal/imaging"
 	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/moderation"
+	"github.com/acyushka/nbf-file-storage-service/internal/notifications"
+	"github.com/acyushka/nbf-file-storage-service/internal/outbox"
+	"github.com/acyushka/nbf-file-storage-service/internal/scanner"
 	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+	"github.com/acyushka/nbf-file-storage-service/internal/webhooks"
 
 	"github.com/google/uuid"
+	"github.com/hesoyamTM/nbf-auth/pkg/logger"
+)
+
+var (
+	ErrPhotoNotFound = errors.New("photo does not exists")
+	ErrNearDuplicate = errors.New("batch contains near-duplicate photos")
 )
 
 type MinioService struct {
-	storage     *storage.MinioClient
-	expiryHours int
+	storage             *storage.MinioClient
+	expiryHours         int
+	similarityThreshold int
+	batchConcurrency    int
+	publicAvatars       bool
+	redirectExpiry      time.Duration
+	blobLocks           keyedMutex
+	metaLocks           keyedMutex
+
+	transcoder       imaging.Transcoder
+	renditionFormats []string
+	transcodeSlots   chan struct{}
+
+	urlCache *urlCache
+
+	// documents is the envelope encrypted store for sensitive documents,
+	// nil when document storage is disabled.
+	documents storage.ObjectStore
+
+	// scanner is nil when malware scanning is off.
+	scanner      scanner.Scanner
+	asyncScan    bool
+	scanFailOpen bool
+	scanSlots    chan struct{}
+
+	// moderation holds new photos back from the public until reviewed;
+	// classifier, when set, takes a first pass at them.
+	moderation        bool
+	classifier        moderation.Classifier
+	classifySlots     chan struct{}
+	rejectedRetention time.Duration
+
+	events events.Publisher
+	// outbox is nil when event publishing is off.
+	outbox *outbox.Outbox
+
+	// notifications is nil unless bucket events are pulled rather than
+	// pushed to the webhook.
+	notifications       notifications.Source
+	directUploads       bool
+	directUploadExpiry  time.Duration
+	directUploadMaxSize int64
+
+	// webhooks is nil when outbound webhooks are off.
+	webhooks *webhooks.Dispatcher
+
+	// receiptSecret signs erasure receipts; erasure is disabled without it.
+	receiptSecret []byte
+	exportExpiry  time.Duration
+
+	// trashRetention is how long deleted photos stay restorable, zero
+	// when the trash is off.
+	trashRetention time.Duration
+
+	// lifecycle holds the rules expired in-process, for backends that do
+	// not run bucket lifecycle themselves.
+	lifecycle []storage.LifecycleRule
+
+	signingSecret     []byte
+	downloadBaseURL   string
+	downloadExpiry    time.Duration
+	proxyMaxDimension int
+	proxyQuality      int
+	maxPixels         int64
+}
+
+// Dependencies are the collaborators NewMinioService wires together beyond
+// plain config. All but Storage are optional.
+type Dependencies struct {
+	Storage    *storage.MinioClient
+	Documents  storage.ObjectStore
+	Scanner    scanner.Scanner
+	Classifier moderation.Classifier
+	Events     events.Publisher
+	Outbox     *outbox.Outbox
+	// Notifications is the source of bucket events, if any.
+	Notifications notifications.Source
+	Webhooks      *webhooks.Dispatcher
+	// Lifecycle are the rules to enforce in-process, if any.
+	Lifecycle []storage.LifecycleRule
+	// Tenant is the tenant Storage is scoped to, empty for the
+	// deployment's own product.
+	Tenant string
 }
 
-func NewMinioService(s3 *storage.MinioClient, expiryHours int) *MinioService {
-	return &MinioService{
-		storage:     s3,
-		expiryHours: expiryHours,
+func NewMinioService(deps Dependencies, cfg *config.Config) *MinioService {
+	s := &MinioService{
+		storage:             deps.Storage,
+		documents:           deps.Documents,
+		scanner:             deps.Scanner,
+		classifier:          deps.Classifier,
+		events:              deps.Events,
+		outbox:              deps.Outbox,
+		notifications:       deps.Notifications,
+		webhooks:            deps.Webhooks,
+		lifecycle:           deps.Lifecycle,
+		receiptSecret:       []byte(cfg.Privacy.ReceiptSecret),
+		exportExpiry:        cfg.Privacy.ExportExpiry,
+		directUploads:       cfg.Notifications.Enabled && cfg.Notifications.DirectUploads,
+		directUploadExpiry:  cfg.Notifications.DirectUploadExpiry,
+		directUploadMaxSize: cfg.Notifications.DirectUploadMaxSize,
+		moderation:          cfg.Moderation.Enabled,
+		rejectedRetention:   cfg.Moderation.RejectedRetention,
+		classifySlots:       make(chan struct{}, max(1, cfg.Moderation.Concurrency)),
+		asyncScan:           cfg.Scanning.Mode == "async",
+		scanFailOpen:        cfg.Scanning.FailOpen,
+		scanSlots:           make(chan struct{}, max(1, cfg.Scanning.Concurrency)),
+		transcodeSlots:      make(chan struct{}, max(1, cfg.Transcoding.Concurrency)),
+		expiryHours:         cfg.PresignedUrl.ExpiryHours,
+		similarityThreshold: cfg.Similarity.Threshold,
+		batchConcurrency:    cfg.Batch.Concurrency,
+		publicAvatars:       publicAvatars(cfg),
+		redirectExpiry:      cfg.Share.RedirectExpiry,
+		urlCache:            newURLCache(cfg, deps.Tenant),
+		signingSecret:       []byte(cfg.HTTP.SigningSecret),
+		downloadBaseURL:     strings.TrimSuffix(cfg.HTTP.PublicURL, "/"),
+		downloadExpiry:      cfg.HTTP.DownloadExpiry,
+		proxyMaxDimension:   cfg.ImageProxy.MaxDimension,
+		proxyQuality:        cfg.ImageProxy.Quality,
+		maxPixels:           cfg.Images.MaxPixels,
+	}
+
+	if cfg.Trash.Enabled {
+		s.trashRetention = cfg.Trash.Retention
 	}
+
+	if cfg.Transcoding.Enabled {
+		s.transcoder = imaging.NewCommandTranscoder(cfg.Transcoding.WebPCommand, cfg.Transcoding.AVIFCommand, cfg.Transcoding.Quality)
+		for _, format := range cfg.Transcoding.Formats {
+			s.renditionFormats = append(s.renditionFormats, imaging.NormalizeFormat(format))
+		}
+	}
+
+	return s
 }
 
-func (s *MinioService) UploadAvatar(ctx context.Context, userID string, data io.Reader, fileName string, fileSize int64, contentType string) (string, error) {
-	photoUUID := uuid.New().String()
-	extension := filepath.Ext(fileName)
-	objectName := fmt.Sprintf("%s/photos/%s%s", userID, photoUUID, extension)
+// PublicPaths lists the object key patterns that must be anonymously
+// readable under the configured visibility. Everything else is private and
+// only reachable through presigned URLs.
+func PublicPaths(visibility config.Visibility) []string {
+	var paths []string
+	if visibility.PublicAvatars {
+		paths = append(paths, blobPrefix(models.KindAvatar)+"*")
+	}
+	if visibility.PublicPhotos {
+		paths = append(paths, blobPrefix(models.KindPhoto)+"*")
+	}
+	if visibility.PublicLegacy {
+		paths = append(paths, legacyObjectName("*", "*"))
+	}
+
+	return append(paths, visibility.ExtraPublicPaths...)
+}
+
+// Buckets turns the bucket config into routes for the storage client. Each
+// bucket inherits the default encryption unless it sets a mode of its own.
+func Buckets(cfg *config.Config, encryption storage.Encryption) ([]storage.Bucket, error) {
+	buckets := make([]storage.Bucket, 0, len(cfg.Minio.Buckets))
+	routed := make(map[string]string)
+	for _, b := range cfg.Minio.Buckets {
+		bucket := storage.Bucket{
+			Name:       b.Name,
+			PublicRead: b.PublicRead,
+			Encryption: encryption,
+		}
+		if b.Encryption.Mode != "" {
+			bucket.Encryption.Mode = storage.EncryptionMode(b.Encryption.Mode)
+			bucket.Encryption.KMSKeyID = b.Encryption.KMSKeyID
+		}
+		if bucket.Encryption.Mode == storage.EncryptionCustomer && cfg.HTTP.SigningSecret == "" {
+			return nil, fmt.Errorf("sse-c in bucket %s requires http.signing_secret", b.Name)
+		}
+
+		for _, kind := range b.Kinds {
+			if other, ok := routed[kind]; ok {
+				return nil, fmt.Errorf("%s are routed to both %s and %s", kind, other, b.Name)
+			}
+			routed[kind] = b.Name
+
+			switch kind {
+			case string(models.KindAvatar), string(models.KindPhoto):
+				bucket.Prefixes = append(bucket.Prefixes, blobPrefix(models.PhotoKind(kind)))
+			case documentsRoot:
+				bucket.Prefixes = append(bucket.Prefixes, documentsRoot+"/")
+			default:
+				return nil, fmt.Errorf("bucket %s has unknown kind %q", b.Name, kind)
+			}
+		}
+
+		buckets = append(buckets, bucket)
+	}
 
-	if err := s.storage.Upload(ctx, objectName, data, fileSize, contentType); err != nil {
-		return "", fmt.Errorf("failed to upload avatar: %w", err)
+	return buckets, nil
+}
+
+// publicAvatars reports whether avatars can be linked to directly.
+func publicAvatars(cfg *config.Config) bool {
+	if cfg.Minio.Visibility.PublicAvatars {
+		return true
+	}
+	for _, b := range cfg.Minio.Buckets {
+		if b.PublicRead && slices.Contains(b.Kinds, string(models.KindAvatar)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// preparedPhoto is an upload read fully into memory along with everything
+// derived from its content.
+type preparedPhoto struct {
+	// photoID is set when the ID was handed out ahead of the upload.
+	photoID     string
+	data        []byte
+	fileName    string
+	contentType string
+	checksums   models.Checksums
+	dhash       string
+	placeholder *models.Placeholder
+}
+
+func preparePhoto(photo models.PhotoData, maxPixels int64) (*preparedPhoto, error) {
+	verified, err := newChecksumReader(photo.Data, photo.FileSize, photo.Checksums)
+	if err != nil {
+		return nil, err
 	}
 
-	publicURL, err := s.storage.GetPublicUrl(ctx, objectName)
+	data, err := io.ReadAll(verified)
 	if err != nil {
-		return "", fmt.Errorf("failed to get public url: %w", err)
+		return nil, fmt.Errorf("failed to read photo: %w", err)
 	}
 
-	return publicURL, nil
+	prepared := &preparedPhoto{
+		data:        data,
+		fileName:    photo.FileName,
+		contentType: photo.ContentType,
+		checksums:   verified.sums(),
+	}
+
+	// Non-image uploads are still stored, just without a perceptual hash
+	// or placeholder.
+	img, _, err := imaging.Decode(data, maxPixels)
+	switch {
+	case errors.Is(err, ErrTooManyPixels):
+		return nil, err
+	case err == nil:
+		prepared.dhash = imaging.FormatHash(imaging.DHash(img))
+		prepared.placeholder = imaging.NewPlaceholder(img)
+	}
+
+	return prepared, nil
+}
+
+func (s *MinioService) UploadAvatar(ctx context.Context, userID string, data io.Reader, fileName string, fileSize int64, contentType string, checksums models.Checksums) (*models.UploadedPhoto, error) {
+	prepared, err := preparePhoto(models.PhotoData{
+		Data:        data,
+		FileSize:    fileSize,
+		FileName:    fileName,
+		ContentType: contentType,
+		Checksums:   checksums,
+	}, s.maxPixels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload avatar: %w", err)
+	}
+
+	if err := s.scanUpload(ctx, userID, models.KindAvatar, prepared); err != nil {
+		return nil, err
+	}
+
+	meta, err := s.storePhoto(ctx, userID, models.KindAvatar, prepared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload avatar: %w", err)
+	}
+
+	// The new avatar is in place either way; a later upload retires
+	// whatever this one leaves behind.
+	if err := s.retireAvatars(ctx, meta); err != nil {
+		if log, logErr := logger.LoggerFromCtx(ctx); logErr == nil {
+			log.Error(fmt.Sprintf("retiring avatars of %s failed: %v", userID, err))
+		}
+	}
+
+	var url string
+	if s.publicAvatars && s.presignable(meta.BlobKey) {
+		url, err = s.storage.GetPublicUrl(ctx, meta.BlobKey)
+	} else {
+		url, err = s.objectURL(ctx, userID, userID, meta.PhotoID, meta.BlobKey, s.expiry())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get avatar url: %w", err)
+	}
+
+	return &models.UploadedPhoto{
+		PhotoID:     meta.PhotoID,
+		URL:         url,
+		Placeholder: meta.Placeholder,
+	}, nil
+}
+
+// retireAvatars purges the avatars a user uploaded before current, so the
+// blobs of replaced avatars are released instead of being kept alive by
+// their records. Avatars newer than current belong to a concurrent upload
+// and are left to it.
+func (s *MinioService) retireAvatars(ctx context.Context, current *models.PhotoMeta) error {
+	prefix := photoMetaPrefix(current.UserID)
+	objects, err := s.storage.List(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list photo records: %w", err)
+	}
+
+	for _, object := range objects {
+		photoID, ok := strings.CutSuffix(strings.TrimPrefix(object.Key, prefix), ".json")
+		if !ok || photoID == current.PhotoID {
+			continue
+		}
+
+		meta, err := s.getPhotoMeta(ctx, current.UserID, photoID)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load photo record: %w", err)
+		}
+		if meta.Kind != models.KindAvatar || meta.CreatedAt.After(current.CreatedAt) {
+			continue
+		}
+
+		if err := s.purgePhoto(ctx, current.UserID, photoID); err != nil && !errors.Is(err, ErrPhotoNotFound) {
+			return fmt.Errorf("failed to retire avatar %s: %w", photoID, err)
+		}
+	}
+
+	return nil
 }
 
-func (s *MinioService) UploadPhotos(ctx context.Context, userID string, photos []models.PhotoData) ([]string, error) {
+func (s *MinioService) UploadPhotos(ctx context.Context, userID string, photos []models.PhotoData, rejectDuplicates bool) ([]models.UploadedPhoto, error) {
 	if len(photos) == 0 || len(photos) > 5 {
 		return nil, fmt.Errorf("invalid length slice of photos")
 	}
 
-	var uuids []string
-
+	prepared := make([]*preparedPhoto, len(photos))
 	for i, photo := range photos {
-		photoUUID := uuid.New().String()
-		extension := filepath.Ext(photo.FileName)
-		objectName := fmt.Sprintf("%s/photos/%s%s", userID, photoUUID, extension)
+		p, err := preparePhoto(photo, s.maxPixels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload photo %d: %w", i+1, err)
+		}
+		prepared[i] = p
+	}
+
+	for i, photo := range prepared {
+		if err := s.scanUpload(ctx, userID, models.KindPhoto, photo); err != nil {
+			return nil, fmt.Errorf("photo %d: %w", i+1, err)
+		}
+	}
+
+	if rejectDuplicates {
+		if err := s.checkBatchDuplicates(prepared); err != nil {
+			return nil, err
+		}
+	}
+
+	var uploaded []models.UploadedPhoto
 
-		if err := s.storage.Upload(ctx, objectName, photo.Data, photo.FileSize, photo.ContentType); err != nil {
+	for i, photo := range prepared {
+		meta, err := s.storePhoto(ctx, userID, models.KindPhoto, photo)
+		if err != nil {
 			return nil, fmt.Errorf("failed to upload photo %d: %w", i+1, err)
 		}
 
-		uuids = append(uuids, photoUUID+extension)
+		uploaded = append(uploaded, models.UploadedPhoto{
+			PhotoID:     meta.PhotoID,
+			Placeholder: meta.Placeholder,
+		})
+	}
+
+	return uploaded, nil
+}
+
+// GetPhotoURL presigns the best rendition of a photo the client accepts,
+// falling back to the original upload.
+func (s *MinioService) GetPhotoURL(ctx context.Context, userID string, uuid string, acceptFormats []string) (*models.PhotoURL, error) {
+	return s.cachedPhotoURL(ctx, userID, uuid, acceptFormats, true)
+}
+
+func (s *MinioService) photoURL(ctx context.Context, userID string, uuid string, acceptFormats []string, verify bool) (*models.PhotoURL, error) {
+	objectName, meta, err := s.resolveObject(ctx, userID, uuid, verify)
+	if err != nil {
+		return nil, err
+	}
+
+	photoURL := &models.PhotoURL{}
+	if meta != nil {
+		photoURL.ContentType = meta.ContentType
+		photoURL.Placeholder = meta.Placeholder
+
+		var renditions map[string]string
+		if len(acceptFormats) > 0 {
+			renditions, err = s.photoRenditions(ctx, meta)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Signed downloads always serve the original, so renditions are
+		// only offered when they can be presigned.
+		if format, key := s.negotiateRendition(renditions, acceptFormats); key != "" && s.presignable(key) {
+			objectName = key
+			photoURL.ContentType = imaging.ContentType(format)
+		}
+	}
+
+	url, err := s.objectURL(ctx, userID, userID, uuid, objectName, s.expiry())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get presigned url for %s: %w", uuid, err)
+	}
+	photoURL.URL = url
+
+	return photoURL, nil
+}
+
+func (s *MinioService) expiry() time.Duration {
+	return time.Duration(s.expiryHours) * time.Hour
+}
+
+// DeletePhoto moves a photo to the trash when it is enabled and removes it
+// for good otherwise. Legacy photos have no record to keep and are always
+// removed.
+func (s *MinioService) DeletePhoto(ctx context.Context, userID string, photoID string) error {
+	if s.trashRetention == 0 {
+		return s.purgePhoto(ctx, userID, photoID)
+	}
+
+	meta, err := s.getPhotoMeta(ctx, userID, photoID)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return s.purgePhoto(ctx, userID, photoID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.trashPhoto(ctx, meta)
+}
+
+// purgePhoto removes a photo for good.
+func (s *MinioService) purgePhoto(ctx context.Context, userID string, photoID string) error {
+	meta, err := s.getPhotoMeta(ctx, userID, photoID)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		objectName := legacyObjectName(userID, photoID)
+		if !s.storage.ObjectExists(ctx, objectName) {
+			return ErrPhotoNotFound
+		}
+		if err := s.recordDeleted(ctx, userID, photoID, models.KindPhoto, objectName); err != nil {
+			return err
+		}
+		if err := s.storage.Delete(ctx, objectName); err != nil {
+			return err
+		}
+		s.publishDeleted(ctx, userID, photoID, models.KindPhoto)
+		return s.invalidateURLs(ctx, userID, photoID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.forgetPhoto(ctx, meta); err != nil {
+		return err
+	}
+
+	if err := s.releaseBlob(ctx, meta.Kind, meta.SHA256); err != nil {
+		return fmt.Errorf("failed to release blob: %w", err)
+	}
+
+	return nil
+}
+
+// forgetPhoto removes a photo from the catalog, leaving its blob alone.
+func (s *MinioService) forgetPhoto(ctx context.Context, meta *models.PhotoMeta) error {
+	if err := s.recordDeleted(ctx, meta.UserID, meta.PhotoID, meta.Kind, photoMetaKey(meta.UserID, meta.PhotoID)); err != nil {
+		return err
+	}
+
+	if err := s.storage.Delete(ctx, photoMetaKey(meta.UserID, meta.PhotoID)); err != nil {
+		return fmt.Errorf("failed to delete photo record: %w", err)
+	}
+	s.publishDeleted(ctx, meta.UserID, meta.PhotoID, meta.Kind)
 
+	if err := s.clearModerationIndex(ctx, meta); err != nil {
+		return err
 	}
 
-	return uuids, nil
+	return s.invalidateURLs(ctx, meta.UserID, meta.PhotoID)
 }
 
-func (s *MinioService) GetPhotoURL(ctx context.Context, userID string, uuid string) (string, error) {
-	objectName := fmt.Sprintf("%s/photos/%s", userID, uuid)
+// invalidateURLs drops cached URLs so a deleted photo stops resolving
+// immediately, even while its shared blob lives on.
+func (s *MinioService) invalidateURLs(ctx context.Context, userID string, photoID string) error {
+	if s.urlCache == nil {
+		return nil
+	}
 
-	if !s.storage.ObjectExists(ctx, objectName) {
-		return "", fmt.Errorf("photo does not exists")
+	if err := s.urlCache.invalidate(ctx, userID, photoID); err != nil {
+		return fmt.Errorf("failed to invalidate cached urls: %w", err)
 	}
 
-	url, err := s.storage.GetPresignedUrl(ctx, objectName, s.expiryHours)
+	return nil
+}
+
+func (s *MinioService) storePhoto(ctx context.Context, userID string, kind models.PhotoKind, photo *preparedPhoto) (*models.PhotoMeta, error) {
+	blob, err := s.putBlob(ctx, kind, photo)
 	if err != nil {
-		return "", fmt.Errorf("failed to get presigned url for %s: %w", uuid, err)
+		return nil, err
+	}
+
+	photoID := photo.photoID
+	if photoID == "" {
+		photoID = uuid.New().String() + filepath.Ext(photo.fileName)
+	}
+
+	meta := &models.PhotoMeta{
+		PhotoID:     photoID,
+		UserID:      userID,
+		Kind:        kind,
+		BlobKey:     blob.BlobKey,
+		SHA256:      blob.SHA256,
+		CRC32C:      photo.checksums.CRC32C,
+		FileSize:    int64(len(photo.data)),
+		ContentType: photo.contentType,
+		DHash:       photo.dhash,
+		Placeholder: photo.placeholder,
+		Renditions:  blob.Renditions,
+		Visibility:  models.VisibilityPrivate,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if s.scanner != nil && s.asyncScan && kind == models.KindPhoto {
+		meta.ScanStatus = models.ScanPending
+	}
+	// Avatars are public by design and skip review.
+	if s.moderation && kind == models.KindPhoto {
+		meta.Moderation = models.ModerationPending
+		if err := s.storage.PutJSON(ctx, moderationKey(models.ModerationPending, userID, meta.PhotoID), struct{}{}); err != nil {
+			if releaseErr := s.releaseBlob(ctx, kind, blob.SHA256); releaseErr != nil {
+				err = errors.Join(err, releaseErr)
+			}
+			return nil, fmt.Errorf("failed to index pending photo: %w", err)
+		}
+	}
+
+	if err := s.recordStored(ctx, meta); err != nil {
+		if releaseErr := s.releaseBlob(ctx, kind, blob.SHA256); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+		return nil, err
+	}
+
+	if err := s.storage.PutJSON(ctx, photoMetaKey(userID, meta.PhotoID), meta); err != nil {
+		if releaseErr := s.releaseBlob(ctx, kind, blob.SHA256); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+		return nil, fmt.Errorf("failed to save photo record: %w", err)
+	}
+	s.publishStored(ctx, meta)
+
+	if meta.ScanStatus == models.ScanPending {
+		s.scanLater(ctx, meta, photo.data)
+	}
+	if meta.Moderation == models.ModerationPending && s.classifier != nil {
+		s.classifyLater(ctx, meta, photo.data)
+	}
+	s.transcodeLater(ctx, meta, photo.data)
+
+	return meta, nil
+}
+
+func (s *MinioService) getPhotoMeta(ctx context.Context, userID string, photoID string) (*models.PhotoMeta, error) {
+	var meta models.PhotoMeta
+	if err := s.storage.GetJSON(ctx, photoMetaKey(userID, photoID), &meta); err != nil {
+		return nil, err
 	}
 
-	return url, nil
+	return &meta, nil
+}
+
+// errMetaUnchanged is returned by an updatePhotoMeta change that has
+// nothing to write.
+var errMetaUnchanged = errors.New("photo record unchanged")
+
+// updatePhotoMeta rewrites a photo's record through change with a
+// conditional write, so a stale copy read here never overwrites an update
+// another instance made in between. change runs again on the fresh record
+// whenever another writer came first, and may return errMetaUnchanged to
+// leave the record as it is. A missing record fails with
+// storage.ErrObjectNotFound.
+func (s *MinioService) updatePhotoMeta(ctx context.Context, userID string, photoID string, change func(meta *models.PhotoMeta) error) (*models.PhotoMeta, error) {
+	key := photoMetaKey(userID, photoID)
+
+	for attempt := 1; ; attempt++ {
+		var meta models.PhotoMeta
+		etag, err := s.storage.GetJSONVersion(ctx, key, &meta)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, storage.ErrObjectNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load photo record: %w", err)
+		}
+
+		if err := change(&meta); errors.Is(err, errMetaUnchanged) {
+			return &meta, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		err = s.storage.PutJSONIf(ctx, key, &meta, etag)
+		switch {
+		case err == nil:
+			return &meta, nil
+		case !errors.Is(err, storage.ErrPreconditionFailed) || attempt == maxRefAttempts:
+			return nil, fmt.Errorf("failed to save photo record: %w", err)
+		}
+
+		if err := sleep(ctx, refRetryDelay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// resolveObject maps a photo_id onto the object holding its bytes. Photos
+// uploaded before deduplication have no catalog record and live under
+// their legacy per-user key; for those the returned record is nil. Without
+// verify the legacy key is trusted as-is, saving a StatObject round trip.
+// Photos awaiting review only resolve for requests made by their owner.
+func (s *MinioService) resolveObject(ctx context.Context, userID string, photoID string, verify bool) (string, *models.PhotoMeta, error) {
+	meta, err := s.getPhotoMeta(ctx, userID, photoID)
+	if err == nil {
+		if err := servable(meta, requestedBy(ctx, userID)); err != nil {
+			return "", nil, err
+		}
+		return meta.BlobKey, meta, nil
+	}
+	if !errors.Is(err, storage.ErrObjectNotFound) {
+		return "", nil, fmt.Errorf("failed to load photo record: %w", err)
+	}
+
+	objectName := legacyObjectName(userID, photoID)
+	if verify && !s.storage.ObjectExists(ctx, objectName) {
+		return "", nil, ErrPhotoNotFound
+	}
+
+	return objectName, nil, nil
+}
+
+func legacyObjectName(userID string, photoID string) string {
+	return fmt.Sprintf("%s/photos/%s", userID, photoID)
 }
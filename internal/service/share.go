@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+var (
+	ErrInvalidVisibility = errors.New("invalid visibility")
+	ErrPhotoPrivate      = errors.New("photo is private")
+	ErrShareNotFound     = errors.New("share link does not exists")
+	ErrShareGone         = errors.New("share link is revoked, expired or used up")
+)
+
+func shareKey(token string) string {
+	return fmt.Sprintf("_meta/shares/%s.json", token)
+}
+
+func (s *MinioService) SetPhotoVisibility(ctx context.Context, userID string, photoID string, visibility models.Visibility) error {
+	if !visibility.Valid() {
+		return ErrInvalidVisibility
+	}
+
+	unlock := s.metaLocks.Lock(photoMetaKey(userID, photoID))
+	defer unlock()
+
+	_, err := s.updatePhotoMeta(ctx, userID, photoID, func(meta *models.PhotoMeta) error {
+		meta.Visibility = visibility
+		return nil
+	})
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return ErrPhotoNotFound
+	}
+
+	return err
+}
+
+// CreateShareLink mints a token for a link-only or public photo. A zero ttl
+// or maxAccesses means no limit.
+func (s *MinioService) CreateShareLink(ctx context.Context, userID string, photoID string, ttl time.Duration, maxAccesses int) (*models.ShareLink, error) {
+	meta, err := s.getPhotoMeta(ctx, userID, photoID)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load photo record: %w", err)
+	}
+	if visibilityOf(meta) == models.VisibilityPrivate {
+		return nil, ErrPhotoPrivate
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.ShareLink{
+		Token:       token,
+		UserID:      userID,
+		PhotoID:     photoID,
+		CreatedAt:   time.Now().UTC(),
+		MaxAccesses: maxAccesses,
+	}
+	if ttl > 0 {
+		link.ExpiresAt = link.CreatedAt.Add(ttl)
+	}
+
+	if err := s.storage.PutJSON(ctx, shareKey(token), link); err != nil {
+		return nil, fmt.Errorf("failed to save share link: %w", err)
+	}
+
+	return link, nil
+}
+
+// RevokeShareLink disables a token. The redirect endpoint checks the record
+// on every hit, so revocation is immediate.
+func (s *MinioService) RevokeShareLink(ctx context.Context, userID string, token string) error {
+	_, err := s.updateShareLink(ctx, token, func(link *models.ShareLink) error {
+		if link.UserID != userID {
+			return ErrShareNotFound
+		}
+		link.Revoked = true
+		return nil
+	})
+
+	return err
+}
+
+// ResolveShareLink counts an access and presigns a short-lived URL for the
+// shared photo.
+func (s *MinioService) ResolveShareLink(ctx context.Context, token string) (string, error) {
+	var meta *models.PhotoMeta
+	link, err := s.updateShareLink(ctx, token, func(link *models.ShareLink) error {
+		if link.Revoked ||
+			(!link.ExpiresAt.IsZero() && time.Now().After(link.ExpiresAt)) ||
+			(link.MaxAccesses > 0 && link.Accesses >= link.MaxAccesses) {
+			return ErrShareGone
+		}
+
+		var err error
+		meta, err = s.getPhotoMeta(ctx, link.UserID, link.PhotoID)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return ErrShareGone
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load photo record: %w", err)
+		}
+		// Making the photo private again disables every link at once.
+		if visibilityOf(meta) == models.VisibilityPrivate {
+			return ErrShareGone
+		}
+		if err := servable(meta, false); err != nil {
+			return err
+		}
+
+		link.Accesses++
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return s.objectURL(ctx, "", link.UserID, link.PhotoID, meta.BlobKey, s.redirectExpiry)
+}
+
+// updateShareLink rewrites a link through change with a conditional write,
+// so concurrent hits on any instance each count, and a link with one
+// access left is handed out once. change runs again on the fresh record
+// whenever another writer came first.
+func (s *MinioService) updateShareLink(ctx context.Context, token string, change func(link *models.ShareLink) error) (*models.ShareLink, error) {
+	key := shareKey(token)
+
+	for attempt := 1; ; attempt++ {
+		var link models.ShareLink
+		etag, err := s.storage.GetJSONVersion(ctx, key, &link)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, ErrShareNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load share link: %w", err)
+		}
+
+		if err := change(&link); err != nil {
+			return nil, err
+		}
+
+		err = s.storage.PutJSONIf(ctx, key, &link, etag)
+		switch {
+		case err == nil:
+			return &link, nil
+		case !errors.Is(err, storage.ErrPreconditionFailed) || attempt == maxRefAttempts:
+			return nil, fmt.Errorf("failed to save share link: %w", err)
+		}
+
+		if err := sleep(ctx, refRetryDelay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ResolvePublicPhoto presigns a short-lived URL for a photo whose owner made
+// it public.
+func (s *MinioService) ResolvePublicPhoto(ctx context.Context, userID string, photoID string) (string, error) {
+	meta, err := s.getPhotoMeta(ctx, userID, photoID)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return "", ErrPhotoNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load photo record: %w", err)
+	}
+	if visibilityOf(meta) != models.VisibilityPublic {
+		return "", ErrPhotoNotFound
+	}
+	if err := servable(meta, false); err != nil {
+		return "", err
+	}
+
+	return s.objectURL(ctx, "", userID, photoID, meta.BlobKey, s.redirectExpiry)
+}
+
+func (s *MinioService) getShareLink(ctx context.Context, token string) (*models.ShareLink, error) {
+	var link models.ShareLink
+	err := s.storage.GetJSON(ctx, shareKey(token), &link)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load share link: %w", err)
+	}
+
+	return &link, nil
+}
+
+func visibilityOf(meta *models.PhotoMeta) models.Visibility {
+	if meta.Visibility == "" {
+		return models.VisibilityPrivate
+	}
+	return meta.Visibility
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
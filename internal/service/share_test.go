@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+)
+
+func TestShareLinkAccessesAreCountedOnce(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.Share.RedirectExpiry = time.Minute
+	s, _ := newTestService(t, cfg, Dependencies{})
+
+	meta := uploadTestPhoto(t, s, "alice", testImage(t, 1))
+	if err := s.SetPhotoVisibility(ctx, "alice", meta.PhotoID, models.VisibilityLink); err != nil {
+		t.Fatal(err)
+	}
+	link, err := s.CreateShareLink(ctx, "alice", meta.PhotoID, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var served, gone atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			_, err := s.ResolveShareLink(ctx, link.Token)
+			switch {
+			case err == nil:
+				served.Add(1)
+			case errors.Is(err, ErrShareGone):
+				gone.Add(1)
+			default:
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if served.Load() != 3 || gone.Load() != 7 {
+		t.Fatalf("served %d and refused %d, want 3 and 7", served.Load(), gone.Load())
+	}
+
+	saved, err := s.getShareLink(ctx, link.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Accesses != 3 {
+		t.Fatalf("counted %d accesses, want 3", saved.Accesses)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/imaging"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// FindSimilarPhotos returns the user's photos whose perceptual hash lies
+// within threshold bits of the given photo, closest first. A non-positive
+// threshold falls back to the configured default.
+func (s *MinioService) FindSimilarPhotos(ctx context.Context, userID string, photoID string, threshold int) ([]models.SimilarPhoto, error) {
+	if threshold <= 0 {
+		threshold = s.similarityThreshold
+	}
+
+	target, err := s.getPhotoMeta(ctx, userID, photoID)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load photo record: %w", err)
+	}
+	if target.DHash == "" {
+		return nil, fmt.Errorf("photo %s has no perceptual hash", photoID)
+	}
+
+	targetHash, err := imaging.ParseHash(target.DHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid perceptual hash: %w", err)
+	}
+
+	metas, err := s.listPhotoMeta(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var similar []models.SimilarPhoto
+	for _, meta := range metas {
+		if meta.PhotoID == photoID || meta.DHash == "" {
+			continue
+		}
+
+		hash, err := imaging.ParseHash(meta.DHash)
+		if err != nil {
+			continue
+		}
+
+		if distance := imaging.Distance(targetHash, hash); distance <= threshold {
+			similar = append(similar, models.SimilarPhoto{
+				PhotoID:  meta.PhotoID,
+				Distance: distance,
+			})
+		}
+	}
+
+	sort.Slice(similar, func(i, j int) bool {
+		return similar[i].Distance < similar[j].Distance
+	})
+
+	return similar, nil
+}
+
+func (s *MinioService) checkBatchDuplicates(photos []*preparedPhoto) error {
+	hashes := make([]uint64, len(photos))
+	for i, photo := range photos {
+		if photo.dhash == "" {
+			continue
+		}
+		hash, err := imaging.ParseHash(photo.dhash)
+		if err != nil {
+			return fmt.Errorf("invalid perceptual hash: %w", err)
+		}
+		hashes[i] = hash
+	}
+
+	for i := range photos {
+		for j := i + 1; j < len(photos); j++ {
+			if photos[i].dhash == "" || photos[j].dhash == "" {
+				continue
+			}
+			if imaging.Distance(hashes[i], hashes[j]) <= s.similarityThreshold {
+				return fmt.Errorf("%w: photo %d and photo %d", ErrNearDuplicate, i+1, j+1)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *MinioService) listPhotoMeta(ctx context.Context, userID string) ([]*models.PhotoMeta, error) {
+	objects, err := s.storage.List(ctx, photoMetaPrefix(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photo records: %w", err)
+	}
+
+	metas := make([]*models.PhotoMeta, 0, len(objects))
+	for _, object := range objects {
+		photoID := strings.TrimSuffix(path.Base(object.Key), ".json")
+
+		meta, err := s.getPhotoMeta(ctx, userID, photoID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load photo record %s: %w", photoID, err)
+		}
+		metas = append(metas, meta)
+	}
+
+	return metas, nil
+}
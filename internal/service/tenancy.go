@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// TenantsRoot holds the objects of tenants that share the deployment's
+// buckets, each under a prefix of its own.
+const TenantsRoot = "tenants/"
+
+func TenantPrefix(tenantID string) string {
+	return TenantsRoot + tenantID + "/"
+}
+
+// TenantKeyScope is EncryptionKeyScope for a tenant with a bucket of its
+// own, so its users never share SSE-C keys with same-named users of
+// another tenant.
+func TenantKeyScope(tenantID string) func(objectName string) string {
+	return func(objectName string) string {
+		return tenantKeyScope(tenantID, objectName)
+	}
+}
+
+func tenantKeyScope(tenantID string, objectName string) string {
+	return fmt.Sprintf("tenant:%s/%s", tenantID, EncryptionKeyScope(objectName))
+}
+
+// ValidateTenancy checks the tenant config before any tenant is served.
+func ValidateTenancy(cfg *config.Config) error {
+	tenancy := cfg.Tenancy
+	switch tenancy.Source {
+	case "metadata":
+	case "claims":
+		if tenancy.ClaimsSecret == "" {
+			return fmt.Errorf("tenancy from claims requires claims_secret")
+		}
+	default:
+		return fmt.Errorf("unknown tenancy source %q", tenancy.Source)
+	}
+
+	buckets := map[string]bool{cfg.Minio.BucketName: true}
+	for _, b := range cfg.Minio.Buckets {
+		buckets[b.Name] = true
+	}
+	ids := make(map[string]bool)
+	publicURLs := make(map[string]bool)
+	if root, err := url.Parse(cfg.HTTP.PublicURL); err == nil {
+		publicURLs[root.Host+strings.TrimSuffix(root.Path, "/")] = true
+	}
+	for _, t := range tenancy.Tenants {
+		if !validTenantID(t.ID) {
+			return fmt.Errorf("invalid tenant id %q", t.ID)
+		}
+		if ids[t.ID] {
+			return fmt.Errorf("duplicate tenant id %q", t.ID)
+		}
+		ids[t.ID] = true
+
+		if tenancy.Source == "metadata" && t.Token == "" {
+			return fmt.Errorf("tenant %s has no token", t.ID)
+		}
+		if t.Bucket != "" {
+			if buckets[t.Bucket] {
+				return fmt.Errorf("bucket %s of tenant %s is already in use", t.Bucket, t.ID)
+			}
+			buckets[t.Bucket] = true
+		}
+		// The public URL is what tells the HTTP server whose request it is.
+		publicURL, err := url.Parse(t.PublicURL)
+		if err != nil || publicURL.Host == "" {
+			return fmt.Errorf("tenant %s needs an absolute public url", t.ID)
+		}
+		key := publicURL.Host + strings.TrimSuffix(publicURL.Path, "/")
+		if publicURLs[key] {
+			return fmt.Errorf("public url of tenant %s is already in use", t.ID)
+		}
+		publicURLs[key] = true
+		if t.PresignedExpiryHours < 0 || t.MaxBatchPhotos < 0 || t.MaxUploadSize < 0 {
+			return fmt.Errorf("tenant %s has negative limits", t.ID)
+		}
+	}
+
+	return nil
+}
+
+// validTenantID keeps tenant IDs usable as key prefixes and cache key parts.
+func validTenantID(tenantID string) bool {
+	if tenantID == "" {
+		return false
+	}
+	for _, r := range tenantID {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TenantPublicPaths copies the public paths into the prefix of each tenant
+// sharing the buckets, so their objects are as readable as the
+// deployment's own.
+func TenantPublicPaths(paths []string, tenants []config.Tenant) []string {
+	scoped := slices.Clone(paths)
+	for _, t := range tenants {
+		if t.Bucket != "" {
+			continue
+		}
+		for _, p := range paths {
+			scoped = append(scoped, TenantPrefix(t.ID)+p)
+		}
+	}
+
+	return scoped
+}
+
+// TenantLifecycleRules copies the lifecycle rules into the prefix of each
+// tenant sharing the buckets. Rules over the whole bucket cover tenants
+// already.
+func TenantLifecycleRules(rules []storage.LifecycleRule, tenants []config.Tenant) []storage.LifecycleRule {
+	scoped := slices.Clone(rules)
+	for _, t := range tenants {
+		if t.Bucket != "" {
+			continue
+		}
+		for _, rule := range rules {
+			if rule.Prefix == "" {
+				continue
+			}
+			rule.ID = fmt.Sprintf("tenant-%s-%s", t.ID, rule.ID)
+			rule.Prefix = TenantPrefix(t.ID) + rule.Prefix
+			scoped = append(scoped, rule)
+		}
+	}
+
+	return scoped
+}
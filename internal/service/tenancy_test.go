@@ -0,0 +1,108 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage/storagetest"
+	"github.com/acyushka/nbf-file-storage-service/internal/tenancy"
+)
+
+// tenantServices builds services for tenants a and b, either under
+// prefixes of one shared bucket, whose own client is returned, or each in
+// a bucket of its own.
+func tenantServices(t *testing.T, ownBuckets bool) (*MinioService, *MinioService, *storage.MinioClient) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.PresignedUrl.ExpiryHours = 1
+
+	fake := storagetest.NewFakeS3()
+	var root *storage.MinioClient
+	view := func(tenantID string) *storage.MinioClient {
+		if ownBuckets {
+			return storagetest.Connect(t, fake, tenantID+"-bucket").ForTenant(tenantID, "")
+		}
+		return root.ForTenant(tenantID, TenantPrefix(tenantID))
+	}
+	if !ownBuckets {
+		root = storagetest.Connect(t, fake, testBucket)
+		root.ReserveForTenants(TenantsRoot)
+	}
+
+	a := NewMinioService(Dependencies{Storage: view("a"), Tenant: "a"}, cfg)
+	b := NewMinioService(Dependencies{Storage: view("b"), Tenant: "b"}, cfg)
+	return a, b, root
+}
+
+func TestTenantsCannotReachEachOther(t *testing.T) {
+	for _, mode := range []struct {
+		name       string
+		ownBuckets bool
+	}{
+		{"shared bucket", false},
+		{"own buckets", true},
+	} {
+		t.Run(mode.name, func(t *testing.T) {
+			ctxA := tenancy.WithID(context.Background(), "a")
+			ctxB := tenancy.WithID(context.Background(), "b")
+			a, b, root := tenantServices(t, mode.ownBuckets)
+
+			data := testImage(t, 1)
+			uploaded, err := a.UploadPhotos(ctxA, "alice", []models.PhotoData{{
+				Data:        bytes.NewReader(data),
+				FileSize:    int64(len(data)),
+				FileName:    "photo.png",
+				ContentType: "image/png",
+			}}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			photoID := uploaded[0].PhotoID
+
+			// Tenant b knows nothing of the photo, whatever the ID.
+			if _, err := b.GetPhotoURL(ctxB, "alice", photoID, nil); !errors.Is(err, ErrPhotoNotFound) {
+				t.Fatalf("b read a's photo: %v", err)
+			}
+			if err := b.DeletePhoto(ctxB, "alice", photoID); !errors.Is(err, ErrPhotoNotFound) {
+				t.Fatalf("b deleted a's photo: %v", err)
+			}
+			if objects, err := b.storage.List(ctxB, ""); err != nil || len(objects) != 0 {
+				t.Fatalf("b listed %d objects, %v, want none", len(objects), err)
+			}
+
+			// Nor can a's view be used on b's behalf.
+			if _, err := a.GetPhotoURL(ctxB, "alice", photoID, nil); !errors.Is(err, storage.ErrTenantMismatch) {
+				t.Fatalf("a's view read for b: %v", err)
+			}
+			if err := a.DeletePhoto(ctxB, "alice", photoID); !errors.Is(err, storage.ErrTenantMismatch) {
+				t.Fatalf("a's view deleted for b: %v", err)
+			}
+			if _, err := a.storage.List(ctxB, ""); !errors.Is(err, storage.ErrTenantMismatch) {
+				t.Fatalf("a's view listed for b: %v", err)
+			}
+
+			if !mode.ownBuckets {
+				key := photoMetaKey("alice", photoID)
+				if err := b.storage.GetJSON(ctxB, "../a/"+key, &models.PhotoMeta{}); !errors.Is(err, storage.ErrTenantMismatch) {
+					t.Fatalf("b climbed into a's prefix: %v", err)
+				}
+				if err := root.GetJSON(context.Background(), TenantPrefix("a")+key, &models.PhotoMeta{}); !errors.Is(err, storage.ErrTenantMismatch) {
+					t.Fatalf("the deployment read a tenant's record: %v", err)
+				}
+				if err := root.Delete(context.Background(), TenantPrefix("a")+key); !errors.Is(err, storage.ErrTenantMismatch) {
+					t.Fatalf("the deployment deleted a tenant's record: %v", err)
+				}
+			}
+
+			if _, err := a.GetPhotoURL(ctxA, "alice", photoID, nil); err != nil {
+				t.Fatalf("a lost its photo: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/imaging"
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+
+	"github.com/hesoyamTM/nbf-auth/pkg/logger"
+)
+
+func renditionKey(blobKey string, format string) string {
+	return blobKey + "." + format
+}
+
+// transcodeLater produces the configured renditions a stored photo's blob
+// still lacks, in the background and outside the blob lock, and records
+// them on the blob and the photo. Uploads that deduplicate onto a blob
+// missing renditions, after an encoder failure say, fill them in. A failed
+// encoder never fails the upload: the original stays servable. With every
+// slot taken the photo goes without until its blob is uploaded again.
+func (s *MinioService) transcodeLater(ctx context.Context, meta *models.PhotoMeta, data []byte) {
+	if s.transcoder == nil || meta.Placeholder == nil {
+		return
+	}
+
+	var missing []string
+	for _, format := range s.renditionFormats {
+		if _, ok := meta.Renditions[format]; !ok {
+			missing = append(missing, format)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	select {
+	case s.transcodeSlots <- struct{}{}:
+	default:
+		metrics.TranscodesDeferred.Add(1)
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer func() { <-s.transcodeSlots }()
+
+		if err := s.transcode(ctx, meta, missing, data); err != nil {
+			metrics.TranscodeFailures.Add(1)
+			logTranscodeFailure(ctx, meta.BlobKey, err)
+		}
+	}()
+}
+
+func (s *MinioService) transcode(ctx context.Context, meta *models.PhotoMeta, formats []string, data []byte) error {
+	renditions := make(map[string]string, len(formats))
+	for _, format := range formats {
+		encoded, err := s.transcoder.Transcode(ctx, data, format)
+		if err != nil {
+			metrics.TranscodeFailures.Add(1)
+			logTranscodeFailure(ctx, meta.BlobKey, fmt.Errorf("failed to encode %s: %w", format, err))
+			continue
+		}
+
+		// Keep the rendition only if it actually beats the original.
+		if len(encoded) >= len(data) {
+			continue
+		}
+
+		key := renditionKey(meta.BlobKey, format)
+		if err := s.storage.Upload(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), imaging.ContentType(format)); err != nil {
+			metrics.TranscodeFailures.Add(1)
+			logTranscodeFailure(ctx, meta.BlobKey, fmt.Errorf("failed to store %s rendition: %w", format, err))
+			continue
+		}
+		renditions[format] = key
+	}
+
+	if len(renditions) == 0 {
+		return nil
+	}
+
+	return s.recordRenditions(ctx, meta, renditions)
+}
+
+// recordRenditions adds renditions to a blob's ref and to the photo that
+// asked for them. A blob removed while they were encoded takes them along.
+func (s *MinioService) recordRenditions(ctx context.Context, meta *models.PhotoMeta, renditions map[string]string) error {
+	_, err := s.updateBlobRef(ctx, meta.Kind, meta.SHA256, func(ref *models.BlobRef) (*models.BlobRef, error) {
+		if ref == nil || ref.Claimed != nil || ref.Refs <= 0 {
+			return nil, errBlobClaimed
+		}
+		if ref.Renditions == nil {
+			ref.Renditions = make(map[string]string, len(renditions))
+		}
+		maps.Copy(ref.Renditions, renditions)
+		return ref, nil
+	})
+	if errors.Is(err, errBlobClaimed) {
+		// releaseBlob may have listed the renditions before these landed.
+		for _, key := range renditions {
+			if err := s.storage.Delete(ctx, key); err != nil {
+				return fmt.Errorf("failed to remove rendition of a removed blob: %w", err)
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	unlock := s.metaLocks.Lock(photoMetaKey(meta.UserID, meta.PhotoID))
+	defer unlock()
+
+	_, err = s.updatePhotoMeta(ctx, meta.UserID, meta.PhotoID, func(current *models.PhotoMeta) error {
+		if current.BlobKey != meta.BlobKey {
+			return errMetaUnchanged
+		}
+		if current.Renditions == nil {
+			current.Renditions = make(map[string]string, len(renditions))
+		}
+		maps.Copy(current.Renditions, renditions)
+		return nil
+	})
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func logTranscodeFailure(ctx context.Context, blobKey string, err error) {
+	if log, logErr := logger.LoggerFromCtx(ctx); logErr == nil {
+		log.Error(fmt.Sprintf("transcoding %s failed: %v", blobKey, err))
+	}
+}
+
+// photoRenditions returns a photo's renditions. Renditions backfilled
+// after the photo was stored, by a later upload of the same bytes, are
+// only recorded on the photo that brought them and on the blob, so a photo
+// missing any is topped up from its blob's ref.
+func (s *MinioService) photoRenditions(ctx context.Context, meta *models.PhotoMeta) (map[string]string, error) {
+	if len(meta.Renditions) >= len(s.renditionFormats) {
+		return meta.Renditions, nil
+	}
+
+	var ref models.BlobRef
+	err := s.storage.GetJSON(ctx, blobRefKey(meta.Kind, meta.SHA256), &ref)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return meta.Renditions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load blob ref: %w", err)
+	}
+	if len(ref.Renditions) == 0 {
+		return meta.Renditions, nil
+	}
+
+	renditions := maps.Clone(ref.Renditions)
+	maps.Copy(renditions, meta.Renditions)
+	return renditions, nil
+}
+
+// negotiateRendition picks the most preferred configured format that both
+// the client accepts and renditions holds.
+func (s *MinioService) negotiateRendition(renditions map[string]string, acceptFormats []string) (string, string) {
+	if len(renditions) == 0 || len(acceptFormats) == 0 {
+		return "", ""
+	}
+
+	accepted := make([]string, len(acceptFormats))
+	for i, format := range acceptFormats {
+		accepted[i] = imaging.NormalizeFormat(format)
+	}
+
+	for _, format := range s.renditionFormats {
+		if key, ok := renditions[format]; ok && slices.Contains(accepted, format) {
+			return format, key
+		}
+	}
+
+	return "", ""
+}
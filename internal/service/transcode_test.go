@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+)
+
+// flakyTranscoder fails its first call and encodes to a single byte after.
+type flakyTranscoder struct {
+	calls atomic.Int32
+}
+
+func (f *flakyTranscoder) Transcode(ctx context.Context, data []byte, format string) ([]byte, error) {
+	if f.calls.Add(1) == 1 {
+		return nil, errors.New("encoder crashed")
+	}
+	return []byte{0}, nil
+}
+
+// waitTranscodes waits for background transcoding to finish; the slot is
+// taken before an upload returns and given back once the work is done.
+func waitTranscodes(t *testing.T, s *MinioService) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.transcodeSlots) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("transcoding did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDedupHitBackfillsRenditions(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.PresignedUrl.ExpiryHours = 1
+	s, _ := newTestService(t, cfg, Dependencies{})
+	transcoder := &flakyTranscoder{}
+	s.transcoder = transcoder
+	s.renditionFormats = []string{"webp"}
+
+	data := testImage(t, 1)
+
+	first := uploadTestPhoto(t, s, "alice", data)
+	waitTranscodes(t, s)
+	if first, err := s.getPhotoMeta(ctx, "alice", first.PhotoID); err != nil || len(first.Renditions) != 0 {
+		t.Fatalf("renditions %v, %v after a failed encode", first.Renditions, err)
+	}
+
+	second := uploadTestPhoto(t, s, "bob", data)
+	waitTranscodes(t, s)
+
+	want := renditionKey(second.BlobKey, "webp")
+	second, err := s.getPhotoMeta(ctx, "bob", second.PhotoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Renditions["webp"] != want {
+		t.Fatalf("renditions %v on the photo, want %s", second.Renditions, want)
+	}
+
+	var ref models.BlobRef
+	if err := s.storage.GetJSON(ctx, blobRefKey(models.KindPhoto, second.SHA256), &ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref.Refs != 2 || ref.Renditions["webp"] != want {
+		t.Fatalf("blob ref %+v", ref)
+	}
+	if !s.storage.ObjectExists(ctx, want) {
+		t.Fatal("rendition was not stored")
+	}
+
+	// The first uploader is offered the backfilled rendition as well.
+	url, err := s.GetPhotoURL(ctx, "alice", first.PhotoID, []string{"webp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url.ContentType != "image/webp" || !strings.Contains(url.URL, want) {
+		t.Fatalf("alice offered %s at %s, want the webp rendition", url.ContentType, url.URL)
+	}
+
+	// Later uploads take the renditions straight from the blob.
+	third := uploadTestPhoto(t, s, "carol", data)
+	waitTranscodes(t, s)
+	if third.Renditions["webp"] != want || transcoder.calls.Load() != 2 {
+		t.Fatalf("renditions %v after %d encodes", third.Renditions, transcoder.calls.Load())
+	}
+}
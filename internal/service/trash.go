@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// Deleted photos wait here, out of the catalog, until the retention
+// passes. A record in the trash keeps its blob reference, so restoring
+// never needs the bytes again.
+const trashRoot = "_meta/trash/"
+
+func trashPrefix(userID string) string {
+	return trashRoot + userID + "/"
+}
+
+func trashKey(userID string, photoID string) string {
+	return trashPrefix(userID) + photoID + ".json"
+}
+
+// trashPhoto moves a photo's record into the trash. The record is written
+// there before it leaves the catalog, so an interrupted delete leaves the
+// photo in both places, and the catalog copy wins.
+func (s *MinioService) trashPhoto(ctx context.Context, meta *models.PhotoMeta) error {
+	unlock := s.metaLocks.Lock(photoMetaKey(meta.UserID, meta.PhotoID))
+	defer unlock()
+
+	meta.DeletedAt = time.Now().UTC()
+	if err := s.storage.PutJSON(ctx, trashKey(meta.UserID, meta.PhotoID), meta); err != nil {
+		return fmt.Errorf("failed to move photo to trash: %w", err)
+	}
+
+	return s.forgetPhoto(ctx, meta)
+}
+
+// ListDeletedPhotos lists the user's photos in the trash, oldest deletion
+// first.
+func (s *MinioService) ListDeletedPhotos(ctx context.Context, userID string) ([]models.DeletedPhoto, error) {
+	objects, err := s.storage.List(ctx, trashPrefix(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	deleted := make([]models.DeletedPhoto, 0, len(objects))
+	for _, object := range objects {
+		var meta models.PhotoMeta
+		if err := s.storage.GetJSON(ctx, object.Key, &meta); err != nil {
+			if errors.Is(err, storage.ErrObjectNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to load trashed photo: %w", err)
+		}
+
+		deleted = append(deleted, models.DeletedPhoto{
+			PhotoID:     meta.PhotoID,
+			Kind:        meta.Kind,
+			ContentType: meta.ContentType,
+			FileSize:    meta.FileSize,
+			Placeholder: meta.Placeholder,
+			DeletedAt:   meta.DeletedAt,
+			PurgeAt:     meta.DeletedAt.Add(s.trashRetention),
+		})
+	}
+
+	slices.SortFunc(deleted, func(a, b models.DeletedPhoto) int {
+		return a.DeletedAt.Compare(b.DeletedAt)
+	})
+
+	return deleted, nil
+}
+
+// RestorePhoto puts a photo from the trash back into the catalog, with the
+// same ID, visibility and moderation state it had.
+func (s *MinioService) RestorePhoto(ctx context.Context, userID string, photoID string) error {
+	unlock := s.metaLocks.Lock(photoMetaKey(userID, photoID))
+	defer unlock()
+
+	var meta models.PhotoMeta
+	err := s.storage.GetJSON(ctx, trashKey(userID, photoID), &meta)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return ErrPhotoNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load trashed photo: %w", err)
+	}
+
+	exists, err := s.storage.Exists(ctx, photoMetaKey(userID, photoID))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		meta.DeletedAt = time.Time{}
+
+		if state := moderationOf(&meta); state != models.ModerationApproved {
+			if err := s.storage.PutJSON(ctx, moderationKey(state, userID, photoID), struct{}{}); err != nil {
+				return fmt.Errorf("failed to update moderation index: %w", err)
+			}
+		}
+		// Consumers see a restore as the photo arriving again.
+		if err := s.recordStored(ctx, &meta); err != nil {
+			return err
+		}
+		// Only ever created here: a record another instance wrote in the
+		// meantime wins over the trashed copy.
+		err := s.storage.PutJSONIf(ctx, photoMetaKey(userID, photoID), &meta, "")
+		switch {
+		case err == nil:
+			s.publishStored(ctx, &meta)
+		case !errors.Is(err, storage.ErrPreconditionFailed):
+			return fmt.Errorf("failed to save photo record: %w", err)
+		}
+	}
+
+	if err := s.storage.Delete(ctx, trashKey(userID, photoID)); err != nil {
+		return fmt.Errorf("failed to remove photo from trash: %w", err)
+	}
+
+	// A miss cached while the photo was trashed would otherwise keep
+	// hiding it.
+	return s.invalidateURLs(ctx, userID, photoID)
+}
+
+// PurgeTrash removes photos whose retention has passed and reports how
+// many went.
+func (s *MinioService) PurgeTrash(ctx context.Context) (int, error) {
+	objects, err := s.storage.List(ctx, trashRoot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	purged := 0
+	for _, object := range objects {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+
+		userID, name, ok := strings.Cut(strings.TrimPrefix(object.Key, trashRoot), "/")
+		photoID, isRecord := strings.CutSuffix(name, ".json")
+		if !ok || !isRecord {
+			continue
+		}
+
+		dropped, err := s.dropTrashed(ctx, userID, photoID, true)
+		if err != nil {
+			continue
+		}
+		if dropped {
+			purged++
+			metrics.PurgedTrash.Add(1)
+		}
+	}
+
+	return purged, nil
+}
+
+// dropTrashed removes a photo from the trash for good, or only once its
+// retention has passed if expiredOnly is set. The record goes before the
+// blob reference: a crash in between leaves an unreferenced blob for the
+// garbage collector rather than a reference released twice.
+func (s *MinioService) dropTrashed(ctx context.Context, userID string, photoID string, expiredOnly bool) (bool, error) {
+	unlock := s.metaLocks.Lock(photoMetaKey(userID, photoID))
+	defer unlock()
+
+	var meta models.PhotoMeta
+	err := s.storage.GetJSON(ctx, trashKey(userID, photoID), &meta)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load trashed photo: %w", err)
+	}
+	if expiredOnly && time.Since(meta.DeletedAt) < s.trashRetention {
+		return false, nil
+	}
+
+	// A restore or delete interrupted half way leaves the record in the
+	// catalog too, holding the blob reference.
+	live, err := s.storage.Exists(ctx, photoMetaKey(userID, photoID))
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.storage.Delete(ctx, trashKey(userID, photoID)); err != nil {
+		return false, fmt.Errorf("failed to remove photo from trash: %w", err)
+	}
+	if live {
+		return false, nil
+	}
+
+	if err := s.releaseBlob(ctx, meta.Kind, meta.SHA256); err != nil {
+		return false, fmt.Errorf("failed to release blob: %w", err)
+	}
+
+	return true, nil
+}
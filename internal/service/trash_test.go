@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+)
+
+func TestRestoredPhotosResolveDespiteCachedMisses(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.PresignedUrl.ExpiryHours = 2
+	cfg.Trash.Enabled = true
+	cfg.Trash.Retention = time.Hour
+	cfg.URLCache.Enabled = true
+	cfg.URLCache.Size = 16
+	cfg.URLCache.TTL = time.Hour
+	cfg.URLCache.NegativeTTL = time.Hour
+	s, _ := newTestService(t, cfg, Dependencies{})
+	meta := uploadTestPhoto(t, s, "alice", testImage(t, 1))
+
+	if err := s.DeletePhoto(ctx, "alice", meta.PhotoID); err != nil {
+		t.Fatal(err)
+	}
+	// Caches the miss.
+	if _, err := s.GetPhotoURL(ctx, "alice", meta.PhotoID, nil); !errors.Is(err, ErrPhotoNotFound) {
+		t.Fatalf("trashed photo returned %v, want ErrPhotoNotFound", err)
+	}
+
+	if err := s.RestorePhoto(ctx, "alice", meta.PhotoID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetPhotoURL(ctx, "alice", meta.PhotoID, nil); err != nil {
+		t.Fatalf("restored photo returned %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/cache"
+	"github.com/acyushka/nbf-file-storage-service/internal/config"
+	"github.com/acyushka/nbf-file-storage-service/internal/imaging"
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/tenancy"
+)
+
+// urlCache hands out the same presigned URL for a photo until shortly before
+// it expires, so browsers and CDNs can cache the image, and remembers
+// missing photos briefly so repeated misses don't reach MinIO.
+type urlCache struct {
+	cache       cache.Cache
+	ttl         time.Duration
+	negativeTTL time.Duration
+	// tenant is checked on reads, since a hit never reaches the storage
+	// that would otherwise refuse a caller acting for another tenant.
+	tenant string
+}
+
+// cachedPhoto holds every URL variant issued for one photo so a delete can
+// drop them all with a single key.
+type cachedPhoto struct {
+	Missing   bool                       `json:"missing,omitempty"`
+	ExpiresAt time.Time                  `json:"expires_at"`
+	Variants  map[string]models.PhotoURL `json:"variants,omitempty"`
+}
+
+// newURLCache keeps each tenant's URLs apart in a shared backend.
+func newURLCache(cfg *config.Config, tenantID string) *urlCache {
+	if !cfg.URLCache.Enabled {
+		return nil
+	}
+
+	var backend cache.Cache
+	switch cfg.URLCache.Backend {
+	case "redis":
+		prefix := "photo-url:"
+		if tenantID != "" {
+			prefix += tenantID + ":"
+		}
+		backend = cache.NewRedisCache(cfg.URLCache.RedisAddr, cfg.URLCache.RedisPassword, prefix, cfg.URLCache.RedisPoolSize)
+	default:
+		backend = cache.NewMemoryCache(cfg.URLCache.Size)
+	}
+
+	// Never hand out a URL that expires sooner than the safety margin.
+	expiry := time.Duration(cfg.PresignedUrl.ExpiryHours) * time.Hour
+	ttl := min(cfg.URLCache.TTL, expiry-cfg.URLCache.SafetyMargin)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return &urlCache{
+		cache:       backend,
+		ttl:         ttl,
+		negativeTTL: cfg.URLCache.NegativeTTL,
+		tenant:      tenantID,
+	}
+}
+
+func urlCacheKey(userID string, photoID string) string {
+	return userID + "/" + photoID
+}
+
+func variantKey(acceptFormats []string) string {
+	formats := make([]string, len(acceptFormats))
+	for i, format := range acceptFormats {
+		formats[i] = imaging.NormalizeFormat(format)
+	}
+	slices.Sort(formats)
+
+	return strings.Join(slices.Compact(formats), ",")
+}
+
+// get looks up a cached URL. On a hit, missing reports a negatively cached
+// photo. Backend failures are treated as misses.
+func (c *urlCache) get(ctx context.Context, userID string, photoID string, acceptFormats []string) (photoURL *models.PhotoURL, missing bool, ok bool) {
+	if tenancy.ID(ctx) != c.tenant {
+		return nil, false, false
+	}
+
+	entry, ok := c.load(ctx, userID, photoID)
+	if !ok {
+		metrics.URLCacheMisses.Add(1)
+		return nil, false, false
+	}
+
+	if entry.Missing {
+		metrics.URLCacheHits.Add(1)
+		return nil, true, true
+	}
+
+	variant, ok := entry.Variants[variantKey(acceptFormats)]
+	if !ok {
+		metrics.URLCacheMisses.Add(1)
+		return nil, false, false
+	}
+
+	metrics.URLCacheHits.Add(1)
+	return &variant, false, true
+}
+
+func (c *urlCache) put(ctx context.Context, userID string, photoID string, acceptFormats []string, photoURL *models.PhotoURL) {
+	entry, ok := c.load(ctx, userID, photoID)
+	if !ok || entry.Missing {
+		entry = &cachedPhoto{ExpiresAt: time.Now().Add(c.ttl)}
+	}
+	if entry.Variants == nil {
+		entry.Variants = make(map[string]models.PhotoURL)
+	}
+	entry.Variants[variantKey(acceptFormats)] = *photoURL
+
+	c.store(ctx, userID, photoID, entry, time.Until(entry.ExpiresAt))
+}
+
+func (c *urlCache) putMissing(ctx context.Context, userID string, photoID string) {
+	if c.negativeTTL <= 0 {
+		return
+	}
+
+	c.store(ctx, userID, photoID, &cachedPhoto{
+		Missing:   true,
+		ExpiresAt: time.Now().Add(c.negativeTTL),
+	}, c.negativeTTL)
+}
+
+func (c *urlCache) invalidate(ctx context.Context, userID string, photoID string) error {
+	return c.cache.Delete(ctx, urlCacheKey(userID, photoID))
+}
+
+func (c *urlCache) load(ctx context.Context, userID string, photoID string) (*cachedPhoto, bool) {
+	data, ok, err := c.cache.Get(ctx, urlCacheKey(userID, photoID))
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	var entry cachedPhoto
+	if err := json.Unmarshal(data, &entry); err != nil || time.Now().After(entry.ExpiresAt) {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+func (c *urlCache) store(ctx context.Context, userID string, photoID string, entry *cachedPhoto, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	// Caching is best effort; a failed write only costs a future miss.
+	_ = c.cache.Set(ctx, urlCacheKey(userID, photoID), data, ttl)
+}
+
+// cachedPhotoURL wraps photoURL with the URL cache when one is configured.
+// Only verified lookups are written back, so an unchecked legacy key can
+// never be served to callers that rely on the existence check, and neither
+// are an owner's, which may cover photos only the owner may see yet. Cached
+// URLs point at the primary, so the cache is left alone while it is down.
+func (s *MinioService) cachedPhotoURL(ctx context.Context, userID string, photoID string, acceptFormats []string, verify bool) (*models.PhotoURL, error) {
+	if s.urlCache == nil || s.storage.PrimaryDown() {
+		return s.photoURL(ctx, userID, photoID, acceptFormats, verify)
+	}
+
+	if photoURL, missing, ok := s.urlCache.get(ctx, userID, photoID, acceptFormats); ok {
+		if missing {
+			return nil, ErrPhotoNotFound
+		}
+		return photoURL, nil
+	}
+
+	photoURL, err := s.photoURL(ctx, userID, photoID, acceptFormats, verify)
+	if !verify || requestedBy(ctx, userID) {
+		return photoURL, err
+	}
+
+	switch {
+	case errors.Is(err, ErrPhotoNotFound):
+		s.urlCache.putMissing(ctx, userID, photoID)
+	case err == nil:
+		s.urlCache.put(ctx, userID, photoID, acceptFormats, photoURL)
+	}
+
+	return photoURL, err
+}
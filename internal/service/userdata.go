@@ -0,0 +1,732 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrErasureDisabled = errors.New("user data erasure requires a receipt secret")
+	ErrErasureNotFound = errors.New("erasure does not exist")
+	ErrExportNotFound  = errors.New("export does not exist")
+	ErrInvalidUserID   = errors.New("invalid user id")
+)
+
+const (
+	erasuresRoot = "_meta/erasures/"
+	exportsRoot  = "exports/"
+)
+
+// An erasure in progress is saved after this many items.
+const erasureSaveEvery = 50
+
+func erasureKey(id string) string {
+	return erasuresRoot + id + ".json"
+}
+
+func exportKey(userID string, exportID string) string {
+	return exportsRoot + userID + "/" + exportID
+}
+
+// ValidUserID rejects IDs that would make the user's legacy prefix reach
+// into the service's own top-level prefixes, or out of its own.
+func ValidUserID(userID string) bool {
+	if !ValidID(userID) {
+		return false
+	}
+
+	switch userID + "/" {
+	case "_meta/", "blobs/", uploadsRoot, quarantineRoot + "/", documentsRoot + "/", exportsRoot, TenantsRoot:
+		return false
+	}
+
+	return true
+}
+
+// ValidID rejects IDs that cannot be a single segment of an object key.
+func ValidID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.Contains(id, "/")
+}
+
+// DeleteAllUserData starts erasing everything stored for a user and returns
+// the erasure to follow with GetErasure. Photos whose bytes are shared with
+// other users only lose this user's reference.
+func (s *MinioService) DeleteAllUserData(ctx context.Context, userID string) (*models.Erasure, error) {
+	if len(s.receiptSecret) == 0 {
+		return nil, ErrErasureDisabled
+	}
+	if !ValidUserID(userID) {
+		return nil, ErrInvalidUserID
+	}
+
+	now := time.Now().UTC()
+	erasure := &models.Erasure{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    models.ErasureRunning,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.storage.PutJSON(ctx, erasureKey(erasure.ID), erasure); err != nil {
+		return nil, fmt.Errorf("failed to save erasure: %w", err)
+	}
+
+	// Like scans, the erasure outlives the request. One cut short by a
+	// restart is picked up by ResumeErasures.
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		_ = s.runErasure(ctx, erasure.ID)
+	}()
+
+	return erasure, nil
+}
+
+func (s *MinioService) GetErasure(ctx context.Context, erasureID string) (*models.Erasure, error) {
+	var erasure models.Erasure
+	err := s.storage.GetJSON(ctx, erasureKey(erasureID), &erasure)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrErasureNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load erasure: %w", err)
+	}
+
+	return &erasure, nil
+}
+
+// ResumeErasures finishes erasures interrupted by a restart and reports
+// how many it ran.
+func (s *MinioService) ResumeErasures(ctx context.Context) (int, error) {
+	if len(s.receiptSecret) == 0 {
+		return 0, nil
+	}
+
+	objects, err := s.storage.List(ctx, erasuresRoot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list erasures: %w", err)
+	}
+
+	resumed := 0
+	for _, object := range objects {
+		id := strings.TrimSuffix(strings.TrimPrefix(object.Key, erasuresRoot), ".json")
+
+		erasure, err := s.GetErasure(ctx, id)
+		if err != nil {
+			return resumed, err
+		}
+		if erasure.Status != models.ErasureRunning {
+			continue
+		}
+
+		if err := s.runErasure(ctx, id); err != nil {
+			return resumed, err
+		}
+		resumed++
+	}
+
+	return resumed, nil
+}
+
+// runErasure works through one erasure. Every stage lists what is left
+// and removes it, so running a stage again after an interruption is safe;
+// Deleted may undercount items removed since the last save.
+func (s *MinioService) runErasure(ctx context.Context, erasureID string) error {
+	unlock := s.metaLocks.Lock(erasureKey(erasureID))
+	defer unlock()
+
+	erasure, err := s.GetErasure(ctx, erasureID)
+	if err != nil {
+		return err
+	}
+	if erasure.Status != models.ErasureRunning {
+		return nil
+	}
+
+	// Staged uploads go first, so none is ingested behind the erasure.
+	stages := []struct {
+		name  string
+		erase func(ctx context.Context, erasure *models.Erasure) error
+	}{
+		{"uploads", s.eraseUnder(uploadsRoot + erasure.UserID + "/")},
+		{"photos", s.erasePhotos},
+		{"trash", s.eraseTrash},
+		{"legacy_photos", s.eraseLegacyPhotos},
+		{"documents", s.eraseUnder(documentsRoot + "/" + erasure.UserID + "/")},
+		{"quarantine", s.eraseUnder(quarantineRoot + "/" + erasure.UserID + "/")},
+		{"exports", s.eraseUnder(exportsRoot + erasure.UserID + "/")},
+		{"shares", s.eraseShares},
+		{"versions", s.eraseVersions},
+	}
+	for _, stage := range stages {
+		erasure.Stage = stage.name
+		if err := s.saveErasure(ctx, erasure); err != nil {
+			return err
+		}
+
+		if err := stage.erase(ctx, erasure); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			erasure.Status = models.ErasureFailed
+			erasure.Error = err.Error()
+			if saveErr := s.saveErasure(ctx, erasure); saveErr != nil {
+				err = errors.Join(err, saveErr)
+			}
+			return err
+		}
+	}
+
+	completedAt := time.Now().UTC()
+	erasure.Status = models.ErasureCompleted
+	erasure.Stage = ""
+	erasure.Receipt = &models.ErasureReceipt{
+		ErasureID:   erasure.ID,
+		UserID:      erasure.UserID,
+		Deleted:     erasure.Deleted,
+		StartedAt:   erasure.StartedAt,
+		CompletedAt: completedAt,
+	}
+	erasure.Receipt.Signature = s.receiptSignature(erasure.Receipt)
+	if err := s.saveErasure(ctx, erasure); err != nil {
+		return err
+	}
+	metrics.CompletedErasures.Add(1)
+
+	return nil
+}
+
+func (s *MinioService) saveErasure(ctx context.Context, erasure *models.Erasure) error {
+	erasure.UpdatedAt = time.Now().UTC()
+	if err := s.storage.PutJSON(ctx, erasureKey(erasure.ID), erasure); err != nil {
+		return fmt.Errorf("failed to save erasure: %w", err)
+	}
+
+	return nil
+}
+
+// erased counts one removed item, saving progress every so often.
+func (s *MinioService) erased(ctx context.Context, erasure *models.Erasure) error {
+	erasure.Deleted++
+	metrics.ErasedItems.Add(1)
+
+	if erasure.Deleted%erasureSaveEvery != 0 {
+		return nil
+	}
+
+	return s.saveErasure(ctx, erasure)
+}
+
+func (s *MinioService) erasePhotos(ctx context.Context, erasure *models.Erasure) error {
+	objects, err := s.storage.List(ctx, photoMetaPrefix(erasure.UserID))
+	if err != nil {
+		return fmt.Errorf("failed to list photo records: %w", err)
+	}
+
+	for _, object := range objects {
+		photoID, ok := strings.CutSuffix(strings.TrimPrefix(object.Key, photoMetaPrefix(erasure.UserID)), ".json")
+		if !ok {
+			continue
+		}
+
+		if err := s.rememberBlob(ctx, erasure, photoMetaKey(erasure.UserID, photoID)); err != nil {
+			return err
+		}
+
+		err := s.purgePhoto(ctx, erasure.UserID, photoID)
+		if errors.Is(err, ErrPhotoNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete photo %s: %w", photoID, err)
+		}
+		if err := s.erased(ctx, erasure); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MinioService) eraseTrash(ctx context.Context, erasure *models.Erasure) error {
+	objects, err := s.storage.List(ctx, trashPrefix(erasure.UserID))
+	if err != nil {
+		return fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	for _, object := range objects {
+		photoID, ok := strings.CutSuffix(strings.TrimPrefix(object.Key, trashPrefix(erasure.UserID)), ".json")
+		if !ok {
+			continue
+		}
+
+		if err := s.rememberBlob(ctx, erasure, object.Key); err != nil {
+			return err
+		}
+
+		dropped, err := s.dropTrashed(ctx, erasure.UserID, photoID, false)
+		if err != nil {
+			return fmt.Errorf("failed to delete trashed photo %s: %w", photoID, err)
+		}
+		if !dropped {
+			continue
+		}
+		if err := s.erased(ctx, erasure); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rememberBlob notes the blob behind a photo record before it is deleted,
+// for eraseVersions. Only versioned buckets keep anything to purge.
+func (s *MinioService) rememberBlob(ctx context.Context, erasure *models.Erasure, recordKey string) error {
+	if !s.storage.Versioning() {
+		return nil
+	}
+
+	var meta models.PhotoMeta
+	err := s.storage.GetJSON(ctx, recordKey, &meta)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load photo record: %w", err)
+	}
+
+	key := blobKey(meta.Kind, meta.SHA256)
+	if slices.Contains(erasure.Blobs, key) {
+		return nil
+	}
+	erasure.Blobs = append(erasure.Blobs, key)
+
+	return s.saveErasure(ctx, erasure)
+}
+
+// eraseVersions purges what deletes leave behind on a versioned bucket:
+// old versions and delete markers under the user's prefixes, and of blobs
+// the user was the last to refer to.
+func (s *MinioService) eraseVersions(ctx context.Context, erasure *models.Erasure) error {
+	if !s.storage.Versioning() {
+		return nil
+	}
+
+	userID := erasure.UserID
+	prefixes := []string{
+		uploadsRoot + userID + "/",
+		photoMetaPrefix(userID),
+		trashPrefix(userID),
+		moderationPrefix(models.ModerationPending) + userID + "/",
+		moderationPrefix(models.ModerationRejected) + userID + "/",
+		userID + "/",
+		documentsRoot + "/" + userID + "/",
+		quarantineRoot + "/" + userID + "/",
+		exportsRoot + userID + "/",
+	}
+	for _, prefix := range prefixes {
+		if _, err := s.storage.PurgePrefix(ctx, prefix); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range erasure.Blobs {
+		if err := s.purgeReleasedBlob(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MinioService) purgeReleasedBlob(ctx context.Context, key string) error {
+	kindName, hash, ok := strings.Cut(strings.TrimPrefix(key, "blobs/"), "/")
+	if !ok {
+		return nil
+	}
+	kind := models.PhotoKind(kindName)
+
+	unlock := s.blobLocks.Lock(key)
+	defer unlock()
+
+	shared, err := s.storage.Exists(ctx, blobRefKey(kind, hash))
+	if err != nil || shared {
+		return err
+	}
+
+	// The blob key prefixes its renditions too.
+	if _, err := s.storage.PurgePrefix(ctx, key); err != nil {
+		return err
+	}
+
+	return s.storage.Purge(ctx, blobRefKey(kind, hash))
+}
+
+// eraseLegacyPhotos removes the user's prefix from before the catalog.
+// Photos go through purgePhoto so their delete events are still sent.
+func (s *MinioService) eraseLegacyPhotos(ctx context.Context, erasure *models.Erasure) error {
+	objects, err := s.storage.List(ctx, erasure.UserID+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list legacy objects: %w", err)
+	}
+
+	for _, object := range objects {
+		if userID, photoID, ok := parseLegacyObjectName(object.Key); ok && userID == erasure.UserID {
+			err = s.purgePhoto(ctx, userID, photoID)
+		} else {
+			err = s.storage.Delete(ctx, object.Key)
+		}
+		if errors.Is(err, ErrPhotoNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", object.Key, err)
+		}
+		if err := s.erased(ctx, erasure); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eraseUnder removes every object under prefix.
+func (s *MinioService) eraseUnder(prefix string) func(ctx context.Context, erasure *models.Erasure) error {
+	return func(ctx context.Context, erasure *models.Erasure) error {
+		objects, err := s.storage.List(ctx, prefix)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+
+		for _, object := range objects {
+			if err := s.storage.Delete(ctx, object.Key); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", object.Key, err)
+			}
+			if err := s.erased(ctx, erasure); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func (s *MinioService) eraseShares(ctx context.Context, erasure *models.Erasure) error {
+	links, err := s.userShareLinks(ctx, erasure.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		// Share links are keyed by token, which eraseVersions cannot find
+		// once they are gone.
+		if err := s.storage.Purge(ctx, shareKey(link.Token)); err != nil {
+			return fmt.Errorf("failed to delete share link: %w", err)
+		}
+		if err := s.erased(ctx, erasure); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// userShareLinks walks every share link, which are keyed by token alone.
+func (s *MinioService) userShareLinks(ctx context.Context, userID string) ([]models.ShareLink, error) {
+	objects, err := s.storage.List(ctx, "_meta/shares/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+
+	var links []models.ShareLink
+	for _, object := range objects {
+		var link models.ShareLink
+		if err := s.storage.GetJSON(ctx, object.Key, &link); err != nil {
+			if errors.Is(err, storage.ErrObjectNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to load share link: %w", err)
+		}
+		if link.UserID == userID {
+			links = append(links, link)
+		}
+	}
+
+	return links, nil
+}
+
+func (s *MinioService) receiptSignature(receipt *models.ErasureReceipt) string {
+	mac := hmac.New(sha256.New, s.receiptSecret)
+	fmt.Fprintf(mac, "%s|%s|%d|%d|%d",
+		receipt.ErasureID,
+		receipt.UserID,
+		receipt.Deleted,
+		receipt.StartedAt.Unix(),
+		receipt.CompletedAt.Unix(),
+	)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ExportUserData packs everything stored for a user into a ZIP archive
+// under exports/ and returns a URL to it that expires with the export:
+// originals of photos and avatars, documents in plaintext, the photo
+// records and the user's share links. Quarantined files are left out.
+func (s *MinioService) ExportUserData(ctx context.Context, userID string) (*models.UserDataExport, error) {
+	if !ValidUserID(userID) {
+		return nil, ErrInvalidUserID
+	}
+
+	exportID := uuid.New().String() + ".zip"
+	key := exportKey(userID, exportID)
+
+	// Presigned URLs cannot carry SSE-C keys; the export is then served
+	// through the HTTP server, which needs the signing secret.
+	if s.storage.CustomerKeys(key) && len(s.signingSecret) == 0 {
+		return nil, ErrProxyDisabled
+	}
+
+	pr, pw := io.Pipe()
+	archive := &countingWriter{w: pw}
+	go func() {
+		pw.CloseWithError(s.writeExport(ctx, archive, userID))
+	}()
+
+	if err := s.storage.Upload(ctx, key, pr, -1, "application/zip"); err != nil {
+		pr.CloseWithError(err)
+		return nil, fmt.Errorf("failed to store export: %w", err)
+	}
+	metrics.UserExports.Add(1)
+
+	expiresAt := time.Now().Add(s.exportExpiry).Truncate(time.Second)
+
+	var exportURL string
+	if s.storage.CustomerKeys(key) {
+		exportURL = fmt.Sprintf("%s/exports/%s/%s/%s?exp=%d",
+			s.downloadBaseURL,
+			s.expiringSignature("export", userID, exportID, expiresAt.Unix()),
+			url.PathEscape(userID),
+			url.PathEscape(exportID),
+			expiresAt.Unix(),
+		)
+	} else {
+		var err error
+		exportURL, err = s.storage.GetPresignedUrlFor(ctx, key, s.exportExpiry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.UserDataExport{
+		URL:       exportURL,
+		Size:      archive.n,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// OpenExport verifies a signed export path and opens the archive for
+// streaming.
+func (s *MinioService) OpenExport(ctx context.Context, signature string, userID string, exportID string, expires int64) (*models.Download, error) {
+	if len(s.signingSecret) == 0 {
+		return nil, ErrProxyDisabled
+	}
+
+	expected := s.expiringSignature("export", userID, exportID, expires)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return nil, ErrDownloadExpired
+	}
+
+	obj, info, err := s.storage.Get(ctx, exportKey(userID, exportID))
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Download{
+		Content:     obj,
+		ContentType: info.ContentType,
+		ETag:        `"` + info.ETag + `"`,
+		ModTime:     info.LastModified,
+		Size:        info.Size,
+		ExpiresAt:   time.Unix(expires, 0),
+	}, nil
+}
+
+func (s *MinioService) writeExport(ctx context.Context, w io.Writer, userID string) error {
+	archive := zip.NewWriter(w)
+
+	metas, err := s.exportPhotos(ctx, archive, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.exportLegacyPhotos(ctx, archive, userID); err != nil {
+		return err
+	}
+	if err := s.exportDocuments(ctx, archive, userID); err != nil {
+		return err
+	}
+
+	links, err := s.userShareLinks(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := writeExportJSON(archive, "photos.json", metas); err != nil {
+		return err
+	}
+	if err := writeExportJSON(archive, "share_links.json", links); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func (s *MinioService) exportPhotos(ctx context.Context, archive *zip.Writer, userID string) ([]*models.PhotoMeta, error) {
+	objects, err := s.storage.List(ctx, photoMetaPrefix(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photo records: %w", err)
+	}
+
+	metas := make([]*models.PhotoMeta, 0, len(objects))
+	for _, object := range objects {
+		if !strings.HasSuffix(object.Key, ".json") {
+			continue
+		}
+
+		var meta models.PhotoMeta
+		if err := s.storage.GetJSON(ctx, object.Key, &meta); err != nil {
+			if errors.Is(err, storage.ErrObjectNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to load photo record: %w", err)
+		}
+
+		if err := s.exportObject(ctx, archive, s.storage, path.Join(string(meta.Kind), meta.PhotoID), meta.BlobKey); err != nil {
+			return nil, err
+		}
+		metas = append(metas, &meta)
+	}
+
+	return metas, nil
+}
+
+func (s *MinioService) exportLegacyPhotos(ctx context.Context, archive *zip.Writer, userID string) error {
+	objects, err := s.storage.List(ctx, userID+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list legacy objects: %w", err)
+	}
+
+	for _, object := range objects {
+		_, photoID, ok := parseLegacyObjectName(object.Key)
+		if !ok {
+			continue
+		}
+		if err := s.exportObject(ctx, archive, s.storage, path.Join(string(models.KindPhoto), photoID), object.Key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MinioService) exportDocuments(ctx context.Context, archive *zip.Writer, userID string) error {
+	if s.documents == nil {
+		return nil
+	}
+
+	prefix := documentKey(userID, "")
+	objects, err := s.storage.List(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	for _, object := range objects {
+		name := path.Join("documents", strings.TrimPrefix(object.Key, prefix))
+		if err := s.exportObject(ctx, archive, s.documents, name, object.Key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportObject copies one object into the archive. Objects deleted since
+// they were listed are skipped.
+func (s *MinioService) exportObject(ctx context.Context, archive *zip.Writer, store storage.ObjectStore, name string, objectName string) error {
+	obj, info, err := store.Get(ctx, objectName)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", objectName, err)
+	}
+	defer obj.Close()
+
+	// Photos are compressed already.
+	method := zip.Deflate
+	if strings.HasPrefix(info.ContentType, "image/") {
+		method = zip.Store
+	}
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   method,
+		Modified: info.LastModified,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to export: %w", name, err)
+	}
+	if _, err := io.Copy(entry, obj); err != nil {
+		return fmt.Errorf("failed to add %s to export: %w", name, err)
+	}
+
+	return nil
+}
+
+func writeExportJSON(archive *zip.Writer, name string, v any) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to export: %w", name, err)
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to add %s to export: %w", name, err)
+	}
+
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
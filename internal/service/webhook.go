@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/webhooks"
+)
+
+var (
+	ErrWebhooksDisabled        = errors.New("webhooks are disabled")
+	ErrInvalidWebhookURL       = webhooks.ErrInvalidURL
+	ErrWebhookNotFound         = webhooks.ErrSubscriptionNotFound
+	ErrWebhookDeliveryNotFound = webhooks.ErrDeliveryNotFound
+)
+
+// CreateWebhook subscribes a partner endpoint to eventTypes, or to every
+// event when none are given. The returned secret signs its deliveries.
+func (s *MinioService) CreateWebhook(ctx context.Context, url string, eventTypes []string) (*models.WebhookSubscription, error) {
+	if s.webhooks == nil {
+		return nil, ErrWebhooksDisabled
+	}
+
+	return s.webhooks.Subscribe(ctx, url, eventTypes)
+}
+
+func (s *MinioService) DeleteWebhook(ctx context.Context, id string) error {
+	if s.webhooks == nil {
+		return ErrWebhooksDisabled
+	}
+
+	return s.webhooks.Unsubscribe(ctx, id)
+}
+
+func (s *MinioService) ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error) {
+	if s.webhooks == nil {
+		return nil, ErrWebhooksDisabled
+	}
+
+	return s.webhooks.Subscriptions(ctx)
+}
+
+// ListDeadWebhookDeliveries lists deliveries that ran out of retries.
+func (s *MinioService) ListDeadWebhookDeliveries(ctx context.Context) ([]models.WebhookDelivery, error) {
+	if s.webhooks == nil {
+		return nil, ErrWebhooksDisabled
+	}
+
+	return s.webhooks.DeadLetters(ctx)
+}
+
+// ReplayWebhook sends a dead delivery again.
+func (s *MinioService) ReplayWebhook(ctx context.Context, deliveryID string) error {
+	if s.webhooks == nil {
+		return ErrWebhooksDisabled
+	}
+
+	return s.webhooks.Replay(ctx, deliveryID)
+}
+
+// DeliverWebhooks runs the delivery workers until ctx is cancelled.
+func (s *MinioService) DeliverWebhooks(ctx context.Context) error {
+	if s.webhooks == nil {
+		return nil
+	}
+
+	return s.webhooks.Run(ctx)
+}
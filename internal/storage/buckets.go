@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Bucket is an extra bucket that takes every object under its prefixes in
+// place of the default bucket, with a policy and encryption of its own.
+type Bucket struct {
+	Name     string
+	Prefixes []string
+	// PublicRead opens the whole bucket to anonymous reads, on top of the
+	// public paths routed to it.
+	PublicRead bool
+	Encryption Encryption
+}
+
+type bucket struct {
+	name       string
+	prefixes   []string
+	publicRead bool
+	encryption Encryption
+}
+
+// provisionBucket makes sure a bucket exists, with versioning turned on if
+// asked for.
+func provisionBucket(ctx context.Context, client *minio.Client, bucketName string, versioning bool) error {
+	exists, err := client.BucketExists(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket %s: %w", bucketName, err)
+	}
+
+	if !exists {
+		if err := client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{}); err != nil {
+			return fmt.Errorf("failed to make bucket %s: %w", bucketName, err)
+		}
+	}
+
+	if versioning {
+		return enableVersioning(ctx, client, bucketName)
+	}
+
+	return nil
+}
+
+// bucketFor routes an object to the bucket with the longest prefix of its
+// name, or to the default bucket when none matches.
+func (m *MinioClient) bucketFor(objectName string) *bucket {
+	routed, longest := m.buckets[0], -1
+	for _, b := range m.buckets[1:] {
+		for _, prefix := range b.prefixes {
+			if strings.HasPrefix(objectName, prefix) && len(prefix) > longest {
+				routed, longest = b, len(prefix)
+			}
+		}
+	}
+
+	return routed
+}
+
+// bucketsUnder lists the buckets that may hold objects under prefix: the
+// one prefix itself routes to, and any routed a longer prefix beneath it.
+func (m *MinioClient) bucketsUnder(prefix string) []*bucket {
+	buckets := []*bucket{m.bucketFor(prefix)}
+	for _, b := range m.buckets[1:] {
+		if slices.Contains(buckets, b) {
+			continue
+		}
+		for _, routed := range b.prefixes {
+			if strings.HasPrefix(routed, prefix) {
+				buckets = append(buckets, b)
+				break
+			}
+		}
+	}
+
+	return buckets
+}
+
+// listBucket lists up to limit objects of one bucket, or all with limit 0.
+func (m *MinioClient) listBucket(ctx context.Context, b *bucket, opts minio.ListObjectsOptions, limit int) ([]minio.ObjectInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var objects []minio.ObjectInfo
+	for object := range m.client.ListObjects(ctx, b.name, opts) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", opts.Prefix, object.Err)
+		}
+		// Another bucket's prefix may sit beneath this one's; what is
+		// stored there under it is not routed here any more.
+		if m.bucketFor(object.Key) != b {
+			continue
+		}
+		objects = append(objects, object)
+		if len(objects) == limit {
+			break
+		}
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"context"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/minio-go/v7/pkg/sse"
+)
+
+type EncryptionMode string
+
+const (
+	EncryptionNone     EncryptionMode = "none"
+	EncryptionS3       EncryptionMode = "sse-s3"
+	EncryptionKMS      EncryptionMode = "sse-kms"
+	EncryptionCustomer EncryptionMode = "sse-c"
+)
+
+const (
+	sseHeader         = "X-Amz-Server-Side-Encryption"
+	sseKMSKeyHeader   = "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"
+	sseCustomerHeader = "X-Amz-Server-Side-Encryption-Customer-Algorithm"
+)
+
+// Encryption configures server-side encryption of stored objects.
+type Encryption struct {
+	Mode     EncryptionMode
+	KMSKeyID string
+	// MasterKey is the secret SSE-C keys are derived from. Objects still
+	// encrypted under one of PreviousMasterKeys stay readable until the
+	// rotation job re-encrypts them.
+	MasterKey          []byte
+	PreviousMasterKeys [][]byte
+	// KeyScope maps an object name onto the owner its SSE-C key is derived
+	// for, so that objects of different owners never share a key.
+	KeyScope func(objectName string) string
+}
+
+func (e Encryption) validate() error {
+	switch e.Mode {
+	case "", EncryptionNone, EncryptionS3:
+	case EncryptionKMS:
+		if e.KMSKeyID == "" {
+			return fmt.Errorf("sse-kms requires a kms key id")
+		}
+	case EncryptionCustomer:
+		if len(e.MasterKey) < 32 {
+			return fmt.Errorf("sse-c requires a master key of at least 32 bytes")
+		}
+		if e.KeyScope == nil {
+			return fmt.Errorf("sse-c requires a key scope")
+		}
+	default:
+		return fmt.Errorf("unknown encryption mode %q", e.Mode)
+	}
+
+	for _, key := range e.PreviousMasterKeys {
+		if len(key) < 32 {
+			return fmt.Errorf("previous master keys must be at least 32 bytes")
+		}
+	}
+	if len(e.PreviousMasterKeys) > 0 && e.KeyScope == nil {
+		return fmt.Errorf("previous master keys require a key scope")
+	}
+
+	return nil
+}
+
+// CustomerKeys reports whether objectName is encrypted with SSE-C. Such
+// objects can only be read by presenting the key, which rules out
+// presigned and public URLs.
+func (m *MinioClient) CustomerKeys(objectName string) bool {
+	return m.bucketFor(m.prefix+objectName).encryption.Mode == EncryptionCustomer
+}
+
+// AnyCustomerKeys reports whether any bucket uses SSE-C.
+func (m *MinioClient) AnyCustomerKeys() bool {
+	for _, b := range m.buckets {
+		if b.encryption.Mode == EncryptionCustomer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReconcileEncryption sets each bucket's default encryption for SSE-S3 and
+// SSE-KMS, so objects written by other tools are covered as well. SSE-C has
+// no bucket default, and with encryption off a bucket is left alone.
+func (m *MinioClient) ReconcileEncryption(ctx context.Context) error {
+	for _, b := range m.buckets {
+		var config *sse.Configuration
+		switch b.encryption.Mode {
+		case EncryptionS3:
+			config = sse.NewConfigurationSSES3()
+		case EncryptionKMS:
+			config = sse.NewConfigurationSSEKMS(b.encryption.KMSKeyID)
+		default:
+			continue
+		}
+
+		if err := m.client.SetBucketEncryption(ctx, b.name, config); err != nil {
+			return fmt.Errorf("failed to set encryption of bucket %s: %w", b.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Reencrypt brings a single object in line with the configured encryption,
+// copying it onto itself when it is stored unencrypted, under another mode
+// or under a previous master key. It reports whether a copy was made.
+// Unlike other calls it reaches the root reserved for tenants, so that one
+// pass over the bucket covers them too.
+func (m *MinioClient) Reencrypt(ctx context.Context, objectName string) (bool, error) {
+	objectName, err := m.scopeAny(ctx, objectName)
+	if err != nil {
+		return false, err
+	}
+
+	target, err := m.writeEncryption(objectName)
+	if err != nil {
+		return false, err
+	}
+
+	candidates, err := m.readEncryptions(objectName)
+	if err != nil {
+		return false, err
+	}
+
+	b := m.bucketFor(objectName)
+	var lastErr error
+	for i, source := range candidates {
+		info, err := m.client.StatObject(ctx, b.name, objectName, minio.StatObjectOptions{ServerSideEncryption: source})
+		if err != nil {
+			if isNotFound(err) {
+				return false, ErrObjectNotFound
+			}
+			lastErr = err
+			continue
+		}
+
+		if encryptedAsConfigured(b.encryption, info, i) {
+			return false, nil
+		}
+
+		if _, err := m.client.CopyObject(ctx,
+			minio.CopyDestOptions{Bucket: b.name, Object: objectName, Encryption: target},
+			minio.CopySrcOptions{Bucket: b.name, Object: objectName, Encryption: source, MatchETag: info.ETag},
+		); err != nil {
+			return false, fmt.Errorf("failed to re-encrypt %s: %w", objectName, err)
+		}
+		// The copy has a new ETag the replica must be made from.
+		m.recordChange(ctx, objectName)
+
+		return true, nil
+	}
+
+	return false, fmt.Errorf("no known key opens %s: %w", objectName, lastErr)
+}
+
+// encryptedAsConfigured tells whether an object that opened with the
+// candidate at index needs no re-encryption. Under SSE-C the current key
+// always comes first.
+func encryptedAsConfigured(encryption Encryption, info minio.ObjectInfo, index int) bool {
+	switch encryption.Mode {
+	case EncryptionCustomer:
+		return index == 0
+	case EncryptionS3:
+		return info.Metadata.Get(sseHeader) == "AES256"
+	case EncryptionKMS:
+		return info.Metadata.Get(sseHeader) == "aws:kms" &&
+			strings.HasSuffix(info.Metadata.Get(sseKMSKeyHeader), encryption.KMSKeyID)
+	default:
+		return info.Metadata.Get(sseHeader) == "" && info.Metadata.Get(sseCustomerHeader) == ""
+	}
+}
+
+// writeEncryption is the encryption new writes of objectName get.
+func (m *MinioClient) writeEncryption(objectName string) (encrypt.ServerSide, error) {
+	encryption := m.bucketFor(objectName).encryption
+	switch encryption.Mode {
+	case EncryptionS3:
+		return encrypt.NewSSE(), nil
+	case EncryptionKMS:
+		sse, err := encrypt.NewSSEKMS(encryption.KMSKeyID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure sse-kms: %w", err)
+		}
+		return sse, nil
+	case EncryptionCustomer:
+		return customerKey(encryption, encryption.MasterKey, objectName)
+	default:
+		return nil, nil
+	}
+}
+
+// readEncryptions lists the keys an existing object may be stored under,
+// most likely first: the current SSE-C key, no key at all (plain, SSE-S3
+// and SSE-KMS objects need none to be read), then previous master keys.
+func (m *MinioClient) readEncryptions(objectName string) ([]encrypt.ServerSide, error) {
+	encryption := m.bucketFor(objectName).encryption
+	var candidates []encrypt.ServerSide
+	if encryption.Mode == EncryptionCustomer {
+		current, err := customerKey(encryption, encryption.MasterKey, objectName)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, current)
+	}
+
+	candidates = append(candidates, nil)
+
+	for _, master := range encryption.PreviousMasterKeys {
+		previous, err := customerKey(encryption, master, objectName)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, previous)
+	}
+
+	return candidates, nil
+}
+
+// customerKey derives the SSE-C key for objectName's scope with HKDF, so
+// only the master key has to be kept and a single derived key exposes
+// nothing beyond its own scope.
+func customerKey(encryption Encryption, master []byte, objectName string) (encrypt.ServerSide, error) {
+	key, err := hkdf.Key(sha256.New, master, nil, "nbf-file-storage sse-c "+encryption.KeyScope(objectName), 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive sse-c key: %w", err)
+	}
+
+	sse, err := encrypt.NewSSEC(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure sse-c: %w", err)
+	}
+
+	return sse, nil
+}
+
+// openObject opens objectName with the first candidate key that works.
+// Only SSE-C with rotation in progress ever has more than one candidate.
+func (m *MinioClient) openObject(ctx context.Context, objectName string) (*minio.Object, minio.ObjectInfo, error) {
+	candidates, err := m.readEncryptions(objectName)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, err
+	}
+
+	var errs []error
+	for _, sse := range candidates {
+		obj, err := m.client.GetObject(ctx, m.bucketFor(objectName).name, objectName, minio.GetObjectOptions{ServerSideEncryption: sse})
+		if err != nil {
+			return nil, minio.ObjectInfo{}, fmt.Errorf("failed to get object: %w", err)
+		}
+
+		info, err := obj.Stat()
+		if err == nil {
+			return obj, info, nil
+		}
+		obj.Close()
+
+		if isNotFound(err) {
+			return nil, minio.ObjectInfo{}, ErrObjectNotFound
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, minio.ObjectInfo{}, fmt.Errorf("failed to stat object: %w", errors.Join(errs...))
+}
@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var ErrPresignDisabled = errors.New("presigned urls are disabled for encrypted objects")
+
+// ErrObjectCorrupt is returned when reading an encrypted object whose
+// ciphertext fails authentication.
+var ErrObjectCorrupt = errors.New("object is corrupt")
+
+const (
+	envelopeMagic      = "NBFE"
+	envelopeVersion    = 1
+	envelopeHeaderSize = len(envelopeMagic) + 1 + 4 + envelopeNonceSize
+	envelopeNonceSize  = 7
+	envelopeTagSize    = 16
+
+	// User metadata keys, as minio-go reports them back on stat.
+	envelopeKeyMeta   = "Envelope-Key"
+	envelopeKeyIDMeta = "Envelope-Key-Id"
+	envelopeSizeMeta  = "Envelope-Size"
+)
+
+// EnvelopeStore encrypts objects before they reach the wrapped store, so
+// the storage provider only ever sees ciphertext. Every object gets its own
+// AES-256-GCM data key, wrapped by a KeyWrapper and kept in the object's
+// metadata.
+//
+// Objects are written as a header (magic, version, chunk size, nonce
+// prefix) followed by independently sealed chunks. A chunk's nonce is the
+// prefix, its index and a final-chunk flag, so chunks can be neither
+// reordered nor dropped from the end, and a range can be decrypted without
+// reading the chunks before it.
+type EnvelopeStore struct {
+	store     ObjectStore
+	keys      KeyWrapper
+	chunkSize int
+}
+
+func NewEnvelopeStore(store ObjectStore, keys KeyWrapper, chunkSize int) *EnvelopeStore {
+	return &EnvelopeStore{
+		store:     store,
+		keys:      keys,
+		chunkSize: chunkSize,
+	}
+}
+
+var _ ObjectStore = (*EnvelopeStore)(nil)
+
+// UploadWithMetadata needs the plaintext size up front to know which chunk
+// is the last one.
+func (e *EnvelopeStore) UploadWithMetadata(ctx context.Context, objectName string, data io.Reader, fileSize int64, contentType string, userMetadata map[string]string) error {
+	if fileSize < 0 {
+		return fmt.Errorf("failed to encrypt %s: size is required", objectName)
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	keyID, wrapped, err := e.keys.WrapKey(ctx, objectName, dataKey)
+	if err != nil {
+		return fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	aead, err := newDataCipher(dataKey)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, envelopeHeaderSize)
+	copy(header, envelopeMagic)
+	header[len(envelopeMagic)] = envelopeVersion
+	binary.BigEndian.PutUint32(header[len(envelopeMagic)+1:], uint32(e.chunkSize))
+	prefix := header[envelopeHeaderSize-envelopeNonceSize:]
+	if _, err := rand.Read(prefix); err != nil {
+		return fmt.Errorf("failed to generate nonce prefix: %w", err)
+	}
+
+	metadata := make(map[string]string, len(userMetadata)+3)
+	for k, v := range userMetadata {
+		metadata[k] = v
+	}
+	metadata[envelopeKeyMeta] = base64.StdEncoding.EncodeToString(wrapped)
+	metadata[envelopeKeyIDMeta] = keyID
+	metadata[envelopeSizeMeta] = strconv.FormatInt(fileSize, 10)
+
+	chunks := chunkCount(fileSize, e.chunkSize)
+	encrypted := &encryptingReader{
+		src:       data,
+		aead:      aead,
+		prefix:    bytes.Clone(prefix),
+		chunkSize: e.chunkSize,
+		remaining: fileSize,
+		chunks:    chunks,
+		out:       header,
+	}
+
+	return e.store.UploadWithMetadata(ctx, objectName, encrypted, int64(envelopeHeaderSize)+fileSize+chunks*envelopeTagSize, contentType, metadata)
+}
+
+// Get returns a seekable plaintext view of the object. The reported size
+// is the plaintext size; the ETag is the ciphertext's, which is just as
+// unique per write.
+func (e *EnvelopeStore) Get(ctx context.Context, objectName string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
+	obj, info, err := e.store.Get(ctx, objectName)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, err
+	}
+
+	reader, size, err := e.openEnvelope(ctx, objectName, obj, info)
+	if err != nil {
+		obj.Close()
+		return nil, minio.ObjectInfo{}, err
+	}
+
+	info.Size = size
+
+	return reader, info, nil
+}
+
+func (e *EnvelopeStore) openEnvelope(ctx context.Context, objectName string, obj io.ReadSeekCloser, info minio.ObjectInfo) (*decryptingReader, int64, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(info.UserMetadata[envelopeKeyMeta])
+	if err != nil || len(wrapped) == 0 {
+		return nil, 0, fmt.Errorf("%s is not envelope encrypted", objectName)
+	}
+
+	size, err := strconv.ParseInt(info.UserMetadata[envelopeSizeMeta], 10, 64)
+	if err != nil || size < 0 {
+		return nil, 0, fmt.Errorf("%s has an invalid plaintext size", objectName)
+	}
+
+	dataKey, err := e.keys.UnwrapKey(ctx, objectName, info.UserMetadata[envelopeKeyIDMeta], wrapped)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	aead, err := newDataCipher(dataKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	header := make([]byte, envelopeHeaderSize)
+	if _, err := io.ReadFull(obj, header); err != nil {
+		return nil, 0, fmt.Errorf("failed to read envelope header: %w", err)
+	}
+	if string(header[:len(envelopeMagic)]) != envelopeMagic || header[len(envelopeMagic)] != envelopeVersion {
+		return nil, 0, fmt.Errorf("%s has an unknown envelope format: %w", objectName, ErrObjectCorrupt)
+	}
+
+	chunkSize := int(binary.BigEndian.Uint32(header[len(envelopeMagic)+1:]))
+	if chunkSize <= 0 {
+		return nil, 0, fmt.Errorf("%s has an invalid chunk size: %w", objectName, ErrObjectCorrupt)
+	}
+
+	return &decryptingReader{
+		obj:       obj,
+		aead:      aead,
+		prefix:    header[envelopeHeaderSize-envelopeNonceSize:],
+		chunkSize: chunkSize,
+		size:      size,
+		chunks:    chunkCount(size, chunkSize),
+		loaded:    -1,
+	}, size, nil
+}
+
+// GetPresignedUrlFor always fails: a presigned URL would hand out
+// ciphertext. Encrypted objects are served through the service instead.
+func (e *EnvelopeStore) GetPresignedUrlFor(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	return "", ErrPresignDisabled
+}
+
+func (e *EnvelopeStore) ObjectExists(ctx context.Context, objectName string) bool {
+	return e.store.ObjectExists(ctx, objectName)
+}
+
+func (e *EnvelopeStore) Delete(ctx context.Context, objectName string) error {
+	return e.store.Delete(ctx, objectName)
+}
+
+func newDataCipher(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init data key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init data key: %w", err)
+	}
+
+	return aead, nil
+}
+
+// chunkCount is the number of chunks size bytes are split into. An empty
+// object still has one (empty, final) chunk.
+func chunkCount(size int64, chunkSize int) int64 {
+	if size == 0 {
+		return 1
+	}
+
+	return (size + int64(chunkSize) - 1) / int64(chunkSize)
+}
+
+func chunkNonce(prefix []byte, index int64, final bool) []byte {
+	nonce := make([]byte, envelopeNonceSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[envelopeNonceSize:], uint32(index))
+	if final {
+		nonce[len(nonce)-1] = 1
+	}
+
+	return nonce
+}
+
+// encryptingReader produces the envelope stream for a plaintext of known
+// size, one sealed chunk at a time.
+type encryptingReader struct {
+	src       io.Reader
+	aead      cipher.AEAD
+	prefix    []byte
+	chunkSize int
+	remaining int64
+	chunks    int64
+	index     int64
+	out       []byte
+}
+
+func (r *encryptingReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.index == r.chunks {
+			return 0, io.EOF
+		}
+
+		plain := make([]byte, min(int64(r.chunkSize), r.remaining))
+		if _, err := io.ReadFull(r.src, plain); err != nil {
+			return 0, fmt.Errorf("failed to read plaintext: %w", err)
+		}
+		r.remaining -= int64(len(plain))
+
+		r.out = r.aead.Seal(plain[:0], chunkNonce(r.prefix, r.index, r.index == r.chunks-1), plain, nil)
+		r.index++
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+
+	return n, nil
+}
+
+// decryptingReader serves plaintext reads and seeks by decrypting whole
+// chunks on demand, seeking the underlying object straight to the chunk
+// that holds the requested offset.
+type decryptingReader struct {
+	obj       io.ReadSeekCloser
+	aead      cipher.AEAD
+	prefix    []byte
+	chunkSize int
+	size      int64
+	chunks    int64
+	offset    int64
+
+	loaded int64
+	plain  []byte
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	index := r.offset / int64(r.chunkSize)
+	if index != r.loaded {
+		if err := r.load(index); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.plain[r.offset-index*int64(r.chunkSize):])
+	r.offset += int64(n)
+
+	return n, nil
+}
+
+func (r *decryptingReader) load(index int64) error {
+	start := int64(envelopeHeaderSize) + index*int64(r.chunkSize+envelopeTagSize)
+	if _, err := r.obj.Seek(start, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to chunk %d: %w", index, err)
+	}
+
+	plainSize := min(int64(r.chunkSize), r.size-index*int64(r.chunkSize))
+	sealed := make([]byte, plainSize+envelopeTagSize)
+	if _, err := io.ReadFull(r.obj, sealed); err != nil {
+		return fmt.Errorf("failed to read chunk %d: %w", index, err)
+	}
+
+	plain, err := r.aead.Open(sealed[:0], chunkNonce(r.prefix, index, index == r.chunks-1), sealed, nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chunk %d: %w", index, ErrObjectCorrupt)
+	}
+
+	r.loaded = index
+	r.plain = plain
+
+	return nil
+}
+
+func (r *decryptingReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	r.offset = offset
+
+	return offset, nil
+}
+
+func (r *decryptingReader) Close() error {
+	return r.obj.Close()
+}
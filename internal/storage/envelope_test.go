@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// memoryStore is an ObjectStore over a map, for looking at what the
+// envelope writes.
+type memoryStore struct {
+	objects  map[string][]byte
+	metadata map[string]map[string]string
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		objects:  make(map[string][]byte),
+		metadata: make(map[string]map[string]string),
+	}
+}
+
+func (s *memoryStore) UploadWithMetadata(ctx context.Context, objectName string, data io.Reader, fileSize int64, contentType string, userMetadata map[string]string) error {
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	if int64(len(content)) != fileSize {
+		return fmt.Errorf("uploaded %d bytes, declared %d", len(content), fileSize)
+	}
+
+	s.objects[objectName] = content
+	s.metadata[objectName] = userMetadata
+	return nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, objectName string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
+	content, ok := s.objects[objectName]
+	if !ok {
+		return nil, minio.ObjectInfo{}, ErrObjectNotFound
+	}
+
+	return nopCloser{bytes.NewReader(content)}, minio.ObjectInfo{
+		Key:          objectName,
+		Size:         int64(len(content)),
+		UserMetadata: s.metadata[objectName],
+	}, nil
+}
+
+func (s *memoryStore) GetPresignedUrlFor(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	return "", ErrPresignDisabled
+}
+
+func (s *memoryStore) ObjectExists(ctx context.Context, objectName string) bool {
+	_, ok := s.objects[objectName]
+	return ok
+}
+
+func (s *memoryStore) Delete(ctx context.Context, objectName string) error {
+	delete(s.objects, objectName)
+	return nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
+
+const testChunkSize = 16
+
+func newTestEnvelope(t *testing.T) (*EnvelopeStore, *memoryStore) {
+	t.Helper()
+
+	keys, err := NewMasterKeys("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMemoryStore()
+	return NewEnvelopeStore(store, keys, testChunkSize), store
+}
+
+func plaintext(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	for _, size := range []int{0, 1, testChunkSize - 1, testChunkSize, testChunkSize + 1, 3 * testChunkSize} {
+		e, store := newTestEnvelope(t)
+		data := plaintext(size)
+
+		if err := e.UploadWithMetadata(ctx, "doc", bytes.NewReader(data), int64(size), "application/pdf", map[string]string{"Sha256": "x"}); err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+
+		stored := store.objects["doc"]
+		if size >= testChunkSize && bytes.Contains(stored, data) {
+			t.Fatalf("size %d: plaintext reached the store", size)
+		}
+		if store.metadata["doc"]["Sha256"] != "x" {
+			t.Fatalf("size %d: user metadata was dropped", size)
+		}
+
+		obj, info, err := e.Get(ctx, "doc")
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		got, err := io.ReadAll(obj)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, data) || info.Size != int64(size) {
+			t.Fatalf("size %d: read back %d bytes, reported size %d", size, len(got), info.Size)
+		}
+	}
+}
+
+func TestEnvelopeFormat(t *testing.T) {
+	ctx := context.Background()
+	e, store := newTestEnvelope(t)
+
+	const size = 2*testChunkSize + 5
+	if err := e.UploadWithMetadata(ctx, "doc", bytes.NewReader(plaintext(size)), size, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := store.objects["doc"]
+	if string(stored[:4]) != "NBFE" {
+		t.Fatalf("magic %q, want NBFE", stored[:4])
+	}
+	if stored[4] != 1 {
+		t.Fatalf("version %d, want 1", stored[4])
+	}
+	if got := binary.BigEndian.Uint32(stored[5:9]); got != testChunkSize {
+		t.Fatalf("chunk size %d, want %d", got, testChunkSize)
+	}
+	// Header, then three chunks, each with its GCM tag.
+	if want := 4 + 1 + 4 + 7 + size + 3*16; len(stored) != want {
+		t.Fatalf("stored %d bytes, want %d", len(stored), want)
+	}
+
+	meta := store.metadata["doc"]
+	if meta["Envelope-Key-Id"] != "k1" || meta["Envelope-Size"] != "37" || meta["Envelope-Key"] == "" {
+		t.Fatalf("envelope metadata %v", meta)
+	}
+}
+
+func TestEnvelopeSeek(t *testing.T) {
+	ctx := context.Background()
+	e, _ := newTestEnvelope(t)
+
+	data := plaintext(5 * testChunkSize)
+	if err := e.UploadWithMetadata(ctx, "doc", bytes.NewReader(data), int64(len(data)), "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	obj, _, err := e.Get(ctx, "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, offset := range []int64{3*testChunkSize + 2, testChunkSize, 0, int64(len(data)) - 1} {
+		if _, err := obj.Seek(offset, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(obj)
+		if err != nil {
+			t.Fatalf("offset %d: %v", offset, err)
+		}
+		if !bytes.Equal(got, data[offset:]) {
+			t.Fatalf("offset %d: read the wrong bytes", offset)
+		}
+	}
+}
+
+func TestEnvelopeDetectsTampering(t *testing.T) {
+	ctx := context.Background()
+
+	tamper := map[string]func(stored []byte, meta map[string]string) []byte{
+		"flipped bit": func(stored []byte, meta map[string]string) []byte {
+			stored[envelopeHeaderSize+3] ^= 1
+			return stored
+		},
+		"swapped chunks": func(stored []byte, meta map[string]string) []byte {
+			sealed := testChunkSize + envelopeTagSize
+			first := bytes.Clone(stored[envelopeHeaderSize : envelopeHeaderSize+sealed])
+			copy(stored[envelopeHeaderSize:], stored[envelopeHeaderSize+sealed:envelopeHeaderSize+2*sealed])
+			copy(stored[envelopeHeaderSize+sealed:], first)
+			return stored
+		},
+		"dropped last chunk": func(stored []byte, meta map[string]string) []byte {
+			meta["Envelope-Size"] = fmt.Sprint(2 * testChunkSize)
+			return stored[:envelopeHeaderSize+2*(testChunkSize+envelopeTagSize)]
+		},
+	}
+
+	for name, change := range tamper {
+		e, store := newTestEnvelope(t)
+
+		data := plaintext(3 * testChunkSize)
+		if err := e.UploadWithMetadata(ctx, "doc", bytes.NewReader(data), int64(len(data)), "", nil); err != nil {
+			t.Fatal(err)
+		}
+		store.objects["doc"] = change(store.objects["doc"], store.metadata["doc"])
+
+		obj, _, err := e.Get(ctx, "doc")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := io.ReadAll(obj); !errors.Is(err, ErrObjectCorrupt) {
+			t.Fatalf("%s: read returned %v, want ErrObjectCorrupt", name, err)
+		}
+	}
+}
+
+func TestEnvelopeKeyIsBoundToObject(t *testing.T) {
+	ctx := context.Background()
+	e, store := newTestEnvelope(t)
+
+	data := plaintext(testChunkSize)
+	if err := e.UploadWithMetadata(ctx, "a", bytes.NewReader(data), int64(len(data)), "", nil); err != nil {
+		t.Fatal(err)
+	}
+	store.objects["b"] = store.objects["a"]
+	store.metadata["b"] = store.metadata["a"]
+
+	if _, _, err := e.Get(ctx, "b"); err == nil {
+		t.Fatal("an envelope copied to another key opened there")
+	}
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrChecksumMismatch is returned when the backend received something other
+// than what an upload's checksum says.
+var ErrChecksumMismatch = errors.New("stored data does not match its checksum")
+
+// ErrPreconditionFailed is returned by conditional writes to an object
+// that another writer changed since it was read.
+var ErrPreconditionFailed = errors.New("object changed since it was read")
+
+const checksumSHA256Header = "x-amz-checksum-sha256"
+
+func (m *MinioClient) PutJSON(ctx context.Context, objectName string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", objectName, err)
+	}
+
+	if err := m.Upload(ctx, objectName, bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
+		return fmt.Errorf("failed to put %s: %w", objectName, err)
+	}
+
+	return nil
+}
+
+// PutJSONIf is PutJSON for read-modify-write cycles: it only writes while
+// the object is still at the ETag GetJSONVersion returned, or with an
+// empty etag, while it does not exist. It fails with ErrPreconditionFailed
+// otherwise.
+func (m *MinioClient) PutJSONIf(ctx context.Context, objectName string, v any, etag string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", objectName, err)
+	}
+
+	opts := minio.PutObjectOptions{ContentType: "application/json"}
+	if etag == "" {
+		opts.SetMatchETagExcept("*")
+	} else {
+		opts.SetMatchETag(etag)
+	}
+
+	if err := m.upload(ctx, objectName, bytes.NewReader(data), int64(len(data)), opts); err != nil {
+		if errors.Is(err, ErrPreconditionFailed) {
+			return ErrPreconditionFailed
+		}
+		return fmt.Errorf("failed to put %s: %w", objectName, err)
+	}
+
+	return nil
+}
+
+func (m *MinioClient) GetJSON(ctx context.Context, objectName string, v any) error {
+	_, err := m.GetJSONVersion(ctx, objectName, v)
+	return err
+}
+
+// maxJSONReads bounds how often a read is restarted on an object that is
+// replaced while it is read.
+const maxJSONReads = 5
+
+// GetJSONVersion is GetJSON that also returns the object's ETag, for a
+// later PutJSONIf.
+func (m *MinioClient) GetJSONVersion(ctx context.Context, objectName string, v any) (string, error) {
+	objectName, err := m.scope(ctx, objectName)
+	if err != nil {
+		return "", err
+	}
+
+	var data []byte
+	var info minio.ObjectInfo
+	for attempt := 1; ; attempt++ {
+		var obj io.ReadSeekCloser
+		obj, info, err = m.read(ctx, objectName)
+		if errors.Is(err, ErrObjectNotFound) {
+			return "", ErrObjectNotFound
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get %s: %w", objectName, err)
+		}
+
+		data, err = io.ReadAll(obj)
+		obj.Close()
+		// The body is fetched pinned to the ETag the object was opened
+		// at; a writer in between fails it, and the read starts over.
+		if err != nil && isPreconditionFailed(err) && attempt < maxJSONReads {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", objectName, err)
+		}
+		break
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return "", fmt.Errorf("failed to unmarshal %s: %w", objectName, err)
+	}
+
+	return info.ETag, nil
+}
+
+// List lists every object under prefix, across all buckets that may hold
+// some, in key order.
+func (m *MinioClient) List(ctx context.Context, prefix string) ([]minio.ObjectInfo, error) {
+	prefix, err := m.scope(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []minio.ObjectInfo
+	for _, b := range m.bucketsUnder(prefix) {
+		listed, err := m.listBucket(ctx, b, minio.ListObjectsOptions{
+			Prefix:    prefix,
+			Recursive: true,
+		}, 0)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, listed...)
+	}
+	sortObjects(objects)
+
+	return m.unscope(objects), nil
+}
+
+func isNotFound(err error) bool {
+	code := minio.ToErrorResponse(err).Code
+	return code == "NoSuchKey" || code == "NoSuchObject"
+}
+
+func isPreconditionFailed(err error) bool {
+	resp := minio.ToErrorResponse(err)
+	return resp.Code == "PreconditionFailed" || resp.StatusCode == http.StatusPreconditionFailed
+}
+
+func isChecksumMismatch(err error) bool {
+	switch minio.ToErrorResponse(err).Code {
+	case "BadDigest", "XAmzContentChecksumMismatch", "XAmzContentSHA256Mismatch":
+		return true
+	default:
+		return false
+	}
+}
+
+// ListPage lists up to limit objects under prefix whose keys sort after
+// startAfter, for paging through large prefixes.
+func (m *MinioClient) ListPage(ctx context.Context, prefix string, startAfter string, limit int) ([]minio.ObjectInfo, error) {
+	prefix, err := m.scope(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if startAfter != "" {
+		startAfter = m.prefix + startAfter
+	}
+
+	var objects []minio.ObjectInfo
+	for _, b := range m.bucketsUnder(prefix) {
+		listed, err := m.listBucket(ctx, b, minio.ListObjectsOptions{
+			Prefix:     prefix,
+			Recursive:  true,
+			StartAfter: startAfter,
+			MaxKeys:    limit,
+		}, limit)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, listed...)
+	}
+
+	// Each bucket gave its first page; the first limit of them all make
+	// the page across buckets.
+	sortObjects(objects)
+	if len(objects) > limit {
+		objects = objects[:limit]
+	}
+
+	return m.unscope(objects), nil
+}
+
+func sortObjects(objects []minio.ObjectInfo) {
+	slices.SortFunc(objects, func(a, b minio.ObjectInfo) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+}
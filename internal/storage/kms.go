@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrUnknownMasterKey = errors.New("unknown master key")
+	ErrNoKeyring        = errors.New("keyring does not exist")
+	ErrKeyringExists    = errors.New("keyring already exists")
+)
+
+// KeyWrapper protects per-object data keys under master keys that never
+// leave the service.
+type KeyWrapper interface {
+	// WrapKey seals dataKey for objectName and returns the ID of the
+	// master key used alongside the wrapped key.
+	WrapKey(ctx context.Context, objectName string, dataKey []byte) (string, []byte, error)
+	UnwrapKey(ctx context.Context, objectName string, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// MasterKeys wraps data keys with AES-256-GCM under the current master
+// key. Retired keys stay available for unwrapping by ID.
+type MasterKeys struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+func NewMasterKeys(currentID string, keys map[string][]byte) (*MasterKeys, error) {
+	if _, ok := keys[currentID]; !ok {
+		return nil, fmt.Errorf("current master key %q is not configured", currentID)
+	}
+
+	m := &MasterKeys{
+		current: currentID,
+		keys:    make(map[string]cipher.AEAD, len(keys)),
+	}
+	for id, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("master key %q must be 32 bytes", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init master key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init master key %q: %w", id, err)
+		}
+		m.keys[id] = aead
+	}
+
+	return m, nil
+}
+
+// WrapKey binds the wrapped key to its object, so a wrapped key copied onto
+// another object's metadata will not unwrap there.
+func (m *MasterKeys) WrapKey(ctx context.Context, objectName string, dataKey []byte) (string, []byte, error) {
+	aead := m.keys[m.current]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return m.current, aead.Seal(nonce, nonce, dataKey, wrapAAD(m.current, objectName)), nil
+}
+
+func (m *MasterKeys) UnwrapKey(ctx context.Context, objectName string, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := m.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMasterKey, keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped key is truncated")
+	}
+
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	dataKey, err := aead.Open(nil, nonce, sealed, wrapAAD(keyID, objectName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return dataKey, nil
+}
+
+func wrapAAD(keyID string, objectName string) []byte {
+	return []byte(keyID + "\x00" + objectName)
+}
+
+// LocalKMS stands in for an external KMS: master keys live in a keyring
+// file on local disk, made beforehand with CreateKeyring. A missing
+// keyring is an error rather than made on the spot, since a fresh one
+// could not unwrap anything stored before.
+type LocalKMS struct {
+	*MasterKeys
+}
+
+type keyring struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+func NewLocalKMS(path string) (*LocalKMS, error) {
+	var ring keyring
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("%w: %s", ErrNoKeyring, path)
+	case err != nil:
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	if err := json.Unmarshal(data, &ring); err != nil {
+		return nil, fmt.Errorf("failed to parse keyring: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(ring.Keys))
+	for id, encoded := range ring.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode keyring key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+
+	masterKeys, err := NewMasterKeys(ring.Current, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalKMS{MasterKeys: masterKeys}, nil
+}
+
+// CreateKeyring writes a keyring with a fresh master key to path and
+// returns the key's ID. It never replaces an existing keyring.
+func CreateKeyring(path string) (string, error) {
+	key := make([]byte, 32)
+	id := make([]byte, 8)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate master key: %w", err)
+	}
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate master key id: %w", err)
+	}
+
+	ring := keyring{
+		Current: hex.EncodeToString(id),
+		Keys:    map[string]string{hex.EncodeToString(id): base64.StdEncoding.EncodeToString(key)},
+	}
+
+	data, err := json.MarshalIndent(ring, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal keyring: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create keyring dir: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("%w: %s", ErrKeyringExists, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write keyring: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write keyring: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write keyring: %w", err)
+	}
+
+	return ring.Current, nil
+}
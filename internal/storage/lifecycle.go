@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// ErrLifecycleUnsupported is returned by ReconcileLifecycle when the
+// backend does not implement bucket lifecycle configuration.
+var ErrLifecycleUnsupported = errors.New("bucket lifecycle is not supported")
+
+// LifecycleRule expires or tiers the objects under a key prefix. Zero
+// durations leave that action out.
+type LifecycleRule struct {
+	ID     string
+	Prefix string
+	// ExpireAfter deletes objects this long after they were written.
+	ExpireAfter time.Duration
+	// NoncurrentExpireAfter removes old versions this long after they were
+	// replaced or deleted.
+	NoncurrentExpireAfter time.Duration
+	// TransitionAfter moves objects to the tier named by StorageClass.
+	TransitionAfter time.Duration
+	StorageClass    string
+}
+
+// LifecycleChange describes what ReconcileLifecycle did to the bucket
+// lifecycles, as bucket/id.
+type LifecycleChange struct {
+	Added   []string
+	Updated []string
+	Removed []string
+}
+
+func (c LifecycleChange) Changed() bool {
+	return len(c.Added) > 0 || len(c.Updated) > 0 || len(c.Removed) > 0
+}
+
+func (c LifecycleChange) String() string {
+	return fmt.Sprintf("added rules %v, updated %v, removed %v", c.Added, c.Updated, c.Removed)
+}
+
+// ReconcileLifecycle makes the bucket lifecycle hold exactly the given
+// rules, each in every bucket that may hold objects under its prefix.
+// Rules set on a bucket by anyone else are dropped. Bucket lifecycle
+// counts in whole days, so shorter ages are rounded up to a day.
+func (m *MinioClient) ReconcileLifecycle(ctx context.Context, rules []LifecycleRule) (LifecycleChange, error) {
+	var change LifecycleChange
+	for _, b := range m.buckets {
+		var routed []LifecycleRule
+		for _, rule := range rules {
+			if slices.Contains(m.bucketsUnder(rule.Prefix), b) {
+				routed = append(routed, rule)
+			}
+		}
+
+		bucketChange, err := m.reconcileBucketLifecycle(ctx, b.name, routed)
+		if err != nil {
+			return LifecycleChange{}, err
+		}
+		change.Added = append(change.Added, bucketChange.Added...)
+		change.Updated = append(change.Updated, bucketChange.Updated...)
+		change.Removed = append(change.Removed, bucketChange.Removed...)
+	}
+
+	return change, nil
+}
+
+func (m *MinioClient) reconcileBucketLifecycle(ctx context.Context, bucketName string, rules []LifecycleRule) (LifecycleChange, error) {
+	current, err := m.client.GetBucketLifecycle(ctx, bucketName)
+	switch {
+	case err == nil:
+	case minio.ToErrorResponse(err).Code == "NoSuchLifecycleConfiguration":
+		current = lifecycle.NewConfiguration()
+	case isNotImplemented(err):
+		return LifecycleChange{}, ErrLifecycleUnsupported
+	default:
+		return LifecycleChange{}, fmt.Errorf("failed to get lifecycle of bucket %s: %w", bucketName, err)
+	}
+
+	have := make(map[string]lifecycle.Rule, len(current.Rules))
+	for _, rule := range current.Rules {
+		have[rule.ID] = rule
+	}
+
+	config := lifecycle.NewConfiguration()
+	var change LifecycleChange
+	for _, rule := range rules {
+		want := lifecycleRule(rule)
+		config.Rules = append(config.Rules, want)
+
+		existing, ok := have[rule.ID]
+		switch {
+		case !ok:
+			change.Added = append(change.Added, bucketName+"/"+rule.ID)
+		case !sameRule(existing, want):
+			change.Updated = append(change.Updated, bucketName+"/"+rule.ID)
+		}
+		delete(have, rule.ID)
+	}
+	for id := range have {
+		change.Removed = append(change.Removed, bucketName+"/"+id)
+	}
+	slices.Sort(change.Removed)
+
+	if !change.Changed() {
+		return LifecycleChange{}, nil
+	}
+
+	// An empty configuration removes the bucket lifecycle altogether.
+	if err := m.client.SetBucketLifecycle(ctx, bucketName, config); err != nil {
+		if isNotImplemented(err) {
+			return LifecycleChange{}, ErrLifecycleUnsupported
+		}
+		return LifecycleChange{}, fmt.Errorf("failed to set lifecycle of bucket %s: %w", bucketName, err)
+	}
+
+	return change, nil
+}
+
+func lifecycleRule(rule LifecycleRule) lifecycle.Rule {
+	r := lifecycle.Rule{
+		ID:         rule.ID,
+		Status:     "Enabled",
+		RuleFilter: lifecycle.Filter{Prefix: rule.Prefix},
+	}
+	if rule.ExpireAfter > 0 {
+		r.Expiration.Days = lifecycle.ExpirationDays(lifecycleDays(rule.ExpireAfter))
+	}
+	if rule.NoncurrentExpireAfter > 0 {
+		r.NoncurrentVersionExpiration.NoncurrentDays = lifecycle.ExpirationDays(lifecycleDays(rule.NoncurrentExpireAfter))
+	}
+	if rule.TransitionAfter > 0 {
+		r.Transition.Days = lifecycle.ExpirationDays(lifecycleDays(rule.TransitionAfter))
+		r.Transition.StorageClass = rule.StorageClass
+	}
+
+	return r
+}
+
+func lifecycleDays(age time.Duration) int {
+	const day = 24 * time.Hour
+	return int((age + day - 1) / day)
+}
+
+// sameRule compares rules in the form they are stored in, so anything set
+// on the bucket beyond what the config says counts as drift.
+func sameRule(a lifecycle.Rule, b lifecycle.Rule) bool {
+	x, err := xml.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := xml.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(x, y)
+}
+
+func isNotImplemented(err error) bool {
+	return minio.ToErrorResponse(err).Code == "NotImplemented"
+}
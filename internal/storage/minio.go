@@ -2,9 +2,13 @@ package storage
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -12,11 +16,30 @@ import (
 )
 
 type MinioClient struct {
-	client     *minio.Client
-	bucketName string
-	publicURL  string
+	client *minio.Client
+	// accessKey tells the client's own changes apart in bucket events.
+	accessKey   string
+	publicURL   string
+	publicPaths []string
+	versioning  bool
+	// tenant and prefix scope a view made by ForTenant.
+	tenant string
+	prefix string
+	// reserved is a root kept for tenant views, set by ReserveForTenants;
+	// the deployment's own keys may not reach into it.
+	reserved string
+	// buckets starts with the default bucket, which takes every object not
+	// routed to one of the others.
+	buckets []*bucket
+	// replication is set by ReplicateTo and shared with tenant views.
+	replication *replication
 }
 
+// NewMinioClient connects to MinIO and makes sure the default bucket and
+// every routed bucket exist, with versioning turned on if asked for.
+// Bucket policies, default encryption and lifecycle are left alone here;
+// call ReconcilePolicy, ReconcileEncryption and ReconcileLifecycle to
+// apply them.
 func NewMinioClient(
 	endpoint string,
 	publicURL string,
@@ -24,43 +47,73 @@ func NewMinioClient(
 	secretKey string,
 	useSSL bool,
 	bucketName string,
+	publicPaths []string,
+	encryption Encryption,
+	versioning bool,
+	routed []Bucket,
 ) (*MinioClient, error) {
+	if err := encryption.validate(); err != nil {
+		return nil, fmt.Errorf("invalid encryption config: %w", err)
+	}
+
+	buckets := []*bucket{{name: bucketName, encryption: encryption}}
+	for _, b := range routed {
+		if err := b.Encryption.validate(); err != nil {
+			return nil, fmt.Errorf("invalid encryption config for bucket %s: %w", b.Name, err)
+		}
+		for _, other := range buckets {
+			if other.name == b.Name {
+				return nil, fmt.Errorf("bucket %s is configured twice", b.Name)
+			}
+		}
+		if len(b.Prefixes) == 0 {
+			return nil, fmt.Errorf("bucket %s has nothing routed to it", b.Name)
+		}
+
+		buckets = append(buckets, &bucket{
+			name:       b.Name,
+			prefixes:   b.Prefixes,
+			publicRead: b.PublicRead,
+			encryption: b.Encryption,
+		})
+	}
+
 	for i := 0; i < 15; i++ {
 		client, err := minio.New(endpoint, &minio.Options{
 			Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
 			Secure: useSSL,
+			// Lets uploads carry checksums, ours or the CRC32C minio-go
+			// adds, for the backend to verify.
+			TrailingHeaders: true,
 		})
 		if err != nil {
 			fmt.Printf("[minio] connect error: %v\n", err)
 			continue
 		}
 
-		ctx := context.Background()
-		bucketExists, err := client.BucketExists(ctx, bucketName)
-		if err != nil {
-			fmt.Printf("[minio] bucket exists error: %v\n", err)
-			continue
-		}
+		// Tags our requests in MinIO's logs and traces.
+		client.SetAppInfo(appName, appVersion)
 
-		if !bucketExists {
-			if err = client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{}); err != nil {
-				fmt.Printf("[minio] make bucket error: %v\n", err)
-				continue
+		ctx := context.Background()
+		provisioned := true
+		for _, b := range buckets {
+			if err := provisionBucket(ctx, client, b.name, versioning); err != nil {
+				fmt.Printf("[minio] provision error: %v\n", err)
+				provisioned = false
+				break
 			}
 		}
-
-		policy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::` + bucketName + `/*"]}]}`
-
-		err = client.SetBucketPolicy(ctx, bucketName, policy)
-		if err != nil {
-			fmt.Printf("[minio] set policy error: %v\n", err)
+		if !provisioned {
 			continue
 		}
 
 		return &MinioClient{
-			client:     client,
-			bucketName: bucketName,
-			publicURL:  publicURL,
+			client:      client,
+			accessKey:   accessKey,
+			publicURL:   publicURL,
+			publicPaths: publicPaths,
+			versioning:  versioning,
+			buckets:     buckets,
 		}, nil
 	}
 
@@ -68,28 +121,73 @@ func NewMinioClient(
 }
 
 func (m *MinioClient) Upload(ctx context.Context, objectName string, data io.Reader, fileSize int64, contentType string) error {
-	if _, err := m.client.PutObject(
-		ctx,
-		m.bucketName,
-		objectName,
-		data,
-		fileSize,
-		minio.PutObjectOptions{
-			ContentType: contentType,
-		},
-	); err != nil {
+	return m.UploadWithMetadata(ctx, objectName, data, fileSize, contentType, nil)
+}
+
+func (m *MinioClient) UploadWithMetadata(ctx context.Context, objectName string, data io.Reader, fileSize int64, contentType string, userMetadata map[string]string) error {
+	return m.upload(ctx, objectName, data, fileSize, minio.PutObjectOptions{
+		ContentType:  contentType,
+		UserMetadata: userMetadata,
+	})
+}
+
+// UploadWithChecksum is UploadWithMetadata for data whose SHA-256 is known.
+// The checksum goes along as a header, so the backend refuses data damaged
+// on the way with ErrChecksumMismatch, and keeps it with the object.
+func (m *MinioClient) UploadWithChecksum(ctx context.Context, objectName string, data io.Reader, fileSize int64, contentType string, userMetadata map[string]string, sha256 []byte) error {
+	metadata := make(map[string]string, len(userMetadata)+1)
+	maps.Copy(metadata, userMetadata)
+	// minio-go sends x-amz-checksum-* keys as headers of their own.
+	metadata[checksumSHA256Header] = base64.StdEncoding.EncodeToString(sha256)
+
+	return m.upload(ctx, objectName, data, fileSize, minio.PutObjectOptions{
+		ContentType:  contentType,
+		UserMetadata: metadata,
+		// A checksum of the whole object only holds for a single PUT.
+		DisableMultipart: true,
+	})
+}
+
+func (m *MinioClient) upload(ctx context.Context, objectName string, data io.Reader, fileSize int64, opts minio.PutObjectOptions) error {
+	objectName, err := m.scope(ctx, objectName)
+	if err != nil {
+		return err
+	}
+
+	opts.ServerSideEncryption, err = m.writeEncryption(objectName)
+	if err != nil {
 		return fmt.Errorf("failed to upload photo: %w", err)
 	}
 
+	if _, err := m.client.PutObject(ctx, m.bucketFor(objectName).name, objectName, data, fileSize, opts); err != nil {
+		switch {
+		case isChecksumMismatch(err):
+			err = ErrChecksumMismatch
+		case isPreconditionFailed(err):
+			err = ErrPreconditionFailed
+		}
+		return fmt.Errorf("failed to upload photo: %w", err)
+	}
+	m.recordChange(ctx, objectName)
+
 	return nil
 }
 
 func (m *MinioClient) GetPresignedUrl(ctx context.Context, objectName string, expiryHours int) (string, error) {
+	return m.GetPresignedUrlFor(ctx, objectName, time.Duration(expiryHours)*time.Hour)
+}
+
+func (m *MinioClient) GetPresignedUrlFor(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	objectName, err := m.scope(ctx, objectName)
+	if err != nil {
+		return "", err
+	}
+
 	presignedUrl, err := m.client.PresignedGetObject(
 		ctx,
-		m.bucketName,
+		m.bucketFor(objectName).name,
 		objectName,
-		time.Duration(expiryHours)*time.Hour,
+		expiry,
 		nil,
 	)
 	if err != nil {
@@ -107,27 +205,115 @@ func (m *MinioClient) GetPresignedUrl(ctx context.Context, objectName string, ex
 	return presignedUrl.String(), nil
 }
 
+// GetPresignedPutURL lets a client upload objectName straight to the
+// bucket. Such uploads bypass SSE-C, so callers must not offer them when
+// customer keys are in use.
+func (m *MinioClient) GetPresignedPutURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	objectName, err := m.scope(ctx, objectName)
+	if err != nil {
+		return "", err
+	}
+
+	presignedUrl, err := m.client.PresignedPutObject(ctx, m.bucketFor(objectName).name, objectName, expiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to get presigned upload url: %w", err)
+	}
+
+	publicURL, err := url.Parse(m.publicURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid public URL: %w", err)
+	}
+
+	presignedUrl.Scheme = publicURL.Scheme
+	presignedUrl.Host = publicURL.Host
+
+	return presignedUrl.String(), nil
+}
+
 func (m *MinioClient) GetPublicUrl(ctx context.Context, objectName string) (string, error) {
-	return fmt.Sprintf("%s/%s/%s", m.publicURL, m.bucketName, objectName), nil
+	objectName, err := m.scope(ctx, objectName)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s/%s", m.publicURL, m.bucketFor(objectName).name, objectName), nil
 }
 
 func (m *MinioClient) Delete(ctx context.Context, objectName string) error {
+	objectName, err := m.scope(ctx, objectName)
+	if err != nil {
+		return err
+	}
+
 	if err := m.client.RemoveObject(
 		ctx,
-		m.bucketName,
+		m.bucketFor(objectName).name,
 		objectName,
 		minio.RemoveObjectOptions{},
 	); err != nil {
 		return fmt.Errorf("failed to delete photo: %w", err)
 	}
+	m.recordChange(ctx, objectName)
 
 	return nil
 }
 
 func (m *MinioClient) ObjectExists(ctx context.Context, objectName string) bool {
-	if _, err := m.client.StatObject(ctx, m.bucketName, objectName, minio.GetObjectOptions{}); err != nil {
-		return false
+	exists, _ := m.Exists(ctx, objectName)
+	return exists
+}
+
+// Exists is ObjectExists for callers that must tell a missing object from
+// a failed lookup.
+func (m *MinioClient) Exists(ctx context.Context, objectName string) (bool, error) {
+	objectName, err := m.scope(ctx, objectName)
+	if err != nil {
+		return false, err
+	}
+
+	if m.PrimaryDown() {
+		_, err := m.replicaStat(ctx, objectName)
+		if errors.Is(err, ErrObjectNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	candidates, err := m.readEncryptions(objectName)
+	if err != nil {
+		return false, err
+	}
+
+	var lastErr error
+	for _, sse := range candidates {
+		_, err := m.client.StatObject(ctx, m.bucketFor(objectName).name, objectName, minio.StatObjectOptions{ServerSideEncryption: sse})
+		if err == nil {
+			return true, nil
+		}
+		if isNotFound(err) {
+			return false, nil
+		}
+		lastErr = err
+	}
+	if lastErr != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", objectName, lastErr)
+	}
+
+	return false, nil
+}
+
+// Get opens an object for reading together with its stat data.
+func (m *MinioClient) Get(ctx context.Context, objectName string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
+	objectName, err := m.scope(ctx, objectName)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, err
+	}
+
+	obj, info, err := m.read(ctx, objectName)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, err
 	}
+	info.Key = strings.TrimPrefix(info.Key, m.prefix)
 
-	return true
+	return obj, info, nil
 }
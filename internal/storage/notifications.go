@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+const (
+	appName    = "nbf-file-storage-service"
+	appVersion = "1"
+)
+
+// ListenEvents streams the notifications of every bucket for the given
+// event types until ctx is cancelled or a connection drops, which closes
+// the channel. Events missed while disconnected are not replayed. Keys are
+// reported as stored, so tenants sharing a bucket are told apart by the
+// caller.
+func (m *MinioClient) ListenEvents(ctx context.Context, events []string) <-chan notification.Info {
+	if len(m.buckets) == 1 {
+		return m.client.ListenBucketNotification(ctx, m.buckets[0].name, "", "", events)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	streams := make([]<-chan notification.Info, len(m.buckets))
+	for i, b := range m.buckets {
+		streams[i] = m.client.ListenBucketNotification(ctx, b.name, "", "", events)
+	}
+
+	return mergeNotifications(ctx, cancel, streams)
+}
+
+// mergeNotifications forwards every stream into one channel. One stream
+// ending ends them all through cancel, so the caller reconnects every
+// bucket at once.
+func mergeNotifications(ctx context.Context, cancel context.CancelFunc, streams []<-chan notification.Info) <-chan notification.Info {
+	merged := make(chan notification.Info)
+
+	var wg sync.WaitGroup
+	for _, stream := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cancel()
+
+			for info := range stream {
+				select {
+				case merged <- info:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged
+}
+
+// OwnPrincipal reports whether a change was made with this client's access
+// key. MinIO records the key a request was authenticated with, which,
+// unlike the user agent, a client cannot pick; presigned requests carry the
+// key of whoever signed them, though, so direct uploads count as the
+// service's own.
+func (m *MinioClient) OwnPrincipal(principal string) bool {
+	return principal != "" && principal == m.accessKey
+}
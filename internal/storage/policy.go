@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type bucketPolicy struct {
+	Version   string            `json:"Version"`
+	Statement []policyStatement `json:"Statement"`
+}
+
+type policyStatement struct {
+	Effect    string          `json:"Effect"`
+	Principal policyPrincipal `json:"Principal"`
+	Action    stringList      `json:"Action"`
+	Resource  stringList      `json:"Resource"`
+}
+
+type policyPrincipal struct {
+	AWS stringList `json:"AWS"`
+}
+
+// stringList accepts both the "x" and ["x"] forms S3 uses interchangeably.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*l = stringList{one}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*l = many
+
+	return nil
+}
+
+// PolicyChange describes what ReconcilePolicy did to the bucket policy.
+type PolicyChange struct {
+	Granted []string
+	Revoked []string
+}
+
+func (c PolicyChange) Changed() bool {
+	return len(c.Granted) > 0 || len(c.Revoked) > 0
+}
+
+func (c PolicyChange) String() string {
+	return fmt.Sprintf("granted public read on %v, revoked on %v", c.Granted, c.Revoked)
+}
+
+// ReconcilePolicy makes anonymous s3:GetObject available exactly on the
+// configured public paths and nowhere else. Paths are object key patterns
+// relative to the bucket and may contain S3 wildcards, e.g. "blobs/avatars/*";
+// each lands in the policy of every bucket that may hold a match. Buckets
+// set to public read are opened as a whole.
+func (m *MinioClient) ReconcilePolicy(ctx context.Context) (PolicyChange, error) {
+	var change PolicyChange
+	for _, b := range m.buckets {
+		var want []string
+		if b.publicRead {
+			want = append(want, resourceARN(b.name, "*"))
+		}
+		for _, path := range m.publicPaths {
+			if slices.Contains(m.bucketsUnder(fixedPart(path)), b) {
+				want = append(want, resourceARN(b.name, path))
+			}
+		}
+
+		bucketChange, err := m.reconcileBucketPolicy(ctx, b.name, want)
+		if err != nil {
+			return PolicyChange{}, err
+		}
+		change.Granted = append(change.Granted, bucketChange.Granted...)
+		change.Revoked = append(change.Revoked, bucketChange.Revoked...)
+	}
+
+	return change, nil
+}
+
+func (m *MinioClient) reconcileBucketPolicy(ctx context.Context, bucketName string, want []string) (PolicyChange, error) {
+	current, err := m.client.GetBucketPolicy(ctx, bucketName)
+	if err != nil {
+		return PolicyChange{}, fmt.Errorf("failed to get policy of bucket %s: %w", bucketName, err)
+	}
+
+	have, err := publicResources(current)
+	if err != nil {
+		return PolicyChange{}, fmt.Errorf("failed to parse policy of bucket %s: %w", bucketName, err)
+	}
+
+	slices.Sort(want)
+	want = slices.Compact(want)
+
+	var change PolicyChange
+	for _, resource := range want {
+		if !slices.Contains(have, resource) {
+			change.Granted = append(change.Granted, resource)
+		}
+	}
+	for _, resource := range have {
+		if !slices.Contains(want, resource) {
+			change.Revoked = append(change.Revoked, resource)
+		}
+	}
+
+	if !change.Changed() && (current == "") == (len(want) == 0) {
+		return PolicyChange{}, nil
+	}
+
+	policy := ""
+	if len(want) > 0 {
+		data, err := json.Marshal(bucketPolicy{
+			Version: "2012-10-17",
+			Statement: []policyStatement{{
+				Effect:    "Allow",
+				Principal: policyPrincipal{AWS: stringList{"*"}},
+				Action:    stringList{"s3:GetObject"},
+				Resource:  want,
+			}},
+		})
+		if err != nil {
+			return PolicyChange{}, fmt.Errorf("failed to marshal bucket policy: %w", err)
+		}
+		policy = string(data)
+	}
+
+	// An empty policy removes the bucket policy altogether.
+	if err := m.client.SetBucketPolicy(ctx, bucketName, policy); err != nil {
+		return PolicyChange{}, fmt.Errorf("failed to set policy of bucket %s: %w", bucketName, err)
+	}
+
+	return change, nil
+}
+
+// fixedPart is the part of a key pattern before its first wildcard.
+func fixedPart(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.IndexAny(path, "*?"); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+func resourceARN(bucketName string, path string) string {
+	return "arn:aws:s3:::" + bucketName + "/" + strings.TrimPrefix(path, "/")
+}
+
+// publicResources lists every resource the policy opens to anonymous reads.
+// Anything else in the policy is not managed by this service and is dropped
+// on reconciliation.
+func publicResources(policy string) ([]string, error) {
+	if policy == "" {
+		return nil, nil
+	}
+
+	var parsed bucketPolicy
+	if err := json.Unmarshal([]byte(policy), &parsed); err != nil {
+		return nil, err
+	}
+
+	var resources []string
+	for _, statement := range parsed.Statement {
+		if statement.Effect != "Allow" || !slices.Contains(statement.Principal.AWS, "*") {
+			continue
+		}
+		if !slices.Contains(statement.Action, "s3:GetObject") && !slices.Contains(statement.Action, "s3:*") {
+			continue
+		}
+		resources = append(resources, statement.Resource...)
+	}
+	slices.Sort(resources)
+
+	return slices.Compact(resources), nil
+}
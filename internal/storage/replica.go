@@ -0,0 +1,390 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// ErrReplicaUnencrypted is returned by replicas that cannot keep objects
+// encrypted when asked to.
+var ErrReplicaUnencrypted = errors.New("replica cannot encrypt objects")
+
+// Replica is a secondary copy of the buckets, kept for disaster recovery.
+// Objects keep their bucket and key there, and are encrypted at rest with
+// sse the way they are on the primary; a nil sse stores them as they are.
+// Missing objects are reported with ErrObjectNotFound.
+type Replica interface {
+	Put(ctx context.Context, bucketName string, object ReplicaObject, data io.Reader, sse encrypt.ServerSide) error
+	Get(ctx context.Context, bucketName string, objectName string, sse encrypt.ServerSide) (io.ReadSeekCloser, ReplicaObject, error)
+	Stat(ctx context.Context, bucketName string, objectName string, sse encrypt.ServerSide) (ReplicaObject, error)
+	Delete(ctx context.Context, bucketName string, objectName string) error
+	// List lists the whole bucket in key order.
+	List(ctx context.Context, bucketName string) ([]ReplicaObject, error)
+}
+
+// ReplicaObject is what a replica keeps about an object besides its data.
+type ReplicaObject struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type"`
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+	// SourceETag is the ETag the object had on the primary when it was
+	// copied, so the two sides can be compared without reading either.
+	SourceETag   string    `json:"source_etag"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// objectInfo makes a replica object look like one read from the primary.
+func (o ReplicaObject) objectInfo() minio.ObjectInfo {
+	return minio.ObjectInfo{
+		Key:          o.Key,
+		Size:         o.Size,
+		ContentType:  o.ContentType,
+		UserMetadata: o.UserMetadata,
+		ETag:         o.SourceETag,
+		LastModified: o.LastModified,
+	}
+}
+
+const sourceETagMeta = "Replica-Source-Etag"
+
+// S3Replica keeps the replica on another S3 endpoint, in buckets named
+// like the primary's. Buckets are made on first use.
+type S3Replica struct {
+	client      *minio.Client
+	provisioned sync.Map
+}
+
+func NewS3Replica(endpoint string, accessKey string, secretKey string, useSSL bool) (*S3Replica, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to replica: %w", err)
+	}
+	client.SetAppInfo(appName, appVersion)
+
+	return &S3Replica{client: client}, nil
+}
+
+func (r *S3Replica) Put(ctx context.Context, bucketName string, object ReplicaObject, data io.Reader, sse encrypt.ServerSide) error {
+	if _, ok := r.provisioned.Load(bucketName); !ok {
+		if err := provisionBucket(ctx, r.client, bucketName, false); err != nil {
+			return err
+		}
+		r.provisioned.Store(bucketName, true)
+	}
+
+	userMetadata := make(map[string]string, len(object.UserMetadata)+1)
+	for k, v := range object.UserMetadata {
+		userMetadata[k] = v
+	}
+	userMetadata[sourceETagMeta] = object.SourceETag
+
+	if _, err := r.client.PutObject(ctx, bucketName, object.Key, data, object.Size, minio.PutObjectOptions{
+		ContentType:          object.ContentType,
+		UserMetadata:         userMetadata,
+		ServerSideEncryption: sse,
+	}); err != nil {
+		return fmt.Errorf("failed to put %s to replica: %w", object.Key, err)
+	}
+
+	return nil
+}
+
+func (r *S3Replica) Get(ctx context.Context, bucketName string, objectName string, sse encrypt.ServerSide) (io.ReadSeekCloser, ReplicaObject, error) {
+	obj, err := r.client.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{ServerSideEncryption: sse})
+	if err != nil {
+		return nil, ReplicaObject{}, fmt.Errorf("failed to get %s from replica: %w", objectName, err)
+	}
+
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		if isNotFound(err) {
+			return nil, ReplicaObject{}, ErrObjectNotFound
+		}
+		return nil, ReplicaObject{}, fmt.Errorf("failed to stat %s on replica: %w", objectName, err)
+	}
+
+	return obj, s3ReplicaObject(info), nil
+}
+
+func (r *S3Replica) Stat(ctx context.Context, bucketName string, objectName string, sse encrypt.ServerSide) (ReplicaObject, error) {
+	info, err := r.client.StatObject(ctx, bucketName, objectName, minio.StatObjectOptions{ServerSideEncryption: sse})
+	if err != nil {
+		if isNotFound(err) {
+			return ReplicaObject{}, ErrObjectNotFound
+		}
+		return ReplicaObject{}, fmt.Errorf("failed to stat %s on replica: %w", objectName, err)
+	}
+
+	return s3ReplicaObject(info), nil
+}
+
+func (r *S3Replica) Delete(ctx context.Context, bucketName string, objectName string) error {
+	if err := r.client.RemoveObject(ctx, bucketName, objectName, minio.RemoveObjectOptions{}); err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete %s from replica: %w", objectName, err)
+	}
+
+	return nil
+}
+
+func (r *S3Replica) List(ctx context.Context, bucketName string) ([]ReplicaObject, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var objects []ReplicaObject
+	for object := range r.client.ListObjects(ctx, bucketName, minio.ListObjectsOptions{
+		Recursive:    true,
+		WithMetadata: true,
+	}) {
+		if object.Err != nil {
+			if isNotFound(object.Err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to list replica bucket %s: %w", bucketName, object.Err)
+		}
+		objects = append(objects, s3ReplicaObject(object))
+	}
+
+	return objects, nil
+}
+
+func s3ReplicaObject(info minio.ObjectInfo) ReplicaObject {
+	userMetadata := make(map[string]string, len(info.UserMetadata))
+	var sourceETag string
+	for k, v := range info.UserMetadata {
+		// Listings report metadata with its header prefix.
+		k = strings.TrimPrefix(k, "X-Amz-Meta-")
+		if strings.EqualFold(k, sourceETagMeta) {
+			sourceETag = v
+			continue
+		}
+		userMetadata[k] = v
+	}
+
+	return ReplicaObject{
+		Key:          info.Key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		UserMetadata: userMetadata,
+		SourceETag:   sourceETag,
+		LastModified: info.LastModified,
+	}
+}
+
+// FSReplica keeps the replica in a directory, e.g. a mounted volume on
+// other hardware: each object at {bucket}/{key}, with what else is known
+// about it in .meta/{bucket}/{key}.json. Objects are kept in the clear, so
+// it refuses to replicate encrypted ones.
+type FSReplica struct {
+	dir string
+}
+
+const fsReplicaMeta = ".meta"
+
+func NewFSReplica(dir string) (*FSReplica, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create replica directory: %w", err)
+	}
+
+	return &FSReplica{dir: dir}, nil
+}
+
+// paths maps an object to its data and metadata files, refusing keys that
+// would land outside the bucket's directory.
+func (r *FSReplica) paths(bucketName string, objectName string) (string, string, error) {
+	clean := path.Clean("/" + objectName)
+	if objectName == "" || strings.HasSuffix(objectName, "/") || clean != "/"+objectName {
+		return "", "", fmt.Errorf("key %q cannot be stored on the replica", objectName)
+	}
+
+	return filepath.Join(r.dir, bucketName, filepath.FromSlash(objectName)),
+		filepath.Join(r.dir, fsReplicaMeta, bucketName, filepath.FromSlash(objectName)+".json"),
+		nil
+}
+
+func (r *FSReplica) Put(ctx context.Context, bucketName string, object ReplicaObject, data io.Reader, sse encrypt.ServerSide) error {
+	if sse != nil {
+		return ErrReplicaUnencrypted
+	}
+
+	dataPath, metaPath, err := r.paths(bucketName, object.Key)
+	if err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("failed to marshal replica metadata: %w", err)
+	}
+
+	// Metadata goes first, so data never sits on the replica without it.
+	if err := writeFileAtomic(metaPath, bytes.NewReader(meta)); err != nil {
+		return fmt.Errorf("failed to put %s to replica: %w", object.Key, err)
+	}
+	if err := writeFileAtomic(dataPath, data); err != nil {
+		return fmt.Errorf("failed to put %s to replica: %w", object.Key, err)
+	}
+
+	return nil
+}
+
+func (r *FSReplica) Get(ctx context.Context, bucketName string, objectName string, sse encrypt.ServerSide) (io.ReadSeekCloser, ReplicaObject, error) {
+	object, err := r.Stat(ctx, bucketName, objectName, sse)
+	if err != nil {
+		return nil, ReplicaObject{}, err
+	}
+
+	dataPath, _, err := r.paths(bucketName, objectName)
+	if err != nil {
+		return nil, ReplicaObject{}, err
+	}
+	f, err := os.Open(dataPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ReplicaObject{}, ErrObjectNotFound
+		}
+		return nil, ReplicaObject{}, fmt.Errorf("failed to get %s from replica: %w", objectName, err)
+	}
+
+	return f, object, nil
+}
+
+func (r *FSReplica) Stat(ctx context.Context, bucketName string, objectName string, sse encrypt.ServerSide) (ReplicaObject, error) {
+	if sse != nil {
+		return ReplicaObject{}, ErrReplicaUnencrypted
+	}
+
+	dataPath, metaPath, err := r.paths(bucketName, objectName)
+	if err != nil {
+		return ReplicaObject{}, err
+	}
+
+	info, err := os.Stat(dataPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ReplicaObject{}, ErrObjectNotFound
+		}
+		return ReplicaObject{}, fmt.Errorf("failed to stat %s on replica: %w", objectName, err)
+	}
+
+	object := ReplicaObject{Key: objectName}
+	data, err := os.ReadFile(metaPath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &object); err != nil {
+			return ReplicaObject{}, fmt.Errorf("failed to read replica metadata of %s: %w", objectName, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return ReplicaObject{}, fmt.Errorf("failed to read replica metadata of %s: %w", objectName, err)
+	}
+	// The file is the truth about the data, whatever the metadata says.
+	object.Size = info.Size()
+	object.LastModified = info.ModTime()
+
+	return object, nil
+}
+
+func (r *FSReplica) Delete(ctx context.Context, bucketName string, objectName string) error {
+	dataPath, metaPath, err := r.paths(bucketName, objectName)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []string{dataPath, metaPath} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete %s from replica: %w", objectName, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *FSReplica) List(ctx context.Context, bucketName string) ([]ReplicaObject, error) {
+	bucketDir := filepath.Join(r.dir, bucketName)
+
+	var objects []ReplicaObject
+	err := filepath.WalkDir(bucketDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(bucketDir, p)
+		if err != nil {
+			return err
+		}
+		object, err := r.Stat(ctx, bucketName, filepath.ToSlash(rel), nil)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, object)
+
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replica bucket %s: %w", bucketName, err)
+	}
+
+	// Directory order is not key order: "a.b" sorts before "a/b".
+	slices.SortFunc(objects, func(a, b ReplicaObject) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return objects, nil
+}
+
+// writeFileAtomic writes through a temporary file, so readers never see a
+// partial object.
+func writeFileAtomic(p string, data io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(p), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), p)
+}
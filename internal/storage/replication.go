@@ -0,0 +1,504 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/tenancy"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// ErrReplicationDisabled is returned by VerifyReplica on a client without
+// a replica.
+var ErrReplicationDisabled = errors.New("replication is disabled")
+
+// replicationRoot holds the keys waiting to be copied to the replica. The
+// queue itself is never replicated.
+const replicationRoot = "_meta/replication/"
+
+// maxReportedDifferences bounds the keys a ReplicaReport lists.
+const maxReportedDifferences = 100
+
+type ReplicationOptions struct {
+	// Failover serves reads from the replica while the health check finds
+	// the primary offline.
+	Failover            bool
+	HealthCheckInterval time.Duration
+	BatchSize           int
+	// A failed copy is retried with exponential backoff between
+	// InitialBackoff and MaxBackoff, for as long as it takes.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+type replication struct {
+	replica Replica
+	opts    ReplicationOptions
+}
+
+// backoff is how long to wait before the given attempt.
+func (r *replication) backoff(attempts int) time.Duration {
+	delay := r.opts.InitialBackoff
+	for i := 1; i < attempts && delay < r.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, r.opts.MaxBackoff)
+}
+
+// replicationEntry is a key waiting to be copied to the replica.
+type replicationEntry struct {
+	Key       string    `json:"key"`
+	QueuedAt  time.Time `json:"queued_at"`
+	Attempts  int       `json:"attempts,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	RetryAt   time.Time `json:"retry_at,omitempty"`
+}
+
+// ReplicaReport sums up a comparison of the primary with its replica.
+type ReplicaReport struct {
+	Checked int
+	// Missing objects are on the primary only, Extra ones on the replica
+	// only.
+	Missing    int
+	Extra      int
+	Mismatched int
+	// Queued differences are copied over by the next Replicate pass.
+	Queued int
+	// Differences lists the first few differing objects as bucket/key and
+	// what is wrong with them.
+	Differences []string
+}
+
+func (r ReplicaReport) String() string {
+	return fmt.Sprintf("checked %d objects: %d missing on the replica, %d only on the replica, %d mismatched, %d queued for repair",
+		r.Checked, r.Missing, r.Extra, r.Mismatched, r.Queued)
+}
+
+type VerifyOptions struct {
+	// Checksum compares SHA-256 digests of both copies instead of the ETag
+	// the replica was made from, reading every object on both sides.
+	Checksum bool
+	// Repair queues every difference for replication.
+	Repair bool
+}
+
+// ReplicateTo makes every change made through the client, and the tenant
+// views made from it afterwards, reach replica too. Changes are queued in
+// the bucket as they happen and copied over by Replicate.
+func (m *MinioClient) ReplicateTo(replica Replica, opts ReplicationOptions) error {
+	if opts.Failover {
+		if _, err := m.client.HealthCheck(opts.HealthCheckInterval); err != nil {
+			return fmt.Errorf("failed to start health check: %w", err)
+		}
+	}
+
+	m.replication = &replication{
+		replica: replica,
+		opts:    opts,
+	}
+
+	return nil
+}
+
+// PrimaryDown reports whether reads are being served from the replica.
+// Reads fail over only when asked to, and never for Replicate itself.
+func (m *MinioClient) PrimaryDown() bool {
+	return m.replication != nil && m.replication.opts.Failover && m.client.IsOffline()
+}
+
+// recordChange queues a changed object for replication. The change itself
+// has happened by now and does not fail for want of an entry; one that
+// could not be queued is left for VerifyReplica to find.
+func (m *MinioClient) recordChange(ctx context.Context, objectName string) {
+	if m.replication == nil || strings.HasPrefix(objectName, replicationRoot) {
+		return
+	}
+
+	if err := m.queueReplication(ctx, objectName); err != nil {
+		metrics.ReplicationEnqueueFailures.Add(1)
+	}
+}
+
+// queueReplication adds an entry for objectName. Keys sort by queue time,
+// so listing the queue yields entries in order.
+func (m *MinioClient) queueReplication(ctx context.Context, objectName string) error {
+	entry := replicationEntry{
+		Key:      objectName,
+		QueuedAt: time.Now().UTC(),
+	}
+	key := fmt.Sprintf("%s%020d-%s.json", replicationRoot, entry.QueuedAt.UnixNano(), uuid.NewString())
+
+	return m.putEntry(ctx, key, entry)
+}
+
+// Replicate copies the queued changes to the replica and reports how many
+// objects it brought up to date. Each entry copies its object as it is now,
+// or deletes it from the replica when it is gone, so repeated and
+// reordered entries do no harm. A failed copy is retried with backoff on
+// later passes and holds nothing else back. Views over a prefix share the
+// queue of the client they were made from and leave it to that.
+func (m *MinioClient) Replicate(ctx context.Context) (int, error) {
+	if m.replication == nil || m.prefix != "" || m.PrimaryDown() {
+		return 0, nil
+	}
+	if tenancy.ID(ctx) != m.tenant {
+		return 0, ErrTenantMismatch
+	}
+
+	batchSize := max(1, m.replication.opts.BatchSize)
+	b := m.bucketFor(replicationRoot)
+
+	replicated, pending := 0, 0
+	var oldest time.Time
+	startAfter := ""
+	for {
+		objects, err := m.listBucket(ctx, b, minio.ListObjectsOptions{
+			Prefix:     replicationRoot,
+			Recursive:  true,
+			StartAfter: startAfter,
+		}, batchSize)
+		if err != nil {
+			return replicated, fmt.Errorf("failed to list replication queue: %w", err)
+		}
+
+		for _, object := range objects {
+			if err := ctx.Err(); err != nil {
+				return replicated, err
+			}
+
+			done, entry, err := m.replicateEntry(ctx, object.Key)
+			if err != nil {
+				return replicated, err
+			}
+			if done {
+				replicated++
+				continue
+			}
+			if entry != nil {
+				pending++
+				if oldest.IsZero() || entry.QueuedAt.Before(oldest) {
+					oldest = entry.QueuedAt
+				}
+			}
+		}
+
+		if len(objects) < batchSize {
+			break
+		}
+		startAfter = objects[len(objects)-1].Key
+	}
+
+	metrics.ReplicationPending.Set(int64(pending))
+	if pending == 0 {
+		metrics.ReplicationLagSeconds.Set(0)
+	} else {
+		metrics.ReplicationLagSeconds.Set(int64(time.Since(oldest).Seconds()))
+	}
+
+	return replicated, nil
+}
+
+// replicateEntry works off one queue entry. It reports whether the object
+// was brought up to date, and otherwise the entry left waiting, if any.
+func (m *MinioClient) replicateEntry(ctx context.Context, key string) (bool, *replicationEntry, error) {
+	var entry replicationEntry
+	if err := m.getEntry(ctx, key, &entry); err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			// Worked off concurrently by another instance.
+			return false, nil, nil
+		}
+		return false, nil, fmt.Errorf("failed to read replication entry: %w", err)
+	}
+
+	if time.Now().Before(entry.RetryAt) {
+		return false, &entry, nil
+	}
+
+	if err := m.replicateObject(ctx, entry.Key); err != nil {
+		metrics.ReplicationFailures.Add(1)
+
+		entry.Attempts++
+		entry.LastError = err.Error()
+		entry.RetryAt = time.Now().UTC().Add(m.replication.backoff(entry.Attempts))
+		if err := m.putEntry(ctx, key, entry); err != nil {
+			return false, nil, fmt.Errorf("failed to requeue %s: %w", entry.Key, err)
+		}
+		return false, &entry, nil
+	}
+
+	if err := m.client.RemoveObject(ctx, m.bucketFor(key).name, key, minio.RemoveObjectOptions{}); err != nil {
+		return false, nil, fmt.Errorf("failed to remove replication entry of %s: %w", entry.Key, err)
+	}
+	metrics.ReplicatedObjects.Add(1)
+
+	return true, nil, nil
+}
+
+// replicateObject makes the replica's copy of an object match the primary,
+// encrypted as it would be written to the primary now.
+func (m *MinioClient) replicateObject(ctx context.Context, objectName string) error {
+	b := m.bucketFor(objectName)
+
+	obj, info, err := m.openObject(ctx, objectName)
+	if errors.Is(err, ErrObjectNotFound) {
+		return m.replication.replica.Delete(ctx, b.name, objectName)
+	}
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	sse, err := m.writeEncryption(objectName)
+	if err != nil {
+		return err
+	}
+
+	return m.replication.replica.Put(ctx, b.name, ReplicaObject{
+		Key:          objectName,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		UserMetadata: info.UserMetadata,
+		SourceETag:   info.ETag,
+		LastModified: info.LastModified,
+	}, obj, sse)
+}
+
+// replicaGet opens the replica's copy of an object, trying every key it
+// may have been encrypted with like openObject does.
+func (m *MinioClient) replicaGet(ctx context.Context, objectName string) (io.ReadSeekCloser, ReplicaObject, error) {
+	candidates, err := m.readEncryptions(objectName)
+	if err != nil {
+		return nil, ReplicaObject{}, err
+	}
+
+	var errs []error
+	for _, sse := range candidates {
+		obj, object, err := m.replication.replica.Get(ctx, m.bucketFor(objectName).name, objectName, sse)
+		if err == nil || errors.Is(err, ErrObjectNotFound) {
+			return obj, object, err
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, ReplicaObject{}, errors.Join(errs...)
+}
+
+// replicaStat is replicaGet for stat data alone.
+func (m *MinioClient) replicaStat(ctx context.Context, objectName string) (ReplicaObject, error) {
+	candidates, err := m.readEncryptions(objectName)
+	if err != nil {
+		return ReplicaObject{}, err
+	}
+
+	var errs []error
+	for _, sse := range candidates {
+		object, err := m.replication.replica.Stat(ctx, m.bucketFor(objectName).name, objectName, sse)
+		if err == nil || errors.Is(err, ErrObjectNotFound) {
+			return object, err
+		}
+		errs = append(errs, err)
+	}
+
+	return ReplicaObject{}, errors.Join(errs...)
+}
+
+// putEntry and getEntry keep the queue on the primary, whatever the view
+// or the health check say.
+func (m *MinioClient) putEntry(ctx context.Context, key string, entry replicationEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+
+	sse, err := m.writeEncryption(key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.client.PutObject(ctx, m.bucketFor(key).name, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:          "application/json",
+		ServerSideEncryption: sse,
+	}); err != nil {
+		return fmt.Errorf("failed to put %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (m *MinioClient) getEntry(ctx context.Context, key string, entry *replicationEntry) error {
+	obj, _, err := m.openObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	if err := json.NewDecoder(obj).Decode(entry); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// read is openObject for reads that fail over to the replica while the
+// primary is down.
+func (m *MinioClient) read(ctx context.Context, objectName string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
+	if !m.PrimaryDown() {
+		return m.openObject(ctx, objectName)
+	}
+	metrics.ReplicaFailoverReads.Add(1)
+
+	obj, object, err := m.replicaGet(ctx, objectName)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, err
+	}
+
+	return obj, object.objectInfo(), nil
+}
+
+// VerifyReplica compares every object on the primary with its copy on the
+// replica. Changes still waiting in the queue show up as differences.
+// Views over a prefix cannot be verified on their own; verify the client
+// they were made from.
+func (m *MinioClient) VerifyReplica(ctx context.Context, opts VerifyOptions) (*ReplicaReport, error) {
+	if m.replication == nil {
+		return nil, ErrReplicationDisabled
+	}
+	if tenancy.ID(ctx) != m.tenant || m.prefix != "" {
+		return nil, ErrTenantMismatch
+	}
+
+	report := &ReplicaReport{}
+	for _, b := range m.buckets {
+		primary, err := m.listBucket(ctx, b, minio.ListObjectsOptions{Recursive: true}, 0)
+		if err != nil {
+			return report, err
+		}
+
+		replicaObjects, err := m.replication.replica.List(ctx, b.name)
+		if err != nil {
+			return report, err
+		}
+		onReplica := make(map[string]ReplicaObject, len(replicaObjects))
+		for _, object := range replicaObjects {
+			onReplica[object.Key] = object
+		}
+
+		for _, object := range primary {
+			if strings.HasPrefix(object.Key, replicationRoot) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			report.Checked++
+
+			replicaObject, ok := onReplica[object.Key]
+			delete(onReplica, object.Key)
+			if !ok {
+				report.Missing++
+				if err := m.noteDifference(ctx, report, b.name, object.Key, "missing on the replica", opts.Repair); err != nil {
+					return report, err
+				}
+				continue
+			}
+
+			same, err := m.sameOnReplica(ctx, object, replicaObject, opts.Checksum)
+			if err != nil {
+				return report, err
+			}
+			if !same {
+				report.Mismatched++
+				if err := m.noteDifference(ctx, report, b.name, object.Key, "differs on the replica", opts.Repair); err != nil {
+					return report, err
+				}
+			}
+		}
+
+		extra := make([]string, 0, len(onReplica))
+		for key := range onReplica {
+			extra = append(extra, key)
+		}
+		slices.Sort(extra)
+		for _, key := range extra {
+			report.Extra++
+			if err := m.noteDifference(ctx, report, b.name, key, "only on the replica", opts.Repair); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (m *MinioClient) noteDifference(ctx context.Context, report *ReplicaReport, bucketName string, objectName string, reason string, repair bool) error {
+	if len(report.Differences) < maxReportedDifferences {
+		report.Differences = append(report.Differences, fmt.Sprintf("%s/%s: %s", bucketName, objectName, reason))
+	}
+
+	if !repair {
+		return nil
+	}
+	if err := m.queueReplication(ctx, objectName); err != nil {
+		return fmt.Errorf("failed to queue %s for repair: %w", objectName, err)
+	}
+	report.Queued++
+
+	return nil
+}
+
+// sameOnReplica compares an object with its copy, by the ETag the copy was
+// made from or, with checksum set, by content.
+func (m *MinioClient) sameOnReplica(ctx context.Context, object minio.ObjectInfo, replicaObject ReplicaObject, checksum bool) (bool, error) {
+	if object.Size != replicaObject.Size {
+		return false, nil
+	}
+	if !checksum {
+		return object.ETag == replicaObject.SourceETag, nil
+	}
+
+	obj, _, err := m.openObject(ctx, object.Key)
+	if err != nil {
+		return false, err
+	}
+	defer obj.Close()
+	primarySum, err := sha256Sum(obj)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", object.Key, err)
+	}
+
+	copied, _, err := m.replicaGet(ctx, object.Key)
+	if errors.Is(err, ErrObjectNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer copied.Close()
+	replicaSum, err := sha256Sum(copied)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s from replica: %w", object.Key, err)
+	}
+
+	return bytes.Equal(primarySum, replicaSum), nil
+}
+
+func sha256Sum(r io.Reader) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
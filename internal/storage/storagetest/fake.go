@@ -0,0 +1,508 @@
+package storagetest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+)
+
+// NewClient serves a FakeS3 for the length of the test and returns a
+// storage client for bucketName on it.
+func NewClient(t testing.TB, bucketName string) (*storage.MinioClient, *FakeS3) {
+	return newClient(t, bucketName, false)
+}
+
+// NewVersionedClient is NewClient with bucket versioning turned on.
+func NewVersionedClient(t testing.TB, bucketName string) (*storage.MinioClient, *FakeS3) {
+	return newClient(t, bucketName, true)
+}
+
+func newClient(t testing.TB, bucketName string, versioning bool) (*storage.MinioClient, *FakeS3) {
+	t.Helper()
+
+	fake := NewFakeS3()
+	return connect(t, fake, bucketName, versioning), fake
+}
+
+// Connect returns a storage client for bucketName on an existing fake, so
+// clients for several buckets can share it.
+func Connect(t testing.TB, fake *FakeS3, bucketName string) *storage.MinioClient {
+	return connect(t, fake, bucketName, false)
+}
+
+func connect(t testing.TB, fake *FakeS3, bucketName string, versioning bool) *storage.MinioClient {
+	t.Helper()
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := storage.NewMinioClient(strings.TrimPrefix(server.URL, "http://"), "", "access", "secret", false, bucketName, nil, storage.Encryption{}, versioning, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// FakeS3 serves enough of the S3 API (buckets, single-part puts with
+// If-Match and If-None-Match, ranged gets, heads, deletes, v2 listings and,
+// once a bucket has it turned on, versioning) to stand in for MinIO in
+// tests. Objects live in memory and are never encrypted; requests are not
+// authenticated.
+type FakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]*fakeObject
+	// versions holds every version of each key of a versioned bucket,
+	// delete markers included, oldest first.
+	versions    map[string]map[string][]*fakeObject
+	nextVersion int
+}
+
+type fakeObject struct {
+	data         []byte
+	etag         string
+	contentType  string
+	metadata     http.Header
+	lastModified time.Time
+	versionID    string
+	deleteMarker bool
+}
+
+func NewFakeS3() *FakeS3 {
+	return &FakeS3{
+		buckets:  make(map[string]map[string]*fakeObject),
+		versions: make(map[string]map[string][]*fakeObject),
+	}
+}
+
+// Versions counts the versions kept of a key, delete markers included.
+func (f *FakeS3) Versions(bucketName string, key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.versions[bucketName][key])
+}
+
+// Keys lists the objects in a bucket, in key order.
+func (f *FakeS3) Keys(bucketName string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.buckets[bucketName]))
+	for key := range f.buckets[bucketName] {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+// Age backdates an object, so tests can put it past a grace period.
+func (f *FakeS3) Age(bucketName string, key string, age time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if object, ok := f.buckets[bucketName][key]; ok {
+		object.lastModified = object.lastModified.Add(-age)
+	}
+}
+
+func (f *FakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if key == "" {
+		f.serveBucket(w, r, bucketName)
+		return
+	}
+
+	objects, ok := f.buckets[bucketName]
+	if !ok {
+		fakeError(w, http.StatusNotFound, "NoSuchBucket", bucketName, key)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		f.putObject(w, r, objects, bucketName, key)
+	case http.MethodGet, http.MethodHead:
+		object, ok := objects[key]
+		if !ok {
+			fakeError(w, http.StatusNotFound, "NoSuchKey", bucketName, key)
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && strings.Trim(match, `"`) != object.etag {
+			fakeError(w, http.StatusPreconditionFailed, "PreconditionFailed", bucketName, key)
+			return
+		}
+		serveObject(w, r, object)
+	case http.MethodDelete:
+		f.deleteObject(r, objects, bucketName, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		fakeError(w, http.StatusNotImplemented, "NotImplemented", bucketName, key)
+	}
+}
+
+func (f *FakeS3) serveBucket(w http.ResponseWriter, r *http.Request, bucketName string) {
+	query := r.URL.Query()
+	objects, exists := f.buckets[bucketName]
+
+	switch {
+	case r.Method == http.MethodPut && len(query) == 0:
+		if !exists {
+			f.buckets[bucketName] = make(map[string]*fakeObject)
+		}
+		w.WriteHeader(http.StatusOK)
+	case !exists:
+		fakeError(w, http.StatusNotFound, "NoSuchBucket", bucketName, "")
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && query.Has("location"):
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"LocationConstraint"`
+		}{})
+	case r.Method == http.MethodGet && query.Has("versioning"):
+		status := ""
+		if f.versions[bucketName] != nil {
+			status = "Enabled"
+		}
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"VersioningConfiguration"`
+			Status  string   `xml:",omitempty"`
+		}{Status: status})
+	case r.Method == http.MethodPut && query.Has("versioning"):
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("<Status>Enabled</Status>")) && f.versions[bucketName] == nil {
+			f.versions[bucketName] = make(map[string][]*fakeObject)
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && query.Has("versions"):
+		listVersions(w, query, bucketName, f.versions[bucketName])
+	case r.Method == http.MethodGet && query.Get("list-type") == "2":
+		listObjects(w, query, bucketName, objects)
+	default:
+		fakeError(w, http.StatusNotImplemented, "NotImplemented", bucketName, "")
+	}
+}
+
+func (f *FakeS3) putObject(w http.ResponseWriter, r *http.Request, objects map[string]*fakeObject, bucketName string, key string) {
+	existing, exists := objects[key]
+	if match := r.Header.Get("If-Match"); match != "" && (!exists || strings.Trim(match, `"`) != existing.etag) {
+		fakeError(w, http.StatusPreconditionFailed, "PreconditionFailed", bucketName, key)
+		return
+	}
+	if r.Header.Get("If-None-Match") == "*" && exists {
+		fakeError(w, http.StatusPreconditionFailed, "PreconditionFailed", bucketName, key)
+		return
+	}
+
+	data, err := readBody(r)
+	if err != nil {
+		fakeError(w, http.StatusBadRequest, "IncompleteBody", bucketName, key)
+		return
+	}
+
+	sum := md5.Sum(data)
+	object := &fakeObject{
+		data:         data,
+		etag:         hex.EncodeToString(sum[:]),
+		contentType:  r.Header.Get("Content-Type"),
+		metadata:     make(http.Header),
+		lastModified: time.Now().UTC().Truncate(time.Second),
+	}
+	for name, values := range r.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+			object.metadata[name] = values
+		}
+	}
+	objects[key] = object
+
+	if versions := f.versions[bucketName]; versions != nil {
+		object.versionID = f.newVersionID()
+		versions[key] = append(versions[key], object)
+		w.Header().Set("X-Amz-Version-Id", object.versionID)
+	}
+
+	w.Header().Set("ETag", `"`+object.etag+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteObject removes one version when asked for it by ID. Otherwise a
+// versioned bucket hides the key behind a delete marker, and any other
+// drops it.
+func (f *FakeS3) deleteObject(r *http.Request, objects map[string]*fakeObject, bucketName string, key string) {
+	versions := f.versions[bucketName]
+	if versions == nil {
+		delete(objects, key)
+		return
+	}
+
+	versionID := r.URL.Query().Get("versionId")
+	if versionID == "" {
+		versions[key] = append(versions[key], &fakeObject{
+			versionID:    f.newVersionID(),
+			deleteMarker: true,
+			lastModified: time.Now().UTC().Truncate(time.Second),
+		})
+		delete(objects, key)
+		return
+	}
+
+	versions[key] = slices.DeleteFunc(versions[key], func(version *fakeObject) bool {
+		return version.versionID == versionID
+	})
+	if len(versions[key]) == 0 {
+		delete(versions, key)
+	}
+
+	// The newest remaining version, unless it is a marker, is current.
+	if n := len(versions[key]); n > 0 && !versions[key][n-1].deleteMarker {
+		objects[key] = versions[key][n-1]
+	} else {
+		delete(objects, key)
+	}
+}
+
+func (f *FakeS3) newVersionID() string {
+	f.nextVersion++
+	return fmt.Sprintf("v%d", f.nextVersion)
+}
+
+// readBody undoes the aws-chunked encoding minio-go uses for uploads that
+// carry trailing checksums.
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	body := bufio.NewReader(r.Body)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			// Trailers follow; nothing here checks them.
+			_, _ = io.Copy(io.Discard, body)
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, body, size); err != nil {
+			return nil, err
+		}
+		if _, err := body.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func serveObject(w http.ResponseWriter, r *http.Request, object *fakeObject) {
+	for name, values := range object.metadata {
+		w.Header()[name] = values
+	}
+	w.Header().Set("ETag", `"`+object.etag+`"`)
+	w.Header().Set("Last-Modified", object.lastModified.Format(http.TimeFormat))
+	if object.contentType != "" {
+		w.Header().Set("Content-Type", object.contentType)
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	data := object.data
+	status := http.StatusOK
+	if spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+		startText, endText, _ := strings.Cut(spec, "-")
+		start, _ := strconv.Atoi(startText)
+		end := len(data) - 1
+		if endText != "" {
+			end, _ = strconv.Atoi(endText)
+		}
+		end = min(end, len(data)-1)
+		if start > end {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(data)))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+func listObjects(w http.ResponseWriter, query map[string][]string, bucketName string, objects map[string]*fakeObject) {
+	get := func(name string) string {
+		if values := query[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	prefix := get("prefix")
+	after := max(get("start-after"), get("continuation-token"))
+	maxKeys, err := strconv.Atoi(get("max-keys"))
+	if err != nil || maxKeys <= 0 {
+		maxKeys = 1000
+	}
+
+	var keys []string
+	for key := range objects {
+		if strings.HasPrefix(key, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+		StorageClass string
+	}
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Name                  string
+		Prefix                string
+		KeyCount              int
+		MaxKeys               int
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+		Contents              []content
+	}{
+		Name:    bucketName,
+		Prefix:  prefix,
+		MaxKeys: maxKeys,
+	}
+	if len(keys) > maxKeys {
+		keys = keys[:maxKeys]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		object := objects[key]
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			LastModified: object.lastModified.Format("2006-01-02T15:04:05.000Z"),
+			ETag:         `"` + object.etag + `"`,
+			Size:         len(object.data),
+			StorageClass: "STANDARD",
+		})
+	}
+	result.KeyCount = len(result.Contents)
+
+	writeXML(w, result)
+}
+
+// listVersions lists every version under the prefix in one page, each key's
+// newest first.
+func listVersions(w http.ResponseWriter, query map[string][]string, bucketName string, versions map[string][]*fakeObject) {
+	prefix := ""
+	if values := query["prefix"]; len(values) > 0 {
+		prefix = values[0]
+	}
+
+	var keys []string
+	for key := range versions {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	type entry struct {
+		XMLName      xml.Name
+		Key          string
+		VersionID    string `xml:"VersionId"`
+		IsLatest     bool
+		LastModified string
+		ETag         string `xml:",omitempty"`
+		Size         int
+		StorageClass string `xml:",omitempty"`
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListVersionsResult"`
+		Name        string
+		Prefix      string
+		MaxKeys     int
+		IsTruncated bool
+		Entries     []entry
+	}{
+		Name:    bucketName,
+		Prefix:  prefix,
+		MaxKeys: 1000,
+	}
+	for _, key := range keys {
+		for i, version := range slices.Backward(versions[key]) {
+			e := entry{
+				XMLName:      xml.Name{Local: "Version"},
+				Key:          key,
+				VersionID:    version.versionID,
+				IsLatest:     i == len(versions[key])-1,
+				LastModified: version.lastModified.Format("2006-01-02T15:04:05.000Z"),
+				ETag:         `"` + version.etag + `"`,
+				Size:         len(version.data),
+				StorageClass: "STANDARD",
+			}
+			if version.deleteMarker {
+				e.XMLName.Local = "DeleteMarker"
+				e.ETag, e.StorageClass = "", ""
+			}
+			result.Entries = append(result.Entries, e)
+		}
+	}
+
+	writeXML(w, result)
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write(append([]byte(xml.Header), data...))
+}
+
+func fakeError(w http.ResponseWriter, status int, code string, bucketName string, key string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+
+	data, _ := xml.Marshal(struct {
+		XMLName    xml.Name `xml:"Error"`
+		Code       string
+		Message    string
+		BucketName string
+		Key        string
+		RequestID  string `xml:"RequestId"`
+	}{Code: code, Message: code, BucketName: bucketName, Key: key, RequestID: "fake"})
+	_, _ = w.Write(append([]byte(xml.Header), data...))
+}
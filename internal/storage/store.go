@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// ObjectStore is the object-level part of the storage API, the surface
+// decorators such as EnvelopeStore wrap.
+type ObjectStore interface {
+	UploadWithMetadata(ctx context.Context, objectName string, data io.Reader, fileSize int64, contentType string, userMetadata map[string]string) error
+	Get(ctx context.Context, objectName string) (io.ReadSeekCloser, minio.ObjectInfo, error)
+	GetPresignedUrlFor(ctx context.Context, objectName string, expiry time.Duration) (string, error)
+	ObjectExists(ctx context.Context, objectName string) bool
+	Delete(ctx context.Context, objectName string) error
+}
+
+var _ ObjectStore = (*MinioClient)(nil)
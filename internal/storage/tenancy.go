@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/tenancy"
+	"github.com/minio/minio-go/v7"
+)
+
+// ErrTenantMismatch is returned when storage scoped to one tenant is used
+// on behalf of another, or given a key outside the tenant's namespace.
+var ErrTenantMismatch = errors.New("object belongs to another tenant")
+
+// ForTenant returns a view of the client scoped to a tenant. Keys given to
+// the view are taken relative to prefix, which is empty for a tenant with
+// a bucket of its own, and come back from listings the same way. Every
+// call must come with a context acting for the tenant.
+func (m *MinioClient) ForTenant(tenantID string, prefix string) *MinioClient {
+	scoped := *m
+	scoped.tenant = tenantID
+	scoped.prefix = prefix
+	scoped.reserved = ""
+
+	return &scoped
+}
+
+// ReserveForTenants keeps the keys under root for tenant views: the client
+// itself refuses them from then on.
+func (m *MinioClient) ReserveForTenants(root string) {
+	m.reserved = root
+}
+
+// scope checks that ctx acts for the client's tenant and turns a key
+// relative to the tenant into the stored one.
+func (m *MinioClient) scope(ctx context.Context, objectName string) (string, error) {
+	objectName, err := m.scopeAny(ctx, objectName)
+	if err != nil {
+		return "", err
+	}
+	if m.reserved != "" && (strings.HasPrefix(objectName, m.reserved) || strings.HasPrefix(path.Clean(objectName)+"/", m.reserved)) {
+		return "", ErrTenantMismatch
+	}
+
+	return objectName, nil
+}
+
+// scopeAny is scope without the reserved root, for maintenance walking
+// the whole bucket on behalf of every tenant at once.
+func (m *MinioClient) scopeAny(ctx context.Context, objectName string) (string, error) {
+	if tenancy.ID(ctx) != m.tenant {
+		return "", ErrTenantMismatch
+	}
+	if m.prefix == "" {
+		return objectName, nil
+	}
+
+	// A key climbing out of the prefix with ".." would reach another
+	// tenant on backends that clean paths.
+	key := m.prefix + objectName
+	root := strings.TrimSuffix(m.prefix, "/")
+	if clean := path.Clean(key); clean != root && !strings.HasPrefix(clean, root+"/") {
+		return "", ErrTenantMismatch
+	}
+
+	return key, nil
+}
+
+// unscope turns listed keys back into keys relative to the tenant.
+func (m *MinioClient) unscope(objects []minio.ObjectInfo) []minio.ObjectInfo {
+	for i := range objects {
+		objects[i].Key = strings.TrimPrefix(objects[i].Key, m.prefix)
+	}
+
+	return objects
+}
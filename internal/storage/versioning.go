@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Versioning reports whether the bucket keeps old versions. Delete then
+// only hides an object behind a delete marker, and the versions it leaves
+// are expired by lifecycle rules; Purge removes an object for good.
+func (m *MinioClient) Versioning() bool {
+	return m.versioning
+}
+
+// enableVersioning turns on bucket versioning. Once on, it can only be
+// suspended, never fully turned off.
+func enableVersioning(ctx context.Context, client *minio.Client, bucketName string) error {
+	config, err := client.GetBucketVersioning(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket versioning: %w", err)
+	}
+	if config.Enabled() {
+		return nil
+	}
+
+	if err := client.EnableVersioning(ctx, bucketName); err != nil {
+		return fmt.Errorf("failed to enable bucket versioning: %w", err)
+	}
+
+	return nil
+}
+
+// Purge removes every version of an object along with its delete markers.
+// Without versioning it is Delete.
+func (m *MinioClient) Purge(ctx context.Context, objectName string) error {
+	if !m.versioning {
+		return m.Delete(ctx, objectName)
+	}
+
+	objectName, err := m.scope(ctx, objectName)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.purge(ctx, objectName, true)
+	return err
+}
+
+// PurgePrefix removes every version of every object under prefix,
+// including objects already deleted, and reports how many versions went.
+func (m *MinioClient) PurgePrefix(ctx context.Context, prefix string) (int, error) {
+	if !m.versioning {
+		objects, err := m.List(ctx, prefix)
+		if err != nil {
+			return 0, err
+		}
+		for i, object := range objects {
+			if err := m.Delete(ctx, object.Key); err != nil {
+				return i, err
+			}
+		}
+		return len(objects), nil
+	}
+
+	prefix, err := m.scope(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.purge(ctx, prefix, false)
+}
+
+// ExpireNoncurrent removes the old versions under prefix that were
+// replaced or deleted more than age ago, the way a noncurrent version
+// expiry rule would, and reports how many went.
+func (m *MinioClient) ExpireNoncurrent(ctx context.Context, prefix string, age time.Duration) (int, error) {
+	if !m.versioning {
+		return 0, nil
+	}
+
+	prefix, err := m.scope(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-age)
+	expired := 0
+	for _, b := range m.bucketsUnder(prefix) {
+		n, err := m.expireNoncurrent(ctx, b.name, prefix, cutoff)
+		expired += n
+		if err != nil {
+			return expired, err
+		}
+	}
+
+	return expired, nil
+}
+
+func (m *MinioClient) expireNoncurrent(ctx context.Context, bucketName string, prefix string, cutoff time.Time) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	expired := 0
+	var key string
+	var replacedAt time.Time
+	for version := range m.client.ListObjects(ctx, bucketName, minio.ListObjectsOptions{
+		Prefix:       prefix,
+		Recursive:    true,
+		WithVersions: true,
+	}) {
+		if version.Err != nil {
+			return expired, fmt.Errorf("failed to list versions of %s: %w", prefix, version.Err)
+		}
+
+		// Versions of a key come newest first, so each one stopped being
+		// current when the one listed before it was written.
+		if version.Key != key || version.IsLatest {
+			key, replacedAt = version.Key, version.LastModified
+			continue
+		}
+		noncurrentSince := replacedAt
+		replacedAt = version.LastModified
+		if noncurrentSince.After(cutoff) {
+			continue
+		}
+
+		if err := m.client.RemoveObject(ctx, bucketName, version.Key, minio.RemoveObjectOptions{
+			VersionID: version.VersionID,
+		}); err != nil {
+			return expired, fmt.Errorf("failed to expire %s: %w", version.Key, err)
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// purge removes every version under prefix, or of the object named prefix
+// if exact is set, from every bucket that may hold them.
+func (m *MinioClient) purge(ctx context.Context, prefix string, exact bool) (int, error) {
+	buckets := []*bucket{m.bucketFor(prefix)}
+	if !exact {
+		buckets = m.bucketsUnder(prefix)
+	}
+
+	purged := 0
+	for _, b := range buckets {
+		n, err := m.purgeBucket(ctx, b.name, prefix, exact)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+
+	return purged, nil
+}
+
+func (m *MinioClient) purgeBucket(ctx context.Context, bucketName string, prefix string, exact bool) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	purged := 0
+	purgedKey := ""
+	for version := range m.client.ListObjects(ctx, bucketName, minio.ListObjectsOptions{
+		Prefix:       prefix,
+		Recursive:    true,
+		WithVersions: true,
+	}) {
+		if version.Err != nil {
+			return purged, fmt.Errorf("failed to list versions of %s: %w", prefix, version.Err)
+		}
+		if exact && version.Key != prefix {
+			continue
+		}
+
+		if err := m.client.RemoveObject(ctx, bucketName, version.Key, minio.RemoveObjectOptions{
+			VersionID: version.VersionID,
+		}); err != nil {
+			return purged, fmt.Errorf("failed to purge %s: %w", version.Key, err)
+		}
+		purged++
+
+		// Versions of a key come together, so this queues each key once.
+		if version.Key != purgedKey {
+			purgedKey = version.Key
+			m.recordChange(ctx, purgedKey)
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,17 @@
+package tenancy
+
+import "context"
+
+type tenantKey struct{}
+
+// WithID marks ctx as acting for a tenant. Storage scoped to a tenant
+// refuses to work under any other.
+func WithID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// ID is the tenant ctx acts for, empty for the deployment's own product.
+func ID(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID
+}
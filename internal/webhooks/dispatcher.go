@@ -0,0 +1,473 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/metrics"
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidURL           = errors.New("webhook url must be an absolute http or https url")
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("dead webhook delivery not found")
+)
+
+const (
+	subscriptionsRoot = "_meta/webhooks/subscriptions/"
+	// Queued deliveries are keyed by their next attempt, so listing the
+	// queue yields the due ones first.
+	queueRoot = "_meta/webhooks/queue/"
+	deadRoot  = "_meta/webhooks/dead/"
+)
+
+// leaseMargin is how long a lease outlives the send it covers, for the
+// bookkeeping around it.
+const leaseMargin = time.Minute
+
+func subscriptionKey(id string) string {
+	return subscriptionsRoot + id + ".json"
+}
+
+func queueKey(delivery *models.WebhookDelivery) string {
+	return fmt.Sprintf("%s%020d-%s.json", queueRoot, delivery.NextAttemptAt.UnixNano(), delivery.ID)
+}
+
+func deadKey(id string) string {
+	return deadRoot + id + ".json"
+}
+
+// RetryPolicy spaces out attempts exponentially, from InitialBackoff up to
+// MaxBackoff, and gives up after MaxAttempts.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff is the wait after the given failed attempt, jittered into its
+// upper half so failed deliveries do not retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.MaxBackoff
+	if shift := attempt - 1; shift < 32 && p.InitialBackoff<<shift < p.MaxBackoff {
+		wait = p.InitialBackoff << shift
+	}
+
+	return wait/2 + mrand.N(wait/2+1)
+}
+
+// Dispatcher delivers events to webhook subscriptions. Deliveries are
+// queued in the bucket and survive restarts; each is retried per the
+// RetryPolicy and then moved to the dead-letter store, from which Replay
+// requeues it. Delivery is at least once.
+type Dispatcher struct {
+	store        *storage.MinioClient
+	client       *http.Client
+	slots        chan struct{}
+	pollInterval time.Duration
+	retry        RetryPolicy
+
+	queued chan struct{}
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+func NewDispatcher(store *storage.MinioClient, workers int, timeout time.Duration, pollInterval time.Duration, retry RetryPolicy) *Dispatcher {
+	return &Dispatcher{
+		store: store,
+		client: &http.Client{
+			Timeout: timeout,
+			// A redirect is a misconfigured endpoint, not somewhere to
+			// send signed payloads.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		slots:        make(chan struct{}, max(1, workers)),
+		pollInterval: pollInterval,
+		retry:        retry,
+		queued:       make(chan struct{}, 1),
+		inFlight:     make(map[string]bool),
+	}
+}
+
+// Subscribe registers an endpoint and returns it with its signing secret,
+// which is only ever handed out here.
+func (d *Dispatcher) Subscribe(ctx context.Context, endpoint string, eventTypes []string) (*models.WebhookSubscription, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidURL
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	subscription := &models.WebhookSubscription{
+		ID:        uuid.New().String(),
+		URL:       endpoint,
+		Secret:    base64.RawURLEncoding.EncodeToString(secret),
+		Events:    eventTypes,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := d.store.PutJSON(ctx, subscriptionKey(subscription.ID), subscription); err != nil {
+		return nil, fmt.Errorf("failed to save webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// Unsubscribe removes a subscription. Its queued deliveries are dropped as
+// the workers reach them.
+func (d *Dispatcher) Unsubscribe(ctx context.Context, id string) error {
+	exists, err := d.store.Exists(ctx, subscriptionKey(id))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrSubscriptionNotFound
+	}
+
+	if err := d.store.Delete(ctx, subscriptionKey(id)); err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) Subscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	objects, err := d.store.List(ctx, subscriptionsRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	subscriptions := make([]models.WebhookSubscription, 0, len(objects))
+	for _, object := range objects {
+		var subscription models.WebhookSubscription
+		if err := d.store.GetJSON(ctx, object.Key, &subscription); err != nil {
+			if errors.Is(err, storage.ErrObjectNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to load webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+// Enqueue queues an event for every interested subscription. It is fed
+// from the outbox, which may hand over the same event again after a
+// failure; deliveries are named after the event and subscription, so a
+// repeat finds them already queued.
+func (d *Dispatcher) Enqueue(ctx context.Context, eventID string, eventType string, occurredAt time.Time, data json.RawMessage) error {
+	subscriptions, err := d.Subscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(struct {
+		ID         string          `json:"id"`
+		Type       string          `json:"type"`
+		OccurredAt time.Time       `json:"occurred_at"`
+		Data       json.RawMessage `json:"data"`
+	}{eventID, eventType, occurredAt, data})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Wants(eventType) {
+			continue
+		}
+
+		delivery := &models.WebhookDelivery{
+			ID:             uuid.NewSHA1(uuid.NameSpaceURL, []byte(eventID+"/"+subscription.ID)).String(),
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Body:           body,
+			NextAttemptAt:  occurredAt.UTC(),
+			CreatedAt:      time.Now().UTC(),
+		}
+		err := d.store.PutJSONIf(ctx, queueKey(delivery), delivery, "")
+		if err != nil && !errors.Is(err, storage.ErrPreconditionFailed) {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+
+	select {
+	case d.queued <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// DeadLetters lists deliveries that ran out of attempts.
+func (d *Dispatcher) DeadLetters(ctx context.Context) ([]models.WebhookDelivery, error) {
+	objects, err := d.store.List(ctx, deadRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(objects))
+	for _, object := range objects {
+		var delivery models.WebhookDelivery
+		if err := d.store.GetJSON(ctx, object.Key, &delivery); err != nil {
+			if errors.Is(err, storage.ErrObjectNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to load dead webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Replay moves a dead delivery back into the queue with a fresh set of
+// attempts.
+func (d *Dispatcher) Replay(ctx context.Context, deliveryID string) error {
+	var delivery models.WebhookDelivery
+	err := d.store.GetJSON(ctx, deadKey(deliveryID), &delivery)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return ErrDeliveryNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load dead webhook delivery: %w", err)
+	}
+
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now().UTC()
+	if err := d.store.PutJSON(ctx, queueKey(&delivery), &delivery); err != nil {
+		return fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+	if err := d.store.Delete(ctx, deadKey(deliveryID)); err != nil {
+		return fmt.Errorf("failed to remove dead webhook delivery: %w", err)
+	}
+
+	select {
+	case d.queued <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Run works the queue until ctx is cancelled, with at most the configured
+// number of deliveries in flight.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.dispatchDue(ctx); err != nil && ctx.Err() == nil {
+			metrics.WebhookFailures.Add(1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-d.queued:
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchDue(ctx context.Context) error {
+	now := time.Now()
+
+	startAfter := ""
+	for {
+		objects, err := d.store.ListPage(ctx, queueRoot, startAfter, 100)
+		if err != nil {
+			return fmt.Errorf("failed to list webhook queue: %w", err)
+		}
+
+		for _, object := range objects {
+			due, err := strconv.ParseInt(strings.SplitN(strings.TrimPrefix(object.Key, queueRoot), "-", 2)[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			if due > now.UnixNano() {
+				return nil
+			}
+
+			if !d.claim(object.Key) {
+				continue
+			}
+
+			select {
+			case d.slots <- struct{}{}:
+			case <-ctx.Done():
+				d.release(object.Key)
+				return ctx.Err()
+			}
+
+			go func(key string) {
+				defer func() {
+					<-d.slots
+					d.release(key)
+				}()
+
+				if err := d.deliver(ctx, key); err != nil {
+					metrics.WebhookFailures.Add(1)
+				}
+			}(object.Key)
+		}
+
+		if len(objects) < 100 {
+			return nil
+		}
+		startAfter = objects[len(objects)-1].Key
+	}
+}
+
+// claim keeps a delivery from being picked up twice by this process while
+// it is in flight; the lease taken in deliver keeps other instances off it.
+func (d *Dispatcher) claim(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.inFlight[key] {
+		return false
+	}
+	d.inFlight[key] = true
+
+	return true
+}
+
+func (d *Dispatcher) release(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.inFlight, key)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, key string) error {
+	var delivery models.WebhookDelivery
+	etag, err := d.store.GetJSONVersion(ctx, key, &delivery)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load webhook delivery: %w", err)
+	}
+
+	// Lease the delivery before sending it; an instance that lost the race
+	// or finds a live lease leaves it to the holder.
+	now := time.Now().UTC()
+	if delivery.LeasedUntil != nil && now.Before(*delivery.LeasedUntil) {
+		return nil
+	}
+	leasedUntil := now.Add(d.client.Timeout + leaseMargin)
+	delivery.LeasedUntil = &leasedUntil
+	err = d.store.PutJSONIf(ctx, key, &delivery, etag)
+	if errors.Is(err, storage.ErrPreconditionFailed) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lease webhook delivery: %w", err)
+	}
+	delivery.LeasedUntil = nil
+
+	var subscription models.WebhookSubscription
+	err = d.store.GetJSON(ctx, subscriptionKey(delivery.SubscriptionID), &subscription)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return d.store.Delete(ctx, key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load webhook subscription: %w", err)
+	}
+
+	sendErr := d.send(ctx, &subscription, &delivery)
+	if sendErr == nil {
+		metrics.WebhookDeliveries.Add(1)
+		return d.store.Delete(ctx, key)
+	}
+	if ctx.Err() != nil {
+		// Shutting down; the attempt does not count.
+		return ctx.Err()
+	}
+
+	delivery.Attempts++
+	delivery.LastError = sendErr.Error()
+
+	if delivery.Attempts >= d.retry.MaxAttempts {
+		metrics.WebhookDeadLetters.Add(1)
+		if err := d.store.PutJSON(ctx, deadKey(delivery.ID), &delivery); err != nil {
+			return fmt.Errorf("failed to dead-letter webhook delivery: %w", err)
+		}
+		return d.store.Delete(ctx, key)
+	}
+
+	delivery.NextAttemptAt = time.Now().UTC().Add(d.retry.backoff(delivery.Attempts))
+	if err := d.store.PutJSON(ctx, queueKey(&delivery), &delivery); err != nil {
+		return fmt.Errorf("failed to reschedule webhook delivery: %w", err)
+	}
+
+	return d.store.Delete(ctx, key)
+}
+
+func (d *Dispatcher) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) error {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nbf-file-storage-webhooks/1")
+	req.Header.Set("Webhook-Id", delivery.EventID)
+	req.Header.Set("Webhook-Delivery", delivery.ID)
+	req.Header.Set("Webhook-Event", delivery.EventType)
+	req.Header.Set("Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("Webhook-Signature", "sha256="+Sign(subscription.Secret, timestamp, delivery.Body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Sign is the signature receivers check: the hex HMAC-SHA256, under the
+// subscription secret, of the Webhook-Timestamp header, a dot and the raw
+// body. Covering the timestamp lets receivers reject replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
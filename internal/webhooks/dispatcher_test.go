@@ -0,0 +1,225 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/acyushka/nbf-file-storage-service/internal/models"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage"
+	"github.com/acyushka/nbf-file-storage-service/internal/storage/storagetest"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Second, MaxBackoff: time.Minute}
+
+	for attempt, want := range map[int]time.Duration{
+		1:   time.Second,
+		2:   2 * time.Second,
+		4:   8 * time.Second,
+		6:   32 * time.Second,
+		7:   time.Minute,
+		40:  time.Minute,
+		100: time.Minute,
+	} {
+		for range 100 {
+			got := p.backoff(attempt)
+			if got < want/2 || got > want {
+				t.Fatalf("attempt %d waited %s, want between %s and %s", attempt, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// The hex HMAC-SHA256 of `1700000000.{"id":"1"}` under "secret".
+	const want = "086f6aff7bd084c98679825129c5a64dbad88c760016d6d2c0fb123f27951d54"
+
+	if got := Sign("secret", 1700000000, []byte(`{"id":"1"}`)); got != want {
+		t.Fatalf("signature %s, want %s", got, want)
+	}
+	if Sign("secret", 1700000001, []byte(`{"id":"1"}`)) == want {
+		t.Fatal("signature does not cover the timestamp")
+	}
+}
+
+func newTestDispatcher(t *testing.T) (*Dispatcher, *storage.MinioClient) {
+	t.Helper()
+
+	store, _ := storagetest.NewClient(t, "test")
+
+	return NewDispatcher(store, 1, 5*time.Second, time.Minute, RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Hour,
+	}), store
+}
+
+func queued(t *testing.T, store *storage.MinioClient) []string {
+	t.Helper()
+
+	objects, err := store.List(context.Background(), queueRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	return keys
+}
+
+func TestDeliverSignsAndRemovesTheDelivery(t *testing.T) {
+	ctx := context.Background()
+	d, store := newTestDispatcher(t)
+
+	var subscription *models.WebhookSubscription
+	received := make(chan *http.Request, 1)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get("Webhook-Timestamp"), 10, 64)
+		if r.Header.Get("Webhook-Signature") != "sha256="+Sign(subscription.Secret, timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received <- r
+	}))
+	defer endpoint.Close()
+
+	subscription, err := d.Subscribe(ctx, endpoint.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The outbox may hand the same event over again.
+	occurredAt := time.Now()
+	for range 2 {
+		if err := d.Enqueue(ctx, "event-1", "photo.uploaded", occurredAt, json.RawMessage(`{"photo_id":"p"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := queued(t, store)
+	if len(keys) != 1 {
+		t.Fatalf("queued %d deliveries for one event", len(keys))
+	}
+
+	if err := d.deliver(ctx, keys[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case r := <-received:
+		if r.Header.Get("Webhook-Id") != "event-1" || r.Header.Get("Webhook-Event") != "photo.uploaded" {
+			t.Fatalf("headers %v", r.Header)
+		}
+	default:
+		t.Fatal("endpoint got no valid delivery")
+	}
+	if keys := queued(t, store); len(keys) != 0 {
+		t.Fatalf("%d deliveries left after success", len(keys))
+	}
+}
+
+func TestDeliverReschedulesAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	d, store := newTestDispatcher(t)
+
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer endpoint.Close()
+
+	if _, err := d.Subscribe(ctx, endpoint.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Enqueue(ctx, "event-1", "photo.deleted", time.Now(), json.RawMessage(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.deliver(ctx, queued(t, store)[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := queued(t, store)
+	if len(keys) != 1 {
+		t.Fatalf("%d deliveries queued after a failure, want 1", len(keys))
+	}
+	var delivery models.WebhookDelivery
+	if err := store.GetJSON(ctx, keys[0], &delivery); err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Attempts != 1 || delivery.LastError == "" || delivery.LeasedUntil != nil {
+		t.Fatalf("rescheduled delivery %+v", delivery)
+	}
+	if wait := time.Until(delivery.NextAttemptAt); wait < 29*time.Second || wait > time.Minute {
+		t.Fatalf("next attempt in %s, want within the first backoff", wait)
+	}
+
+	if err := d.deliver(ctx, keys[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if keys := queued(t, store); len(keys) != 0 {
+		t.Fatalf("%d deliveries queued after the last attempt", len(keys))
+	}
+	dead, err := d.DeadLetters(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].Attempts != 2 {
+		t.Fatalf("dead letters %+v", dead)
+	}
+}
+
+func TestDeliverSkipsLeasedDeliveries(t *testing.T) {
+	ctx := context.Background()
+	// Two instances sharing one bucket.
+	first, store := newTestDispatcher(t)
+	second := NewDispatcher(store, 1, 5*time.Second, time.Minute, first.retry)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	}))
+	defer endpoint.Close()
+
+	if _, err := first.Subscribe(ctx, endpoint.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Enqueue(ctx, "event-1", "photo.uploaded", time.Now(), json.RawMessage(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	key := queued(t, store)[0]
+
+	done := make(chan error)
+	go func() {
+		done <- first.deliver(ctx, key)
+	}()
+
+	// Wait until the first instance holds the lease and is sending.
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := second.deliver(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("endpoint called %d times, want once", calls.Load())
+	}
+}
@@ -21,18 +21,172 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Visibility int32
+
+const (
+	Visibility_VISIBILITY_UNSPECIFIED Visibility = 0
+	Visibility_VISIBILITY_PRIVATE     Visibility = 1
+	Visibility_VISIBILITY_LINK        Visibility = 2
+	Visibility_VISIBILITY_PUBLIC      Visibility = 3
+)
+
+// Enum value maps for Visibility.
+var (
+	Visibility_name = map[int32]string{
+		0: "VISIBILITY_UNSPECIFIED",
+		1: "VISIBILITY_PRIVATE",
+		2: "VISIBILITY_LINK",
+		3: "VISIBILITY_PUBLIC",
+	}
+	Visibility_value = map[string]int32{
+		"VISIBILITY_UNSPECIFIED": 0,
+		"VISIBILITY_PRIVATE":     1,
+		"VISIBILITY_LINK":        2,
+		"VISIBILITY_PUBLIC":      3,
+	}
+)
+
+func (x Visibility) Enum() *Visibility {
+	p := new(Visibility)
+	*p = x
+	return p
+}
+
+func (x Visibility) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Visibility) Descriptor() protoreflect.EnumDescriptor {
+	return file_file_storage_proto_enumTypes[0].Descriptor()
+}
+
+func (Visibility) Type() protoreflect.EnumType {
+	return &file_file_storage_proto_enumTypes[0]
+}
+
+func (x Visibility) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Visibility.Descriptor instead.
+func (Visibility) EnumDescriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{0}
+}
+
+type ModerationDecision int32
+
+const (
+	ModerationDecision_MODERATION_DECISION_UNSPECIFIED ModerationDecision = 0
+	ModerationDecision_MODERATION_DECISION_APPROVE     ModerationDecision = 1
+	ModerationDecision_MODERATION_DECISION_REJECT      ModerationDecision = 2
+)
+
+// Enum value maps for ModerationDecision.
+var (
+	ModerationDecision_name = map[int32]string{
+		0: "MODERATION_DECISION_UNSPECIFIED",
+		1: "MODERATION_DECISION_APPROVE",
+		2: "MODERATION_DECISION_REJECT",
+	}
+	ModerationDecision_value = map[string]int32{
+		"MODERATION_DECISION_UNSPECIFIED": 0,
+		"MODERATION_DECISION_APPROVE":     1,
+		"MODERATION_DECISION_REJECT":      2,
+	}
+)
+
+func (x ModerationDecision) Enum() *ModerationDecision {
+	p := new(ModerationDecision)
+	*p = x
+	return p
+}
+
+func (x ModerationDecision) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ModerationDecision) Descriptor() protoreflect.EnumDescriptor {
+	return file_file_storage_proto_enumTypes[1].Descriptor()
+}
+
+func (ModerationDecision) Type() protoreflect.EnumType {
+	return &file_file_storage_proto_enumTypes[1]
+}
+
+func (x ModerationDecision) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ModerationDecision.Descriptor instead.
+func (ModerationDecision) EnumDescriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{1}
+}
+
+type Checksums struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sha256        string                 `protobuf:"bytes,1,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Crc32C        string                 `protobuf:"bytes,2,opt,name=crc32c,proto3" json:"crc32c,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Checksums) Reset() {
+	*x = Checksums{}
+	mi := &file_file_storage_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Checksums) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Checksums) ProtoMessage() {}
+
+func (x *Checksums) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Checksums.ProtoReflect.Descriptor instead.
+func (*Checksums) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Checksums) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *Checksums) GetCrc32C() string {
+	if x != nil {
+		return x.Crc32C
+	}
+	return ""
+}
+
 type Photo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	FileData      []byte                 `protobuf:"bytes,1,opt,name=file_data,json=fileData,proto3" json:"file_data,omitempty"`
 	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
 	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Checksums     *Checksums             `protobuf:"bytes,4,opt,name=checksums,proto3" json:"checksums,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Photo) Reset() {
 	*x = Photo{}
-	mi := &file_file_storage_proto_msgTypes[0]
+	mi := &file_file_storage_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -44,7 +198,7 @@ func (x *Photo) String() string {
 func (*Photo) ProtoMessage() {}
 
 func (x *Photo) ProtoReflect() protoreflect.Message {
-	mi := &file_file_storage_proto_msgTypes[0]
+	mi := &file_file_storage_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -57,7 +211,7 @@ func (x *Photo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Photo.ProtoReflect.Descriptor instead.
 func (*Photo) Descriptor() ([]byte, []int) {
-	return file_file_storage_proto_rawDescGZIP(), []int{0}
+	return file_file_storage_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *Photo) GetFileData() []byte {
@@ -81,19 +235,27 @@ func (x *Photo) GetContentType() string {
 	return ""
 }
 
+func (x *Photo) GetChecksums() *Checksums {
+	if x != nil {
+		return x.Checksums
+	}
+	return nil
+}
+
 type UploadAvatarRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	FileData      []byte                 `protobuf:"bytes,2,opt,name=file_data,json=fileData,proto3" json:"file_data,omitempty"`
 	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
 	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Checksums     *Checksums             `protobuf:"bytes,5,opt,name=checksums,proto3" json:"checksums,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UploadAvatarRequest) Reset() {
 	*x = UploadAvatarRequest{}
-	mi := &file_file_storage_proto_msgTypes[1]
+	mi := &file_file_storage_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -105,7 +267,7 @@ func (x *UploadAvatarRequest) String() string {
 func (*UploadAvatarRequest) ProtoMessage() {}
 
 func (x *UploadAvatarRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_file_storage_proto_msgTypes[1]
+	mi := &file_file_storage_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -118,7 +280,7 @@ func (x *UploadAvatarRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UploadAvatarRequest.ProtoReflect.Descriptor instead.
 func (*UploadAvatarRequest) Descriptor() ([]byte, []int) {
-	return file_file_storage_proto_rawDescGZIP(), []int{1}
+	return file_file_storage_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *UploadAvatarRequest) GetUserId() string {
@@ -149,16 +311,92 @@ func (x *UploadAvatarRequest) GetContentType() string {
 	return ""
 }
 
+func (x *UploadAvatarRequest) GetChecksums() *Checksums {
+	if x != nil {
+		return x.Checksums
+	}
+	return nil
+}
+
+type Placeholder struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Blurhash      string                 `protobuf:"bytes,1,opt,name=blurhash,proto3" json:"blurhash,omitempty"`
+	DominantColor string                 `protobuf:"bytes,2,opt,name=dominant_color,json=dominantColor,proto3" json:"dominant_color,omitempty"`
+	Width         int32                  `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Placeholder) Reset() {
+	*x = Placeholder{}
+	mi := &file_file_storage_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Placeholder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Placeholder) ProtoMessage() {}
+
+func (x *Placeholder) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Placeholder.ProtoReflect.Descriptor instead.
+func (*Placeholder) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Placeholder) GetBlurhash() string {
+	if x != nil {
+		return x.Blurhash
+	}
+	return ""
+}
+
+func (x *Placeholder) GetDominantColor() string {
+	if x != nil {
+		return x.DominantColor
+	}
+	return ""
+}
+
+func (x *Placeholder) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Placeholder) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
 type UploadAvatarResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	PhotoId       string                 `protobuf:"bytes,1,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	Placeholder   *Placeholder           `protobuf:"bytes,2,opt,name=placeholder,proto3" json:"placeholder,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UploadAvatarResponse) Reset() {
 	*x = UploadAvatarResponse{}
-	mi := &file_file_storage_proto_msgTypes[2]
+	mi := &file_file_storage_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -170,7 +408,7 @@ func (x *UploadAvatarResponse) String() string {
 func (*UploadAvatarResponse) ProtoMessage() {}
 
 func (x *UploadAvatarResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_file_storage_proto_msgTypes[2]
+	mi := &file_file_storage_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -183,7 +421,7 @@ func (x *UploadAvatarResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UploadAvatarResponse.ProtoReflect.Descriptor instead.
 func (*UploadAvatarResponse) Descriptor() ([]byte, []int) {
-	return file_file_storage_proto_rawDescGZIP(), []int{2}
+	return file_file_storage_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *UploadAvatarResponse) GetPhotoId() string {
@@ -193,17 +431,25 @@ func (x *UploadAvatarResponse) GetPhotoId() string {
 	return ""
 }
 
+func (x *UploadAvatarResponse) GetPlaceholder() *Placeholder {
+	if x != nil {
+		return x.Placeholder
+	}
+	return nil
+}
+
 type UploadPhotosRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Photos        []*Photo               `protobuf:"bytes,2,rep,name=photos,proto3" json:"photos,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	UserId           string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Photos           []*Photo               `protobuf:"bytes,2,rep,name=photos,proto3" json:"photos,omitempty"`
+	RejectDuplicates bool                   `protobuf:"varint,3,opt,name=reject_duplicates,json=rejectDuplicates,proto3" json:"reject_duplicates,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *UploadPhotosRequest) Reset() {
 	*x = UploadPhotosRequest{}
-	mi := &file_file_storage_proto_msgTypes[3]
+	mi := &file_file_storage_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -215,7 +461,7 @@ func (x *UploadPhotosRequest) String() string {
 func (*UploadPhotosRequest) ProtoMessage() {}
 
 func (x *UploadPhotosRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_file_storage_proto_msgTypes[3]
+	mi := &file_file_storage_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -228,7 +474,7 @@ func (x *UploadPhotosRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UploadPhotosRequest.ProtoReflect.Descriptor instead.
 func (*UploadPhotosRequest) Descriptor() ([]byte, []int) {
-	return file_file_storage_proto_rawDescGZIP(), []int{3}
+	return file_file_storage_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *UploadPhotosRequest) GetUserId() string {
@@ -245,16 +491,24 @@ func (x *UploadPhotosRequest) GetPhotos() []*Photo {
 	return nil
 }
 
+func (x *UploadPhotosRequest) GetRejectDuplicates() bool {
+	if x != nil {
+		return x.RejectDuplicates
+	}
+	return false
+}
+
 type UploadPhotosResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	PhotoIds      []string               `protobuf:"bytes,1,rep,name=photo_ids,json=photoIds,proto3" json:"photo_ids,omitempty"`
+	Placeholders  []*Placeholder         `protobuf:"bytes,2,rep,name=placeholders,proto3" json:"placeholders,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UploadPhotosResponse) Reset() {
 	*x = UploadPhotosResponse{}
-	mi := &file_file_storage_proto_msgTypes[4]
+	mi := &file_file_storage_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -266,7 +520,7 @@ func (x *UploadPhotosResponse) String() string {
 func (*UploadPhotosResponse) ProtoMessage() {}
 
 func (x *UploadPhotosResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_file_storage_proto_msgTypes[4]
+	mi := &file_file_storage_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -279,7 +533,7 @@ func (x *UploadPhotosResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UploadPhotosResponse.ProtoReflect.Descriptor instead.
 func (*UploadPhotosResponse) Descriptor() ([]byte, []int) {
-	return file_file_storage_proto_rawDescGZIP(), []int{4}
+	return file_file_storage_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *UploadPhotosResponse) GetPhotoIds() []string {
@@ -289,17 +543,26 @@ func (x *UploadPhotosResponse) GetPhotoIds() []string {
 	return nil
 }
 
+func (x *UploadPhotosResponse) GetPlaceholders() []*Placeholder {
+	if x != nil {
+		return x.Placeholders
+	}
+	return nil
+}
+
 type GetPhotoURLRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	PhotoId       string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	AcceptFormats []string               `protobuf:"bytes,3,rep,name=accept_formats,json=acceptFormats,proto3" json:"accept_formats,omitempty"`
+	RequesterId   string                 `protobuf:"bytes,4,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetPhotoURLRequest) Reset() {
 	*x = GetPhotoURLRequest{}
-	mi := &file_file_storage_proto_msgTypes[5]
+	mi := &file_file_storage_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -311,7 +574,7 @@ func (x *GetPhotoURLRequest) String() string {
 func (*GetPhotoURLRequest) ProtoMessage() {}
 
 func (x *GetPhotoURLRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_file_storage_proto_msgTypes[5]
+	mi := &file_file_storage_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -324,7 +587,7 @@ func (x *GetPhotoURLRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPhotoURLRequest.ProtoReflect.Descriptor instead.
 func (*GetPhotoURLRequest) Descriptor() ([]byte, []int) {
-	return file_file_storage_proto_rawDescGZIP(), []int{5}
+	return file_file_storage_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetPhotoURLRequest) GetUserId() string {
@@ -341,16 +604,32 @@ func (x *GetPhotoURLRequest) GetPhotoId() string {
 	return ""
 }
 
+func (x *GetPhotoURLRequest) GetAcceptFormats() []string {
+	if x != nil {
+		return x.AcceptFormats
+	}
+	return nil
+}
+
+func (x *GetPhotoURLRequest) GetRequesterId() string {
+	if x != nil {
+		return x.RequesterId
+	}
+	return ""
+}
+
 type GetPhotoURLResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Placeholder   *Placeholder           `protobuf:"bytes,2,opt,name=placeholder,proto3" json:"placeholder,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetPhotoURLResponse) Reset() {
 	*x = GetPhotoURLResponse{}
-	mi := &file_file_storage_proto_msgTypes[6]
+	mi := &file_file_storage_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -362,7 +641,7 @@ func (x *GetPhotoURLResponse) String() string {
 func (*GetPhotoURLResponse) ProtoMessage() {}
 
 func (x *GetPhotoURLResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_file_storage_proto_msgTypes[6]
+	mi := &file_file_storage_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -375,7 +654,7 @@ func (x *GetPhotoURLResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPhotoURLResponse.ProtoReflect.Descriptor instead.
 func (*GetPhotoURLResponse) Descriptor() ([]byte, []int) {
-	return file_file_storage_proto_rawDescGZIP(), []int{6}
+	return file_file_storage_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *GetPhotoURLResponse) GetUrl() string {
@@ -385,36 +664,3264 @@ func (x *GetPhotoURLResponse) GetUrl() string {
 	return ""
 }
 
-var File_file_storage_proto protoreflect.FileDescriptor
+func (x *GetPhotoURLResponse) GetPlaceholder() *Placeholder {
+	if x != nil {
+		return x.Placeholder
+	}
+	return nil
+}
 
-const file_file_storage_proto_rawDesc = "" +
-	"\n" +
-	"\x12file_storage.proto\x12\x05s3.v1\"d\n" +
-	"\x05Photo\x12\x1b\n" +
-	"\tfile_data\x18\x01 \x01(\fR\bfileData\x12\x1b\n" +
-	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12!\n" +
-	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\"\x8b\x01\n" +
-	"\x13UploadAvatarRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
-	"\tfile_data\x18\x02 \x01(\fR\bfileData\x12\x1b\n" +
-	"\tfile_name\x18\x03 \x01(\tR\bfileName\x12!\n" +
-	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\"1\n" +
-	"\x14UploadAvatarResponse\x12\x19\n" +
-	"\bphoto_id\x18\x01 \x01(\tR\aphotoId\"T\n" +
-	"\x13UploadPhotosRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\x12$\n" +
-	"\x06photos\x18\x02 \x03(\v2\f.s3.v1.PhotoR\x06photos\"3\n" +
-	"\x14UploadPhotosResponse\x12\x1b\n" +
-	"\tphoto_ids\x18\x01 \x03(\tR\bphotoIds\"H\n" +
-	"\x12GetPhotoURLRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
-	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\"'\n" +
-	"\x13GetPhotoURLResponse\x12\x10\n" +
-	"\x03url\x18\x01 \x01(\tR\x03url2\xec\x01\n" +
+func (x *GetPhotoURLResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+type GetPhotoURLsRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	UserId             string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoIds           []string               `protobuf:"bytes,2,rep,name=photo_ids,json=photoIds,proto3" json:"photo_ids,omitempty"`
+	AcceptFormats      []string               `protobuf:"bytes,3,rep,name=accept_formats,json=acceptFormats,proto3" json:"accept_formats,omitempty"`
+	SkipExistenceCheck bool                   `protobuf:"varint,4,opt,name=skip_existence_check,json=skipExistenceCheck,proto3" json:"skip_existence_check,omitempty"`
+	RequesterId        string                 `protobuf:"bytes,5,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *GetPhotoURLsRequest) Reset() {
+	*x = GetPhotoURLsRequest{}
+	mi := &file_file_storage_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPhotoURLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPhotoURLsRequest) ProtoMessage() {}
+
+func (x *GetPhotoURLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPhotoURLsRequest.ProtoReflect.Descriptor instead.
+func (*GetPhotoURLsRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetPhotoURLsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetPhotoURLsRequest) GetPhotoIds() []string {
+	if x != nil {
+		return x.PhotoIds
+	}
+	return nil
+}
+
+func (x *GetPhotoURLsRequest) GetAcceptFormats() []string {
+	if x != nil {
+		return x.AcceptFormats
+	}
+	return nil
+}
+
+func (x *GetPhotoURLsRequest) GetSkipExistenceCheck() bool {
+	if x != nil {
+		return x.SkipExistenceCheck
+	}
+	return false
+}
+
+func (x *GetPhotoURLsRequest) GetRequesterId() string {
+	if x != nil {
+		return x.RequesterId
+	}
+	return ""
+}
+
+type PhotoURLResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PhotoId       string                 `protobuf:"bytes,1,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Placeholder   *Placeholder           `protobuf:"bytes,3,opt,name=placeholder,proto3" json:"placeholder,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Code          int32                  `protobuf:"varint,5,opt,name=code,proto3" json:"code,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PhotoURLResult) Reset() {
+	*x = PhotoURLResult{}
+	mi := &file_file_storage_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PhotoURLResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PhotoURLResult) ProtoMessage() {}
+
+func (x *PhotoURLResult) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PhotoURLResult.ProtoReflect.Descriptor instead.
+func (*PhotoURLResult) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PhotoURLResult) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *PhotoURLResult) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *PhotoURLResult) GetPlaceholder() *Placeholder {
+	if x != nil {
+		return x.Placeholder
+	}
+	return nil
+}
+
+func (x *PhotoURLResult) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *PhotoURLResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *PhotoURLResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type GetPhotoURLsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*PhotoURLResult      `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPhotoURLsResponse) Reset() {
+	*x = GetPhotoURLsResponse{}
+	mi := &file_file_storage_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPhotoURLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPhotoURLsResponse) ProtoMessage() {}
+
+func (x *GetPhotoURLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPhotoURLsResponse.ProtoReflect.Descriptor instead.
+func (*GetPhotoURLsResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetPhotoURLsResponse) GetResults() []*PhotoURLResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type GetImageURLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoId       string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	Width         int32                  `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	Fit           string                 `protobuf:"bytes,5,opt,name=fit,proto3" json:"fit,omitempty"`
+	Format        string                 `protobuf:"bytes,6,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetImageURLRequest) Reset() {
+	*x = GetImageURLRequest{}
+	mi := &file_file_storage_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetImageURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetImageURLRequest) ProtoMessage() {}
+
+func (x *GetImageURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetImageURLRequest.ProtoReflect.Descriptor instead.
+func (*GetImageURLRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetImageURLRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetImageURLRequest) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *GetImageURLRequest) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *GetImageURLRequest) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *GetImageURLRequest) GetFit() string {
+	if x != nil {
+		return x.Fit
+	}
+	return ""
+}
+
+func (x *GetImageURLRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type GetImageURLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetImageURLResponse) Reset() {
+	*x = GetImageURLResponse{}
+	mi := &file_file_storage_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetImageURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetImageURLResponse) ProtoMessage() {}
+
+func (x *GetImageURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetImageURLResponse.ProtoReflect.Descriptor instead.
+func (*GetImageURLResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetImageURLResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *GetImageURLResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type GetDownloadURLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoId       string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDownloadURLRequest) Reset() {
+	*x = GetDownloadURLRequest{}
+	mi := &file_file_storage_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDownloadURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDownloadURLRequest) ProtoMessage() {}
+
+func (x *GetDownloadURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDownloadURLRequest.ProtoReflect.Descriptor instead.
+func (*GetDownloadURLRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetDownloadURLRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetDownloadURLRequest) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+type GetDownloadURLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDownloadURLResponse) Reset() {
+	*x = GetDownloadURLResponse{}
+	mi := &file_file_storage_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDownloadURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDownloadURLResponse) ProtoMessage() {}
+
+func (x *GetDownloadURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDownloadURLResponse.ProtoReflect.Descriptor instead.
+func (*GetDownloadURLResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetDownloadURLResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *GetDownloadURLResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type DeletePhotoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoId       string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePhotoRequest) Reset() {
+	*x = DeletePhotoRequest{}
+	mi := &file_file_storage_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePhotoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePhotoRequest) ProtoMessage() {}
+
+func (x *DeletePhotoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePhotoRequest.ProtoReflect.Descriptor instead.
+func (*DeletePhotoRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeletePhotoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeletePhotoRequest) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+type DeletePhotoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePhotoResponse) Reset() {
+	*x = DeletePhotoResponse{}
+	mi := &file_file_storage_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePhotoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePhotoResponse) ProtoMessage() {}
+
+func (x *DeletePhotoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePhotoResponse.ProtoReflect.Descriptor instead.
+func (*DeletePhotoResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{17}
+}
+
+type FindSimilarPhotosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoId       string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	Threshold     int32                  `protobuf:"varint,3,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindSimilarPhotosRequest) Reset() {
+	*x = FindSimilarPhotosRequest{}
+	mi := &file_file_storage_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindSimilarPhotosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindSimilarPhotosRequest) ProtoMessage() {}
+
+func (x *FindSimilarPhotosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindSimilarPhotosRequest.ProtoReflect.Descriptor instead.
+func (*FindSimilarPhotosRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *FindSimilarPhotosRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *FindSimilarPhotosRequest) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *FindSimilarPhotosRequest) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+type SimilarPhoto struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PhotoId       string                 `protobuf:"bytes,1,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	Distance      int32                  `protobuf:"varint,2,opt,name=distance,proto3" json:"distance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimilarPhoto) Reset() {
+	*x = SimilarPhoto{}
+	mi := &file_file_storage_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarPhoto) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarPhoto) ProtoMessage() {}
+
+func (x *SimilarPhoto) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarPhoto.ProtoReflect.Descriptor instead.
+func (*SimilarPhoto) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SimilarPhoto) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *SimilarPhoto) GetDistance() int32 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+type FindSimilarPhotosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Photos        []*SimilarPhoto        `protobuf:"bytes,1,rep,name=photos,proto3" json:"photos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindSimilarPhotosResponse) Reset() {
+	*x = FindSimilarPhotosResponse{}
+	mi := &file_file_storage_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindSimilarPhotosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindSimilarPhotosResponse) ProtoMessage() {}
+
+func (x *FindSimilarPhotosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindSimilarPhotosResponse.ProtoReflect.Descriptor instead.
+func (*FindSimilarPhotosResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *FindSimilarPhotosResponse) GetPhotos() []*SimilarPhoto {
+	if x != nil {
+		return x.Photos
+	}
+	return nil
+}
+
+type SetPhotoVisibilityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoId       string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	Visibility    Visibility             `protobuf:"varint,3,opt,name=visibility,proto3,enum=s3.v1.Visibility" json:"visibility,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetPhotoVisibilityRequest) Reset() {
+	*x = SetPhotoVisibilityRequest{}
+	mi := &file_file_storage_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetPhotoVisibilityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetPhotoVisibilityRequest) ProtoMessage() {}
+
+func (x *SetPhotoVisibilityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetPhotoVisibilityRequest.ProtoReflect.Descriptor instead.
+func (*SetPhotoVisibilityRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SetPhotoVisibilityRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SetPhotoVisibilityRequest) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *SetPhotoVisibilityRequest) GetVisibility() Visibility {
+	if x != nil {
+		return x.Visibility
+	}
+	return Visibility_VISIBILITY_UNSPECIFIED
+}
+
+type SetPhotoVisibilityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetPhotoVisibilityResponse) Reset() {
+	*x = SetPhotoVisibilityResponse{}
+	mi := &file_file_storage_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetPhotoVisibilityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetPhotoVisibilityResponse) ProtoMessage() {}
+
+func (x *SetPhotoVisibilityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetPhotoVisibilityResponse.ProtoReflect.Descriptor instead.
+func (*SetPhotoVisibilityResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{22}
+}
+
+type CreateShareLinkRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	UserId           string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoId          string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	ExpiresInSeconds int64                  `protobuf:"varint,3,opt,name=expires_in_seconds,json=expiresInSeconds,proto3" json:"expires_in_seconds,omitempty"`
+	MaxAccesses      int32                  `protobuf:"varint,4,opt,name=max_accesses,json=maxAccesses,proto3" json:"max_accesses,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CreateShareLinkRequest) Reset() {
+	*x = CreateShareLinkRequest{}
+	mi := &file_file_storage_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateShareLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateShareLinkRequest) ProtoMessage() {}
+
+func (x *CreateShareLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateShareLinkRequest.ProtoReflect.Descriptor instead.
+func (*CreateShareLinkRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CreateShareLinkRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateShareLinkRequest) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *CreateShareLinkRequest) GetExpiresInSeconds() int64 {
+	if x != nil {
+		return x.ExpiresInSeconds
+	}
+	return 0
+}
+
+func (x *CreateShareLinkRequest) GetMaxAccesses() int32 {
+	if x != nil {
+		return x.MaxAccesses
+	}
+	return 0
+}
+
+type CreateShareLinkResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateShareLinkResponse) Reset() {
+	*x = CreateShareLinkResponse{}
+	mi := &file_file_storage_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateShareLinkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateShareLinkResponse) ProtoMessage() {}
+
+func (x *CreateShareLinkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateShareLinkResponse.ProtoReflect.Descriptor instead.
+func (*CreateShareLinkResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CreateShareLinkResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *CreateShareLinkResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CreateShareLinkResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type RevokeShareLinkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeShareLinkRequest) Reset() {
+	*x = RevokeShareLinkRequest{}
+	mi := &file_file_storage_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeShareLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeShareLinkRequest) ProtoMessage() {}
+
+func (x *RevokeShareLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeShareLinkRequest.ProtoReflect.Descriptor instead.
+func (*RevokeShareLinkRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *RevokeShareLinkRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RevokeShareLinkRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type RevokeShareLinkResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeShareLinkResponse) Reset() {
+	*x = RevokeShareLinkResponse{}
+	mi := &file_file_storage_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeShareLinkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeShareLinkResponse) ProtoMessage() {}
+
+func (x *RevokeShareLinkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeShareLinkResponse.ProtoReflect.Descriptor instead.
+func (*RevokeShareLinkResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{26}
+}
+
+type UploadDocumentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	FileData      []byte                 `protobuf:"bytes,2,opt,name=file_data,json=fileData,proto3" json:"file_data,omitempty"`
+	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Checksums     *Checksums             `protobuf:"bytes,5,opt,name=checksums,proto3" json:"checksums,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadDocumentRequest) Reset() {
+	*x = UploadDocumentRequest{}
+	mi := &file_file_storage_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadDocumentRequest) ProtoMessage() {}
+
+func (x *UploadDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadDocumentRequest.ProtoReflect.Descriptor instead.
+func (*UploadDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *UploadDocumentRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UploadDocumentRequest) GetFileData() []byte {
+	if x != nil {
+		return x.FileData
+	}
+	return nil
+}
+
+func (x *UploadDocumentRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *UploadDocumentRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *UploadDocumentRequest) GetChecksums() *Checksums {
+	if x != nil {
+		return x.Checksums
+	}
+	return nil
+}
+
+type UploadDocumentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DocumentId    string                 `protobuf:"bytes,1,opt,name=document_id,json=documentId,proto3" json:"document_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadDocumentResponse) Reset() {
+	*x = UploadDocumentResponse{}
+	mi := &file_file_storage_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadDocumentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadDocumentResponse) ProtoMessage() {}
+
+func (x *UploadDocumentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadDocumentResponse.ProtoReflect.Descriptor instead.
+func (*UploadDocumentResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *UploadDocumentResponse) GetDocumentId() string {
+	if x != nil {
+		return x.DocumentId
+	}
+	return ""
+}
+
+type GetDocumentURLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	DocumentId    string                 `protobuf:"bytes,2,opt,name=document_id,json=documentId,proto3" json:"document_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDocumentURLRequest) Reset() {
+	*x = GetDocumentURLRequest{}
+	mi := &file_file_storage_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDocumentURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDocumentURLRequest) ProtoMessage() {}
+
+func (x *GetDocumentURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDocumentURLRequest.ProtoReflect.Descriptor instead.
+func (*GetDocumentURLRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GetDocumentURLRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetDocumentURLRequest) GetDocumentId() string {
+	if x != nil {
+		return x.DocumentId
+	}
+	return ""
+}
+
+type GetDocumentURLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDocumentURLResponse) Reset() {
+	*x = GetDocumentURLResponse{}
+	mi := &file_file_storage_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDocumentURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDocumentURLResponse) ProtoMessage() {}
+
+func (x *GetDocumentURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDocumentURLResponse.ProtoReflect.Descriptor instead.
+func (*GetDocumentURLResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *GetDocumentURLResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *GetDocumentURLResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type DeleteDocumentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	DocumentId    string                 `protobuf:"bytes,2,opt,name=document_id,json=documentId,proto3" json:"document_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDocumentRequest) Reset() {
+	*x = DeleteDocumentRequest{}
+	mi := &file_file_storage_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDocumentRequest) ProtoMessage() {}
+
+func (x *DeleteDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDocumentRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *DeleteDocumentRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeleteDocumentRequest) GetDocumentId() string {
+	if x != nil {
+		return x.DocumentId
+	}
+	return ""
+}
+
+type DeleteDocumentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDocumentResponse) Reset() {
+	*x = DeleteDocumentResponse{}
+	mi := &file_file_storage_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDocumentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDocumentResponse) ProtoMessage() {}
+
+func (x *DeleteDocumentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDocumentResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDocumentResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{32}
+}
+
+type ListPendingPhotosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPendingPhotosRequest) Reset() {
+	*x = ListPendingPhotosRequest{}
+	mi := &file_file_storage_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPendingPhotosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPendingPhotosRequest) ProtoMessage() {}
+
+func (x *ListPendingPhotosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPendingPhotosRequest.ProtoReflect.Descriptor instead.
+func (*ListPendingPhotosRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListPendingPhotosRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPendingPhotosRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type PendingPhoto struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoId       string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	Url           string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PendingPhoto) Reset() {
+	*x = PendingPhoto{}
+	mi := &file_file_storage_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PendingPhoto) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendingPhoto) ProtoMessage() {}
+
+func (x *PendingPhoto) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PendingPhoto.ProtoReflect.Descriptor instead.
+func (*PendingPhoto) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *PendingPhoto) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PendingPhoto) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *PendingPhoto) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *PendingPhoto) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *PendingPhoto) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type ListPendingPhotosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Photos        []*PendingPhoto        `protobuf:"bytes,1,rep,name=photos,proto3" json:"photos,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPendingPhotosResponse) Reset() {
+	*x = ListPendingPhotosResponse{}
+	mi := &file_file_storage_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPendingPhotosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPendingPhotosResponse) ProtoMessage() {}
+
+func (x *ListPendingPhotosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPendingPhotosResponse.ProtoReflect.Descriptor instead.
+func (*ListPendingPhotosResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ListPendingPhotosResponse) GetPhotos() []*PendingPhoto {
+	if x != nil {
+		return x.Photos
+	}
+	return nil
+}
+
+func (x *ListPendingPhotosResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type ModeratePhotoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoId       string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	Decision      ModerationDecision     `protobuf:"varint,3,opt,name=decision,proto3,enum=s3.v1.ModerationDecision" json:"decision,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModeratePhotoRequest) Reset() {
+	*x = ModeratePhotoRequest{}
+	mi := &file_file_storage_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModeratePhotoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModeratePhotoRequest) ProtoMessage() {}
+
+func (x *ModeratePhotoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModeratePhotoRequest.ProtoReflect.Descriptor instead.
+func (*ModeratePhotoRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ModeratePhotoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ModeratePhotoRequest) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *ModeratePhotoRequest) GetDecision() ModerationDecision {
+	if x != nil {
+		return x.Decision
+	}
+	return ModerationDecision_MODERATION_DECISION_UNSPECIFIED
+}
+
+func (x *ModeratePhotoRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ModeratePhotoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModeratePhotoResponse) Reset() {
+	*x = ModeratePhotoResponse{}
+	mi := &file_file_storage_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModeratePhotoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModeratePhotoResponse) ProtoMessage() {}
+
+func (x *ModeratePhotoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModeratePhotoResponse.ProtoReflect.Descriptor instead.
+func (*ModeratePhotoResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{37}
+}
+
+type CreateUploadURLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateUploadURLRequest) Reset() {
+	*x = CreateUploadURLRequest{}
+	mi := &file_file_storage_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateUploadURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateUploadURLRequest) ProtoMessage() {}
+
+func (x *CreateUploadURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateUploadURLRequest.ProtoReflect.Descriptor instead.
+func (*CreateUploadURLRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CreateUploadURLRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateUploadURLRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+type CreateUploadURLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PhotoId       string                 `protobuf:"bytes,1,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateUploadURLResponse) Reset() {
+	*x = CreateUploadURLResponse{}
+	mi := &file_file_storage_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateUploadURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateUploadURLResponse) ProtoMessage() {}
+
+func (x *CreateUploadURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateUploadURLResponse.ProtoReflect.Descriptor instead.
+func (*CreateUploadURLResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *CreateUploadURLResponse) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *CreateUploadURLResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CreateUploadURLResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type CreateWebhookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Events        []string               `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWebhookRequest) Reset() {
+	*x = CreateWebhookRequest{}
+	mi := &file_file_storage_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWebhookRequest) ProtoMessage() {}
+
+func (x *CreateWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWebhookRequest.ProtoReflect.Descriptor instead.
+func (*CreateWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *CreateWebhookRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CreateWebhookRequest) GetEvents() []string {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type CreateWebhookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId     string                 `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId,proto3" json:"webhook_id,omitempty"`
+	Secret        string                 `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWebhookResponse) Reset() {
+	*x = CreateWebhookResponse{}
+	mi := &file_file_storage_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWebhookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWebhookResponse) ProtoMessage() {}
+
+func (x *CreateWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWebhookResponse.ProtoReflect.Descriptor instead.
+func (*CreateWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *CreateWebhookResponse) GetWebhookId() string {
+	if x != nil {
+		return x.WebhookId
+	}
+	return ""
+}
+
+func (x *CreateWebhookResponse) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+type DeleteWebhookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId     string                 `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId,proto3" json:"webhook_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWebhookRequest) Reset() {
+	*x = DeleteWebhookRequest{}
+	mi := &file_file_storage_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWebhookRequest) ProtoMessage() {}
+
+func (x *DeleteWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWebhookRequest.ProtoReflect.Descriptor instead.
+func (*DeleteWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *DeleteWebhookRequest) GetWebhookId() string {
+	if x != nil {
+		return x.WebhookId
+	}
+	return ""
+}
+
+type DeleteWebhookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWebhookResponse) Reset() {
+	*x = DeleteWebhookResponse{}
+	mi := &file_file_storage_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWebhookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWebhookResponse) ProtoMessage() {}
+
+func (x *DeleteWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWebhookResponse.ProtoReflect.Descriptor instead.
+func (*DeleteWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{43}
+}
+
+type ListWebhooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhooksRequest) Reset() {
+	*x = ListWebhooksRequest{}
+	mi := &file_file_storage_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhooksRequest) ProtoMessage() {}
+
+func (x *ListWebhooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhooksRequest.ProtoReflect.Descriptor instead.
+func (*ListWebhooksRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{44}
+}
+
+type Webhook struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WebhookId     string                 `protobuf:"bytes,1,opt,name=webhook_id,json=webhookId,proto3" json:"webhook_id,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Events        []string               `protobuf:"bytes,3,rep,name=events,proto3" json:"events,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Webhook) Reset() {
+	*x = Webhook{}
+	mi := &file_file_storage_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Webhook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Webhook) ProtoMessage() {}
+
+func (x *Webhook) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Webhook.ProtoReflect.Descriptor instead.
+func (*Webhook) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *Webhook) GetWebhookId() string {
+	if x != nil {
+		return x.WebhookId
+	}
+	return ""
+}
+
+func (x *Webhook) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Webhook) GetEvents() []string {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Webhook) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type ListWebhooksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Webhooks      []*Webhook             `protobuf:"bytes,1,rep,name=webhooks,proto3" json:"webhooks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhooksResponse) Reset() {
+	*x = ListWebhooksResponse{}
+	mi := &file_file_storage_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhooksResponse) ProtoMessage() {}
+
+func (x *ListWebhooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhooksResponse.ProtoReflect.Descriptor instead.
+func (*ListWebhooksResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ListWebhooksResponse) GetWebhooks() []*Webhook {
+	if x != nil {
+		return x.Webhooks
+	}
+	return nil
+}
+
+type ListDeadWebhookDeliveriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadWebhookDeliveriesRequest) Reset() {
+	*x = ListDeadWebhookDeliveriesRequest{}
+	mi := &file_file_storage_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadWebhookDeliveriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadWebhookDeliveriesRequest) ProtoMessage() {}
+
+func (x *ListDeadWebhookDeliveriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadWebhookDeliveriesRequest.ProtoReflect.Descriptor instead.
+func (*ListDeadWebhookDeliveriesRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{47}
+}
+
+type WebhookDelivery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	WebhookId     string                 `protobuf:"bytes,2,opt,name=webhook_id,json=webhookId,proto3" json:"webhook_id,omitempty"`
+	EventId       string                 `protobuf:"bytes,3,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	EventType     string                 `protobuf:"bytes,4,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Attempts      int32                  `protobuf:"varint,5,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	LastError     string                 `protobuf:"bytes,6,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebhookDelivery) Reset() {
+	*x = WebhookDelivery{}
+	mi := &file_file_storage_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebhookDelivery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebhookDelivery) ProtoMessage() {}
+
+func (x *WebhookDelivery) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebhookDelivery.ProtoReflect.Descriptor instead.
+func (*WebhookDelivery) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *WebhookDelivery) GetDeliveryId() string {
+	if x != nil {
+		return x.DeliveryId
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetWebhookId() string {
+	if x != nil {
+		return x.WebhookId
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type ListDeadWebhookDeliveriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deliveries    []*WebhookDelivery     `protobuf:"bytes,1,rep,name=deliveries,proto3" json:"deliveries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadWebhookDeliveriesResponse) Reset() {
+	*x = ListDeadWebhookDeliveriesResponse{}
+	mi := &file_file_storage_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadWebhookDeliveriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadWebhookDeliveriesResponse) ProtoMessage() {}
+
+func (x *ListDeadWebhookDeliveriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadWebhookDeliveriesResponse.ProtoReflect.Descriptor instead.
+func (*ListDeadWebhookDeliveriesResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ListDeadWebhookDeliveriesResponse) GetDeliveries() []*WebhookDelivery {
+	if x != nil {
+		return x.Deliveries
+	}
+	return nil
+}
+
+type ReplayWebhookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeliveryId    string                 `protobuf:"bytes,1,opt,name=delivery_id,json=deliveryId,proto3" json:"delivery_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayWebhookRequest) Reset() {
+	*x = ReplayWebhookRequest{}
+	mi := &file_file_storage_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayWebhookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayWebhookRequest) ProtoMessage() {}
+
+func (x *ReplayWebhookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayWebhookRequest.ProtoReflect.Descriptor instead.
+func (*ReplayWebhookRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *ReplayWebhookRequest) GetDeliveryId() string {
+	if x != nil {
+		return x.DeliveryId
+	}
+	return ""
+}
+
+type ReplayWebhookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayWebhookResponse) Reset() {
+	*x = ReplayWebhookResponse{}
+	mi := &file_file_storage_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayWebhookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayWebhookResponse) ProtoMessage() {}
+
+func (x *ReplayWebhookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayWebhookResponse.ProtoReflect.Descriptor instead.
+func (*ReplayWebhookResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{51}
+}
+
+type DeleteAllUserDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAllUserDataRequest) Reset() {
+	*x = DeleteAllUserDataRequest{}
+	mi := &file_file_storage_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAllUserDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAllUserDataRequest) ProtoMessage() {}
+
+func (x *DeleteAllUserDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAllUserDataRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAllUserDataRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *DeleteAllUserDataRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type DeleteAllUserDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ErasureId     string                 `protobuf:"bytes,1,opt,name=erasure_id,json=erasureId,proto3" json:"erasure_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAllUserDataResponse) Reset() {
+	*x = DeleteAllUserDataResponse{}
+	mi := &file_file_storage_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAllUserDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAllUserDataResponse) ProtoMessage() {}
+
+func (x *DeleteAllUserDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAllUserDataResponse.ProtoReflect.Descriptor instead.
+func (*DeleteAllUserDataResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *DeleteAllUserDataResponse) GetErasureId() string {
+	if x != nil {
+		return x.ErasureId
+	}
+	return ""
+}
+
+type GetErasureStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ErasureId     string                 `protobuf:"bytes,1,opt,name=erasure_id,json=erasureId,proto3" json:"erasure_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetErasureStatusRequest) Reset() {
+	*x = GetErasureStatusRequest{}
+	mi := &file_file_storage_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetErasureStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetErasureStatusRequest) ProtoMessage() {}
+
+func (x *GetErasureStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetErasureStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetErasureStatusRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *GetErasureStatusRequest) GetErasureId() string {
+	if x != nil {
+		return x.ErasureId
+	}
+	return ""
+}
+
+type ErasureReceipt struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ErasureId     string                 `protobuf:"bytes,1,opt,name=erasure_id,json=erasureId,proto3" json:"erasure_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Deleted       int64                  `protobuf:"varint,3,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	StartedAt     int64                  `protobuf:"varint,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	CompletedAt   int64                  `protobuf:"varint,5,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	Signature     string                 `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErasureReceipt) Reset() {
+	*x = ErasureReceipt{}
+	mi := &file_file_storage_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErasureReceipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErasureReceipt) ProtoMessage() {}
+
+func (x *ErasureReceipt) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErasureReceipt.ProtoReflect.Descriptor instead.
+func (*ErasureReceipt) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *ErasureReceipt) GetErasureId() string {
+	if x != nil {
+		return x.ErasureId
+	}
+	return ""
+}
+
+func (x *ErasureReceipt) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ErasureReceipt) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *ErasureReceipt) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *ErasureReceipt) GetCompletedAt() int64 {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return 0
+}
+
+func (x *ErasureReceipt) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type GetErasureStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Stage         string                 `protobuf:"bytes,3,opt,name=stage,proto3" json:"stage,omitempty"`
+	Deleted       int64                  `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Receipt       *ErasureReceipt        `protobuf:"bytes,6,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetErasureStatusResponse) Reset() {
+	*x = GetErasureStatusResponse{}
+	mi := &file_file_storage_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetErasureStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetErasureStatusResponse) ProtoMessage() {}
+
+func (x *GetErasureStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetErasureStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetErasureStatusResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *GetErasureStatusResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetErasureStatusResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GetErasureStatusResponse) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *GetErasureStatusResponse) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *GetErasureStatusResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *GetErasureStatusResponse) GetReceipt() *ErasureReceipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+type ExportUserDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportUserDataRequest) Reset() {
+	*x = ExportUserDataRequest{}
+	mi := &file_file_storage_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportUserDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportUserDataRequest) ProtoMessage() {}
+
+func (x *ExportUserDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportUserDataRequest.ProtoReflect.Descriptor instead.
+func (*ExportUserDataRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *ExportUserDataRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ExportUserDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportUserDataResponse) Reset() {
+	*x = ExportUserDataResponse{}
+	mi := &file_file_storage_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportUserDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportUserDataResponse) ProtoMessage() {}
+
+func (x *ExportUserDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportUserDataResponse.ProtoReflect.Descriptor instead.
+func (*ExportUserDataResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *ExportUserDataResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *ExportUserDataResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ExportUserDataResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type ListDeletedPhotosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeletedPhotosRequest) Reset() {
+	*x = ListDeletedPhotosRequest{}
+	mi := &file_file_storage_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeletedPhotosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeletedPhotosRequest) ProtoMessage() {}
+
+func (x *ListDeletedPhotosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeletedPhotosRequest.ProtoReflect.Descriptor instead.
+func (*ListDeletedPhotosRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *ListDeletedPhotosRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type DeletedPhoto struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PhotoId       string                 `protobuf:"bytes,1,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	ContentType   string                 `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	FileSize      int64                  `protobuf:"varint,3,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`
+	Placeholder   *Placeholder           `protobuf:"bytes,4,opt,name=placeholder,proto3" json:"placeholder,omitempty"`
+	DeletedAt     int64                  `protobuf:"varint,5,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	PurgeAt       int64                  `protobuf:"varint,6,opt,name=purge_at,json=purgeAt,proto3" json:"purge_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletedPhoto) Reset() {
+	*x = DeletedPhoto{}
+	mi := &file_file_storage_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletedPhoto) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletedPhoto) ProtoMessage() {}
+
+func (x *DeletedPhoto) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletedPhoto.ProtoReflect.Descriptor instead.
+func (*DeletedPhoto) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *DeletedPhoto) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+func (x *DeletedPhoto) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *DeletedPhoto) GetFileSize() int64 {
+	if x != nil {
+		return x.FileSize
+	}
+	return 0
+}
+
+func (x *DeletedPhoto) GetPlaceholder() *Placeholder {
+	if x != nil {
+		return x.Placeholder
+	}
+	return nil
+}
+
+func (x *DeletedPhoto) GetDeletedAt() int64 {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return 0
+}
+
+func (x *DeletedPhoto) GetPurgeAt() int64 {
+	if x != nil {
+		return x.PurgeAt
+	}
+	return 0
+}
+
+type ListDeletedPhotosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Photos        []*DeletedPhoto        `protobuf:"bytes,1,rep,name=photos,proto3" json:"photos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeletedPhotosResponse) Reset() {
+	*x = ListDeletedPhotosResponse{}
+	mi := &file_file_storage_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeletedPhotosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeletedPhotosResponse) ProtoMessage() {}
+
+func (x *ListDeletedPhotosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeletedPhotosResponse.ProtoReflect.Descriptor instead.
+func (*ListDeletedPhotosResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *ListDeletedPhotosResponse) GetPhotos() []*DeletedPhoto {
+	if x != nil {
+		return x.Photos
+	}
+	return nil
+}
+
+type RestorePhotoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PhotoId       string                 `protobuf:"bytes,2,opt,name=photo_id,json=photoId,proto3" json:"photo_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestorePhotoRequest) Reset() {
+	*x = RestorePhotoRequest{}
+	mi := &file_file_storage_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestorePhotoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestorePhotoRequest) ProtoMessage() {}
+
+func (x *RestorePhotoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestorePhotoRequest.ProtoReflect.Descriptor instead.
+func (*RestorePhotoRequest) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *RestorePhotoRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RestorePhotoRequest) GetPhotoId() string {
+	if x != nil {
+		return x.PhotoId
+	}
+	return ""
+}
+
+type RestorePhotoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestorePhotoResponse) Reset() {
+	*x = RestorePhotoResponse{}
+	mi := &file_file_storage_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestorePhotoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestorePhotoResponse) ProtoMessage() {}
+
+func (x *RestorePhotoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_file_storage_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestorePhotoResponse.ProtoReflect.Descriptor instead.
+func (*RestorePhotoResponse) Descriptor() ([]byte, []int) {
+	return file_file_storage_proto_rawDescGZIP(), []int{63}
+}
+
+var File_file_storage_proto protoreflect.FileDescriptor
+
+const file_file_storage_proto_rawDesc = "" +
+	"\n" +
+	"\x12file_storage.proto\x12\x05s3.v1\";\n" +
+	"\tChecksums\x12\x16\n" +
+	"\x06sha256\x18\x01 \x01(\tR\x06sha256\x12\x16\n" +
+	"\x06crc32c\x18\x02 \x01(\tR\x06crc32c\"\x94\x01\n" +
+	"\x05Photo\x12\x1b\n" +
+	"\tfile_data\x18\x01 \x01(\fR\bfileData\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x12.\n" +
+	"\tchecksums\x18\x04 \x01(\v2\x10.s3.v1.ChecksumsR\tchecksums\"\xbb\x01\n" +
+	"\x13UploadAvatarRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tfile_data\x18\x02 \x01(\fR\bfileData\x12\x1b\n" +
+	"\tfile_name\x18\x03 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12.\n" +
+	"\tchecksums\x18\x05 \x01(\v2\x10.s3.v1.ChecksumsR\tchecksums\"~\n" +
+	"\vPlaceholder\x12\x1a\n" +
+	"\bblurhash\x18\x01 \x01(\tR\bblurhash\x12%\n" +
+	"\x0edominant_color\x18\x02 \x01(\tR\rdominantColor\x12\x14\n" +
+	"\x05width\x18\x03 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\x05R\x06height\"g\n" +
+	"\x14UploadAvatarResponse\x12\x19\n" +
+	"\bphoto_id\x18\x01 \x01(\tR\aphotoId\x124\n" +
+	"\vplaceholder\x18\x02 \x01(\v2\x12.s3.v1.PlaceholderR\vplaceholder\"\x81\x01\n" +
+	"\x13UploadPhotosRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12$\n" +
+	"\x06photos\x18\x02 \x03(\v2\f.s3.v1.PhotoR\x06photos\x12+\n" +
+	"\x11reject_duplicates\x18\x03 \x01(\bR\x10rejectDuplicates\"k\n" +
+	"\x14UploadPhotosResponse\x12\x1b\n" +
+	"\tphoto_ids\x18\x01 \x03(\tR\bphotoIds\x126\n" +
+	"\fplaceholders\x18\x02 \x03(\v2\x12.s3.v1.PlaceholderR\fplaceholders\"\x92\x01\n" +
+	"\x12GetPhotoURLRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\x12%\n" +
+	"\x0eaccept_formats\x18\x03 \x03(\tR\racceptFormats\x12!\n" +
+	"\frequester_id\x18\x04 \x01(\tR\vrequesterId\"\x80\x01\n" +
+	"\x13GetPhotoURLResponse\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x124\n" +
+	"\vplaceholder\x18\x02 \x01(\v2\x12.s3.v1.PlaceholderR\vplaceholder\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\"\xc7\x01\n" +
+	"\x13GetPhotoURLsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tphoto_ids\x18\x02 \x03(\tR\bphotoIds\x12%\n" +
+	"\x0eaccept_formats\x18\x03 \x03(\tR\racceptFormats\x120\n" +
+	"\x14skip_existence_check\x18\x04 \x01(\bR\x12skipExistenceCheck\x12!\n" +
+	"\frequester_id\x18\x05 \x01(\tR\vrequesterId\"\xc0\x01\n" +
+	"\x0ePhotoURLResult\x12\x19\n" +
+	"\bphoto_id\x18\x01 \x01(\tR\aphotoId\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x124\n" +
+	"\vplaceholder\x18\x03 \x01(\v2\x12.s3.v1.PlaceholderR\vplaceholder\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04code\x18\x05 \x01(\x05R\x04code\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"G\n" +
+	"\x14GetPhotoURLsResponse\x12/\n" +
+	"\aresults\x18\x01 \x03(\v2\x15.s3.v1.PhotoURLResultR\aresults\"\xa0\x01\n" +
+	"\x12GetImageURLRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\x12\x14\n" +
+	"\x05width\x18\x03 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\x05R\x06height\x12\x10\n" +
+	"\x03fit\x18\x05 \x01(\tR\x03fit\x12\x16\n" +
+	"\x06format\x18\x06 \x01(\tR\x06format\"F\n" +
+	"\x13GetImageURLResponse\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\x03R\texpiresAt\"K\n" +
+	"\x15GetDownloadURLRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\"I\n" +
+	"\x16GetDownloadURLResponse\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\x03R\texpiresAt\"H\n" +
+	"\x12DeletePhotoRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\"\x15\n" +
+	"\x13DeletePhotoResponse\"l\n" +
+	"\x18FindSimilarPhotosRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\x12\x1c\n" +
+	"\tthreshold\x18\x03 \x01(\x05R\tthreshold\"E\n" +
+	"\fSimilarPhoto\x12\x19\n" +
+	"\bphoto_id\x18\x01 \x01(\tR\aphotoId\x12\x1a\n" +
+	"\bdistance\x18\x02 \x01(\x05R\bdistance\"H\n" +
+	"\x19FindSimilarPhotosResponse\x12+\n" +
+	"\x06photos\x18\x01 \x03(\v2\x13.s3.v1.SimilarPhotoR\x06photos\"\x82\x01\n" +
+	"\x19SetPhotoVisibilityRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\x121\n" +
+	"\n" +
+	"visibility\x18\x03 \x01(\x0e2\x11.s3.v1.VisibilityR\n" +
+	"visibility\"\x1c\n" +
+	"\x1aSetPhotoVisibilityResponse\"\x9d\x01\n" +
+	"\x16CreateShareLinkRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\x12,\n" +
+	"\x12expires_in_seconds\x18\x03 \x01(\x03R\x10expiresInSeconds\x12!\n" +
+	"\fmax_accesses\x18\x04 \x01(\x05R\vmaxAccesses\"`\n" +
+	"\x17CreateShareLinkResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\"G\n" +
+	"\x16RevokeShareLinkRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\"\x19\n" +
+	"\x17RevokeShareLinkResponse\"\xbd\x01\n" +
+	"\x15UploadDocumentRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tfile_data\x18\x02 \x01(\fR\bfileData\x12\x1b\n" +
+	"\tfile_name\x18\x03 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12.\n" +
+	"\tchecksums\x18\x05 \x01(\v2\x10.s3.v1.ChecksumsR\tchecksums\"9\n" +
+	"\x16UploadDocumentResponse\x12\x1f\n" +
+	"\vdocument_id\x18\x01 \x01(\tR\n" +
+	"documentId\"Q\n" +
+	"\x15GetDocumentURLRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1f\n" +
+	"\vdocument_id\x18\x02 \x01(\tR\n" +
+	"documentId\"I\n" +
+	"\x16GetDocumentURLResponse\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\x03R\texpiresAt\"Q\n" +
+	"\x15DeleteDocumentRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1f\n" +
+	"\vdocument_id\x18\x02 \x01(\tR\n" +
+	"documentId\"\x18\n" +
+	"\x16DeleteDocumentResponse\"V\n" +
+	"\x18ListPendingPhotosRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"\x96\x01\n" +
+	"\fPendingPhoto\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\"p\n" +
+	"\x19ListPendingPhotosResponse\x12+\n" +
+	"\x06photos\x18\x01 \x03(\v2\x13.s3.v1.PendingPhotoR\x06photos\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x99\x01\n" +
+	"\x14ModeratePhotoRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\x125\n" +
+	"\bdecision\x18\x03 \x01(\x0e2\x19.s3.v1.ModerationDecisionR\bdecision\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\x17\n" +
+	"\x15ModeratePhotoResponse\"N\n" +
+	"\x16CreateUploadURLRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\"e\n" +
+	"\x17CreateUploadURLResponse\x12\x19\n" +
+	"\bphoto_id\x18\x01 \x01(\tR\aphotoId\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\"@\n" +
+	"\x14CreateWebhookRequest\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x16\n" +
+	"\x06events\x18\x02 \x03(\tR\x06events\"N\n" +
+	"\x15CreateWebhookResponse\x12\x1d\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tR\twebhookId\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"5\n" +
+	"\x14DeleteWebhookRequest\x12\x1d\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tR\twebhookId\"\x17\n" +
+	"\x15DeleteWebhookResponse\"\x15\n" +
+	"\x13ListWebhooksRequest\"q\n" +
+	"\aWebhook\x12\x1d\n" +
+	"\n" +
+	"webhook_id\x18\x01 \x01(\tR\twebhookId\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x16\n" +
+	"\x06events\x18\x03 \x03(\tR\x06events\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\"B\n" +
+	"\x14ListWebhooksResponse\x12*\n" +
+	"\bwebhooks\x18\x01 \x03(\v2\x0e.s3.v1.WebhookR\bwebhooks\"\"\n" +
+	" ListDeadWebhookDeliveriesRequest\"\xe5\x01\n" +
+	"\x0fWebhookDelivery\x12\x1f\n" +
+	"\vdelivery_id\x18\x01 \x01(\tR\n" +
+	"deliveryId\x12\x1d\n" +
+	"\n" +
+	"webhook_id\x18\x02 \x01(\tR\twebhookId\x12\x19\n" +
+	"\bevent_id\x18\x03 \x01(\tR\aeventId\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x04 \x01(\tR\teventType\x12\x1a\n" +
+	"\battempts\x18\x05 \x01(\x05R\battempts\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x06 \x01(\tR\tlastError\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\"[\n" +
+	"!ListDeadWebhookDeliveriesResponse\x126\n" +
+	"\n" +
+	"deliveries\x18\x01 \x03(\v2\x16.s3.v1.WebhookDeliveryR\n" +
+	"deliveries\"7\n" +
+	"\x14ReplayWebhookRequest\x12\x1f\n" +
+	"\vdelivery_id\x18\x01 \x01(\tR\n" +
+	"deliveryId\"\x17\n" +
+	"\x15ReplayWebhookResponse\"3\n" +
+	"\x18DeleteAllUserDataRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\":\n" +
+	"\x19DeleteAllUserDataResponse\x12\x1d\n" +
+	"\n" +
+	"erasure_id\x18\x01 \x01(\tR\terasureId\"8\n" +
+	"\x17GetErasureStatusRequest\x12\x1d\n" +
+	"\n" +
+	"erasure_id\x18\x01 \x01(\tR\terasureId\"\xc2\x01\n" +
+	"\x0eErasureReceipt\x12\x1d\n" +
+	"\n" +
+	"erasure_id\x18\x01 \x01(\tR\terasureId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x18\n" +
+	"\adeleted\x18\x03 \x01(\x03R\adeleted\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x04 \x01(\x03R\tstartedAt\x12!\n" +
+	"\fcompleted_at\x18\x05 \x01(\x03R\vcompletedAt\x12\x1c\n" +
+	"\tsignature\x18\x06 \x01(\tR\tsignature\"\xc2\x01\n" +
+	"\x18GetErasureStatusResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x14\n" +
+	"\x05stage\x18\x03 \x01(\tR\x05stage\x12\x18\n" +
+	"\adeleted\x18\x04 \x01(\x03R\adeleted\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12/\n" +
+	"\areceipt\x18\x06 \x01(\v2\x15.s3.v1.ErasureReceiptR\areceipt\"0\n" +
+	"\x15ExportUserDataRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"]\n" +
+	"\x16ExportUserDataResponse\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\"3\n" +
+	"\x18ListDeletedPhotosRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"\xd9\x01\n" +
+	"\fDeletedPhoto\x12\x19\n" +
+	"\bphoto_id\x18\x01 \x01(\tR\aphotoId\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x1b\n" +
+	"\tfile_size\x18\x03 \x01(\x03R\bfileSize\x124\n" +
+	"\vplaceholder\x18\x04 \x01(\v2\x12.s3.v1.PlaceholderR\vplaceholder\x12\x1d\n" +
+	"\n" +
+	"deleted_at\x18\x05 \x01(\x03R\tdeletedAt\x12\x19\n" +
+	"\bpurge_at\x18\x06 \x01(\x03R\apurgeAt\"H\n" +
+	"\x19ListDeletedPhotosResponse\x12+\n" +
+	"\x06photos\x18\x01 \x03(\v2\x13.s3.v1.DeletedPhotoR\x06photos\"I\n" +
+	"\x13RestorePhotoRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bphoto_id\x18\x02 \x01(\tR\aphotoId\"\x16\n" +
+	"\x14RestorePhotoResponse*l\n" +
+	"\n" +
+	"Visibility\x12\x1a\n" +
+	"\x16VISIBILITY_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12VISIBILITY_PRIVATE\x10\x01\x12\x13\n" +
+	"\x0fVISIBILITY_LINK\x10\x02\x12\x15\n" +
+	"\x11VISIBILITY_PUBLIC\x10\x03*z\n" +
+	"\x12ModerationDecision\x12#\n" +
+	"\x1fMODERATION_DECISION_UNSPECIFIED\x10\x00\x12\x1f\n" +
+	"\x1bMODERATION_DECISION_APPROVE\x10\x01\x12\x1e\n" +
+	"\x1aMODERATION_DECISION_REJECT\x10\x022\x84\x11\n" +
 	"\x12FileStorageService\x12G\n" +
 	"\fUploadAvatar\x12\x1a.s3.v1.UploadAvatarRequest\x1a\x1b.s3.v1.UploadAvatarResponse\x12G\n" +
 	"\fUploadPhotos\x12\x1a.s3.v1.UploadPhotosRequest\x1a\x1b.s3.v1.UploadPhotosResponse\x12D\n" +
-	"\vGetPhotoURL\x12\x19.s3.v1.GetPhotoURLRequest\x1a\x1a.s3.v1.GetPhotoURLResponseB\fZ\n" +
+	"\vGetPhotoURL\x12\x19.s3.v1.GetPhotoURLRequest\x1a\x1a.s3.v1.GetPhotoURLResponse\x12G\n" +
+	"\fGetPhotoURLs\x12\x1a.s3.v1.GetPhotoURLsRequest\x1a\x1b.s3.v1.GetPhotoURLsResponse\x12D\n" +
+	"\vGetImageURL\x12\x19.s3.v1.GetImageURLRequest\x1a\x1a.s3.v1.GetImageURLResponse\x12M\n" +
+	"\x0eGetDownloadURL\x12\x1c.s3.v1.GetDownloadURLRequest\x1a\x1d.s3.v1.GetDownloadURLResponse\x12D\n" +
+	"\vDeletePhoto\x12\x19.s3.v1.DeletePhotoRequest\x1a\x1a.s3.v1.DeletePhotoResponse\x12V\n" +
+	"\x11FindSimilarPhotos\x12\x1f.s3.v1.FindSimilarPhotosRequest\x1a .s3.v1.FindSimilarPhotosResponse\x12Y\n" +
+	"\x12SetPhotoVisibility\x12 .s3.v1.SetPhotoVisibilityRequest\x1a!.s3.v1.SetPhotoVisibilityResponse\x12P\n" +
+	"\x0fCreateShareLink\x12\x1d.s3.v1.CreateShareLinkRequest\x1a\x1e.s3.v1.CreateShareLinkResponse\x12P\n" +
+	"\x0fRevokeShareLink\x12\x1d.s3.v1.RevokeShareLinkRequest\x1a\x1e.s3.v1.RevokeShareLinkResponse\x12M\n" +
+	"\x0eUploadDocument\x12\x1c.s3.v1.UploadDocumentRequest\x1a\x1d.s3.v1.UploadDocumentResponse\x12M\n" +
+	"\x0eGetDocumentURL\x12\x1c.s3.v1.GetDocumentURLRequest\x1a\x1d.s3.v1.GetDocumentURLResponse\x12M\n" +
+	"\x0eDeleteDocument\x12\x1c.s3.v1.DeleteDocumentRequest\x1a\x1d.s3.v1.DeleteDocumentResponse\x12V\n" +
+	"\x11ListPendingPhotos\x12\x1f.s3.v1.ListPendingPhotosRequest\x1a .s3.v1.ListPendingPhotosResponse\x12J\n" +
+	"\rModeratePhoto\x12\x1b.s3.v1.ModeratePhotoRequest\x1a\x1c.s3.v1.ModeratePhotoResponse\x12P\n" +
+	"\x0fCreateUploadURL\x12\x1d.s3.v1.CreateUploadURLRequest\x1a\x1e.s3.v1.CreateUploadURLResponse\x12J\n" +
+	"\rCreateWebhook\x12\x1b.s3.v1.CreateWebhookRequest\x1a\x1c.s3.v1.CreateWebhookResponse\x12J\n" +
+	"\rDeleteWebhook\x12\x1b.s3.v1.DeleteWebhookRequest\x1a\x1c.s3.v1.DeleteWebhookResponse\x12G\n" +
+	"\fListWebhooks\x12\x1a.s3.v1.ListWebhooksRequest\x1a\x1b.s3.v1.ListWebhooksResponse\x12n\n" +
+	"\x19ListDeadWebhookDeliveries\x12'.s3.v1.ListDeadWebhookDeliveriesRequest\x1a(.s3.v1.ListDeadWebhookDeliveriesResponse\x12J\n" +
+	"\rReplayWebhook\x12\x1b.s3.v1.ReplayWebhookRequest\x1a\x1c.s3.v1.ReplayWebhookResponse\x12V\n" +
+	"\x11DeleteAllUserData\x12\x1f.s3.v1.DeleteAllUserDataRequest\x1a .s3.v1.DeleteAllUserDataResponse\x12S\n" +
+	"\x10GetErasureStatus\x12\x1e.s3.v1.GetErasureStatusRequest\x1a\x1f.s3.v1.GetErasureStatusResponse\x12M\n" +
+	"\x0eExportUserData\x12\x1c.s3.v1.ExportUserDataRequest\x1a\x1d.s3.v1.ExportUserDataResponse\x12V\n" +
+	"\x11ListDeletedPhotos\x12\x1f.s3.v1.ListDeletedPhotosRequest\x1a .s3.v1.ListDeletedPhotosResponse\x12G\n" +
+	"\fRestorePhoto\x12\x1a.s3.v1.RestorePhotoRequest\x1a\x1b.s3.v1.RestorePhotoResponseB\fZ\n" +
 	"s3.v1;s3v1b\x06proto3"
 
 var (
@@ -429,29 +3936,154 @@ func file_file_storage_proto_rawDescGZIP() []byte {
 	return file_file_storage_proto_rawDescData
 }
 
-var file_file_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_file_storage_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_file_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 64)
 var file_file_storage_proto_goTypes = []any{
-	(*Photo)(nil),                // 0: s3.v1.Photo
-	(*UploadAvatarRequest)(nil),  // 1: s3.v1.UploadAvatarRequest
-	(*UploadAvatarResponse)(nil), // 2: s3.v1.UploadAvatarResponse
-	(*UploadPhotosRequest)(nil),  // 3: s3.v1.UploadPhotosRequest
-	(*UploadPhotosResponse)(nil), // 4: s3.v1.UploadPhotosResponse
-	(*GetPhotoURLRequest)(nil),   // 5: s3.v1.GetPhotoURLRequest
-	(*GetPhotoURLResponse)(nil),  // 6: s3.v1.GetPhotoURLResponse
+	(Visibility)(0),                           // 0: s3.v1.Visibility
+	(ModerationDecision)(0),                   // 1: s3.v1.ModerationDecision
+	(*Checksums)(nil),                         // 2: s3.v1.Checksums
+	(*Photo)(nil),                             // 3: s3.v1.Photo
+	(*UploadAvatarRequest)(nil),               // 4: s3.v1.UploadAvatarRequest
+	(*Placeholder)(nil),                       // 5: s3.v1.Placeholder
+	(*UploadAvatarResponse)(nil),              // 6: s3.v1.UploadAvatarResponse
+	(*UploadPhotosRequest)(nil),               // 7: s3.v1.UploadPhotosRequest
+	(*UploadPhotosResponse)(nil),              // 8: s3.v1.UploadPhotosResponse
+	(*GetPhotoURLRequest)(nil),                // 9: s3.v1.GetPhotoURLRequest
+	(*GetPhotoURLResponse)(nil),               // 10: s3.v1.GetPhotoURLResponse
+	(*GetPhotoURLsRequest)(nil),               // 11: s3.v1.GetPhotoURLsRequest
+	(*PhotoURLResult)(nil),                    // 12: s3.v1.PhotoURLResult
+	(*GetPhotoURLsResponse)(nil),              // 13: s3.v1.GetPhotoURLsResponse
+	(*GetImageURLRequest)(nil),                // 14: s3.v1.GetImageURLRequest
+	(*GetImageURLResponse)(nil),               // 15: s3.v1.GetImageURLResponse
+	(*GetDownloadURLRequest)(nil),             // 16: s3.v1.GetDownloadURLRequest
+	(*GetDownloadURLResponse)(nil),            // 17: s3.v1.GetDownloadURLResponse
+	(*DeletePhotoRequest)(nil),                // 18: s3.v1.DeletePhotoRequest
+	(*DeletePhotoResponse)(nil),               // 19: s3.v1.DeletePhotoResponse
+	(*FindSimilarPhotosRequest)(nil),          // 20: s3.v1.FindSimilarPhotosRequest
+	(*SimilarPhoto)(nil),                      // 21: s3.v1.SimilarPhoto
+	(*FindSimilarPhotosResponse)(nil),         // 22: s3.v1.FindSimilarPhotosResponse
+	(*SetPhotoVisibilityRequest)(nil),         // 23: s3.v1.SetPhotoVisibilityRequest
+	(*SetPhotoVisibilityResponse)(nil),        // 24: s3.v1.SetPhotoVisibilityResponse
+	(*CreateShareLinkRequest)(nil),            // 25: s3.v1.CreateShareLinkRequest
+	(*CreateShareLinkResponse)(nil),           // 26: s3.v1.CreateShareLinkResponse
+	(*RevokeShareLinkRequest)(nil),            // 27: s3.v1.RevokeShareLinkRequest
+	(*RevokeShareLinkResponse)(nil),           // 28: s3.v1.RevokeShareLinkResponse
+	(*UploadDocumentRequest)(nil),             // 29: s3.v1.UploadDocumentRequest
+	(*UploadDocumentResponse)(nil),            // 30: s3.v1.UploadDocumentResponse
+	(*GetDocumentURLRequest)(nil),             // 31: s3.v1.GetDocumentURLRequest
+	(*GetDocumentURLResponse)(nil),            // 32: s3.v1.GetDocumentURLResponse
+	(*DeleteDocumentRequest)(nil),             // 33: s3.v1.DeleteDocumentRequest
+	(*DeleteDocumentResponse)(nil),            // 34: s3.v1.DeleteDocumentResponse
+	(*ListPendingPhotosRequest)(nil),          // 35: s3.v1.ListPendingPhotosRequest
+	(*PendingPhoto)(nil),                      // 36: s3.v1.PendingPhoto
+	(*ListPendingPhotosResponse)(nil),         // 37: s3.v1.ListPendingPhotosResponse
+	(*ModeratePhotoRequest)(nil),              // 38: s3.v1.ModeratePhotoRequest
+	(*ModeratePhotoResponse)(nil),             // 39: s3.v1.ModeratePhotoResponse
+	(*CreateUploadURLRequest)(nil),            // 40: s3.v1.CreateUploadURLRequest
+	(*CreateUploadURLResponse)(nil),           // 41: s3.v1.CreateUploadURLResponse
+	(*CreateWebhookRequest)(nil),              // 42: s3.v1.CreateWebhookRequest
+	(*CreateWebhookResponse)(nil),             // 43: s3.v1.CreateWebhookResponse
+	(*DeleteWebhookRequest)(nil),              // 44: s3.v1.DeleteWebhookRequest
+	(*DeleteWebhookResponse)(nil),             // 45: s3.v1.DeleteWebhookResponse
+	(*ListWebhooksRequest)(nil),               // 46: s3.v1.ListWebhooksRequest
+	(*Webhook)(nil),                           // 47: s3.v1.Webhook
+	(*ListWebhooksResponse)(nil),              // 48: s3.v1.ListWebhooksResponse
+	(*ListDeadWebhookDeliveriesRequest)(nil),  // 49: s3.v1.ListDeadWebhookDeliveriesRequest
+	(*WebhookDelivery)(nil),                   // 50: s3.v1.WebhookDelivery
+	(*ListDeadWebhookDeliveriesResponse)(nil), // 51: s3.v1.ListDeadWebhookDeliveriesResponse
+	(*ReplayWebhookRequest)(nil),              // 52: s3.v1.ReplayWebhookRequest
+	(*ReplayWebhookResponse)(nil),             // 53: s3.v1.ReplayWebhookResponse
+	(*DeleteAllUserDataRequest)(nil),          // 54: s3.v1.DeleteAllUserDataRequest
+	(*DeleteAllUserDataResponse)(nil),         // 55: s3.v1.DeleteAllUserDataResponse
+	(*GetErasureStatusRequest)(nil),           // 56: s3.v1.GetErasureStatusRequest
+	(*ErasureReceipt)(nil),                    // 57: s3.v1.ErasureReceipt
+	(*GetErasureStatusResponse)(nil),          // 58: s3.v1.GetErasureStatusResponse
+	(*ExportUserDataRequest)(nil),             // 59: s3.v1.ExportUserDataRequest
+	(*ExportUserDataResponse)(nil),            // 60: s3.v1.ExportUserDataResponse
+	(*ListDeletedPhotosRequest)(nil),          // 61: s3.v1.ListDeletedPhotosRequest
+	(*DeletedPhoto)(nil),                      // 62: s3.v1.DeletedPhoto
+	(*ListDeletedPhotosResponse)(nil),         // 63: s3.v1.ListDeletedPhotosResponse
+	(*RestorePhotoRequest)(nil),               // 64: s3.v1.RestorePhotoRequest
+	(*RestorePhotoResponse)(nil),              // 65: s3.v1.RestorePhotoResponse
 }
 var file_file_storage_proto_depIdxs = []int32{
-	0, // 0: s3.v1.UploadPhotosRequest.photos:type_name -> s3.v1.Photo
-	1, // 1: s3.v1.FileStorageService.UploadAvatar:input_type -> s3.v1.UploadAvatarRequest
-	3, // 2: s3.v1.FileStorageService.UploadPhotos:input_type -> s3.v1.UploadPhotosRequest
-	5, // 3: s3.v1.FileStorageService.GetPhotoURL:input_type -> s3.v1.GetPhotoURLRequest
-	2, // 4: s3.v1.FileStorageService.UploadAvatar:output_type -> s3.v1.UploadAvatarResponse
-	4, // 5: s3.v1.FileStorageService.UploadPhotos:output_type -> s3.v1.UploadPhotosResponse
-	6, // 6: s3.v1.FileStorageService.GetPhotoURL:output_type -> s3.v1.GetPhotoURLResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	2,  // 0: s3.v1.Photo.checksums:type_name -> s3.v1.Checksums
+	2,  // 1: s3.v1.UploadAvatarRequest.checksums:type_name -> s3.v1.Checksums
+	5,  // 2: s3.v1.UploadAvatarResponse.placeholder:type_name -> s3.v1.Placeholder
+	3,  // 3: s3.v1.UploadPhotosRequest.photos:type_name -> s3.v1.Photo
+	5,  // 4: s3.v1.UploadPhotosResponse.placeholders:type_name -> s3.v1.Placeholder
+	5,  // 5: s3.v1.GetPhotoURLResponse.placeholder:type_name -> s3.v1.Placeholder
+	5,  // 6: s3.v1.PhotoURLResult.placeholder:type_name -> s3.v1.Placeholder
+	12, // 7: s3.v1.GetPhotoURLsResponse.results:type_name -> s3.v1.PhotoURLResult
+	21, // 8: s3.v1.FindSimilarPhotosResponse.photos:type_name -> s3.v1.SimilarPhoto
+	0,  // 9: s3.v1.SetPhotoVisibilityRequest.visibility:type_name -> s3.v1.Visibility
+	2,  // 10: s3.v1.UploadDocumentRequest.checksums:type_name -> s3.v1.Checksums
+	36, // 11: s3.v1.ListPendingPhotosResponse.photos:type_name -> s3.v1.PendingPhoto
+	1,  // 12: s3.v1.ModeratePhotoRequest.decision:type_name -> s3.v1.ModerationDecision
+	47, // 13: s3.v1.ListWebhooksResponse.webhooks:type_name -> s3.v1.Webhook
+	50, // 14: s3.v1.ListDeadWebhookDeliveriesResponse.deliveries:type_name -> s3.v1.WebhookDelivery
+	57, // 15: s3.v1.GetErasureStatusResponse.receipt:type_name -> s3.v1.ErasureReceipt
+	5,  // 16: s3.v1.DeletedPhoto.placeholder:type_name -> s3.v1.Placeholder
+	62, // 17: s3.v1.ListDeletedPhotosResponse.photos:type_name -> s3.v1.DeletedPhoto
+	4,  // 18: s3.v1.FileStorageService.UploadAvatar:input_type -> s3.v1.UploadAvatarRequest
+	7,  // 19: s3.v1.FileStorageService.UploadPhotos:input_type -> s3.v1.UploadPhotosRequest
+	9,  // 20: s3.v1.FileStorageService.GetPhotoURL:input_type -> s3.v1.GetPhotoURLRequest
+	11, // 21: s3.v1.FileStorageService.GetPhotoURLs:input_type -> s3.v1.GetPhotoURLsRequest
+	14, // 22: s3.v1.FileStorageService.GetImageURL:input_type -> s3.v1.GetImageURLRequest
+	16, // 23: s3.v1.FileStorageService.GetDownloadURL:input_type -> s3.v1.GetDownloadURLRequest
+	18, // 24: s3.v1.FileStorageService.DeletePhoto:input_type -> s3.v1.DeletePhotoRequest
+	20, // 25: s3.v1.FileStorageService.FindSimilarPhotos:input_type -> s3.v1.FindSimilarPhotosRequest
+	23, // 26: s3.v1.FileStorageService.SetPhotoVisibility:input_type -> s3.v1.SetPhotoVisibilityRequest
+	25, // 27: s3.v1.FileStorageService.CreateShareLink:input_type -> s3.v1.CreateShareLinkRequest
+	27, // 28: s3.v1.FileStorageService.RevokeShareLink:input_type -> s3.v1.RevokeShareLinkRequest
+	29, // 29: s3.v1.FileStorageService.UploadDocument:input_type -> s3.v1.UploadDocumentRequest
+	31, // 30: s3.v1.FileStorageService.GetDocumentURL:input_type -> s3.v1.GetDocumentURLRequest
+	33, // 31: s3.v1.FileStorageService.DeleteDocument:input_type -> s3.v1.DeleteDocumentRequest
+	35, // 32: s3.v1.FileStorageService.ListPendingPhotos:input_type -> s3.v1.ListPendingPhotosRequest
+	38, // 33: s3.v1.FileStorageService.ModeratePhoto:input_type -> s3.v1.ModeratePhotoRequest
+	40, // 34: s3.v1.FileStorageService.CreateUploadURL:input_type -> s3.v1.CreateUploadURLRequest
+	42, // 35: s3.v1.FileStorageService.CreateWebhook:input_type -> s3.v1.CreateWebhookRequest
+	44, // 36: s3.v1.FileStorageService.DeleteWebhook:input_type -> s3.v1.DeleteWebhookRequest
+	46, // 37: s3.v1.FileStorageService.ListWebhooks:input_type -> s3.v1.ListWebhooksRequest
+	49, // 38: s3.v1.FileStorageService.ListDeadWebhookDeliveries:input_type -> s3.v1.ListDeadWebhookDeliveriesRequest
+	52, // 39: s3.v1.FileStorageService.ReplayWebhook:input_type -> s3.v1.ReplayWebhookRequest
+	54, // 40: s3.v1.FileStorageService.DeleteAllUserData:input_type -> s3.v1.DeleteAllUserDataRequest
+	56, // 41: s3.v1.FileStorageService.GetErasureStatus:input_type -> s3.v1.GetErasureStatusRequest
+	59, // 42: s3.v1.FileStorageService.ExportUserData:input_type -> s3.v1.ExportUserDataRequest
+	61, // 43: s3.v1.FileStorageService.ListDeletedPhotos:input_type -> s3.v1.ListDeletedPhotosRequest
+	64, // 44: s3.v1.FileStorageService.RestorePhoto:input_type -> s3.v1.RestorePhotoRequest
+	6,  // 45: s3.v1.FileStorageService.UploadAvatar:output_type -> s3.v1.UploadAvatarResponse
+	8,  // 46: s3.v1.FileStorageService.UploadPhotos:output_type -> s3.v1.UploadPhotosResponse
+	10, // 47: s3.v1.FileStorageService.GetPhotoURL:output_type -> s3.v1.GetPhotoURLResponse
+	13, // 48: s3.v1.FileStorageService.GetPhotoURLs:output_type -> s3.v1.GetPhotoURLsResponse
+	15, // 49: s3.v1.FileStorageService.GetImageURL:output_type -> s3.v1.GetImageURLResponse
+	17, // 50: s3.v1.FileStorageService.GetDownloadURL:output_type -> s3.v1.GetDownloadURLResponse
+	19, // 51: s3.v1.FileStorageService.DeletePhoto:output_type -> s3.v1.DeletePhotoResponse
+	22, // 52: s3.v1.FileStorageService.FindSimilarPhotos:output_type -> s3.v1.FindSimilarPhotosResponse
+	24, // 53: s3.v1.FileStorageService.SetPhotoVisibility:output_type -> s3.v1.SetPhotoVisibilityResponse
+	26, // 54: s3.v1.FileStorageService.CreateShareLink:output_type -> s3.v1.CreateShareLinkResponse
+	28, // 55: s3.v1.FileStorageService.RevokeShareLink:output_type -> s3.v1.RevokeShareLinkResponse
+	30, // 56: s3.v1.FileStorageService.UploadDocument:output_type -> s3.v1.UploadDocumentResponse
+	32, // 57: s3.v1.FileStorageService.GetDocumentURL:output_type -> s3.v1.GetDocumentURLResponse
+	34, // 58: s3.v1.FileStorageService.DeleteDocument:output_type -> s3.v1.DeleteDocumentResponse
+	37, // 59: s3.v1.FileStorageService.ListPendingPhotos:output_type -> s3.v1.ListPendingPhotosResponse
+	39, // 60: s3.v1.FileStorageService.ModeratePhoto:output_type -> s3.v1.ModeratePhotoResponse
+	41, // 61: s3.v1.FileStorageService.CreateUploadURL:output_type -> s3.v1.CreateUploadURLResponse
+	43, // 62: s3.v1.FileStorageService.CreateWebhook:output_type -> s3.v1.CreateWebhookResponse
+	45, // 63: s3.v1.FileStorageService.DeleteWebhook:output_type -> s3.v1.DeleteWebhookResponse
+	48, // 64: s3.v1.FileStorageService.ListWebhooks:output_type -> s3.v1.ListWebhooksResponse
+	51, // 65: s3.v1.FileStorageService.ListDeadWebhookDeliveries:output_type -> s3.v1.ListDeadWebhookDeliveriesResponse
+	53, // 66: s3.v1.FileStorageService.ReplayWebhook:output_type -> s3.v1.ReplayWebhookResponse
+	55, // 67: s3.v1.FileStorageService.DeleteAllUserData:output_type -> s3.v1.DeleteAllUserDataResponse
+	58, // 68: s3.v1.FileStorageService.GetErasureStatus:output_type -> s3.v1.GetErasureStatusResponse
+	60, // 69: s3.v1.FileStorageService.ExportUserData:output_type -> s3.v1.ExportUserDataResponse
+	63, // 70: s3.v1.FileStorageService.ListDeletedPhotos:output_type -> s3.v1.ListDeletedPhotosResponse
+	65, // 71: s3.v1.FileStorageService.RestorePhoto:output_type -> s3.v1.RestorePhotoResponse
+	45, // [45:72] is the sub-list for method output_type
+	18, // [18:45] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_file_storage_proto_init() }
@@ -464,13 +4096,14 @@ func file_file_storage_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_file_storage_proto_rawDesc), len(file_file_storage_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      2,
+			NumMessages:   64,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_file_storage_proto_goTypes,
 		DependencyIndexes: file_file_storage_proto_depIdxs,
+		EnumInfos:         file_file_storage_proto_enumTypes,
 		MessageInfos:      file_file_storage_proto_msgTypes,
 	}.Build()
 	File_file_storage_proto = out.File
@@ -19,9 +19,33 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	FileStorageService_UploadAvatar_FullMethodName = "/s3.v1.FileStorageService/UploadAvatar"
-	FileStorageService_UploadPhotos_FullMethodName = "/s3.v1.FileStorageService/UploadPhotos"
-	FileStorageService_GetPhotoURL_FullMethodName  = "/s3.v1.FileStorageService/GetPhotoURL"
+	FileStorageService_UploadAvatar_FullMethodName              = "/s3.v1.FileStorageService/UploadAvatar"
+	FileStorageService_UploadPhotos_FullMethodName              = "/s3.v1.FileStorageService/UploadPhotos"
+	FileStorageService_GetPhotoURL_FullMethodName               = "/s3.v1.FileStorageService/GetPhotoURL"
+	FileStorageService_GetPhotoURLs_FullMethodName              = "/s3.v1.FileStorageService/GetPhotoURLs"
+	FileStorageService_GetImageURL_FullMethodName               = "/s3.v1.FileStorageService/GetImageURL"
+	FileStorageService_GetDownloadURL_FullMethodName            = "/s3.v1.FileStorageService/GetDownloadURL"
+	FileStorageService_DeletePhoto_FullMethodName               = "/s3.v1.FileStorageService/DeletePhoto"
+	FileStorageService_FindSimilarPhotos_FullMethodName         = "/s3.v1.FileStorageService/FindSimilarPhotos"
+	FileStorageService_SetPhotoVisibility_FullMethodName        = "/s3.v1.FileStorageService/SetPhotoVisibility"
+	FileStorageService_CreateShareLink_FullMethodName           = "/s3.v1.FileStorageService/CreateShareLink"
+	FileStorageService_RevokeShareLink_FullMethodName           = "/s3.v1.FileStorageService/RevokeShareLink"
+	FileStorageService_UploadDocument_FullMethodName            = "/s3.v1.FileStorageService/UploadDocument"
+	FileStorageService_GetDocumentURL_FullMethodName            = "/s3.v1.FileStorageService/GetDocumentURL"
+	FileStorageService_DeleteDocument_FullMethodName            = "/s3.v1.FileStorageService/DeleteDocument"
+	FileStorageService_ListPendingPhotos_FullMethodName         = "/s3.v1.FileStorageService/ListPendingPhotos"
+	FileStorageService_ModeratePhoto_FullMethodName             = "/s3.v1.FileStorageService/ModeratePhoto"
+	FileStorageService_CreateUploadURL_FullMethodName           = "/s3.v1.FileStorageService/CreateUploadURL"
+	FileStorageService_CreateWebhook_FullMethodName             = "/s3.v1.FileStorageService/CreateWebhook"
+	FileStorageService_DeleteWebhook_FullMethodName             = "/s3.v1.FileStorageService/DeleteWebhook"
+	FileStorageService_ListWebhooks_FullMethodName              = "/s3.v1.FileStorageService/ListWebhooks"
+	FileStorageService_ListDeadWebhookDeliveries_FullMethodName = "/s3.v1.FileStorageService/ListDeadWebhookDeliveries"
+	FileStorageService_ReplayWebhook_FullMethodName             = "/s3.v1.FileStorageService/ReplayWebhook"
+	FileStorageService_DeleteAllUserData_FullMethodName         = "/s3.v1.FileStorageService/DeleteAllUserData"
+	FileStorageService_GetErasureStatus_FullMethodName          = "/s3.v1.FileStorageService/GetErasureStatus"
+	FileStorageService_ExportUserData_FullMethodName            = "/s3.v1.FileStorageService/ExportUserData"
+	FileStorageService_ListDeletedPhotos_FullMethodName         = "/s3.v1.FileStorageService/ListDeletedPhotos"
+	FileStorageService_RestorePhoto_FullMethodName              = "/s3.v1.FileStorageService/RestorePhoto"
 )
 
 // FileStorageServiceClient is the client API for FileStorageService service.
@@ -31,6 +55,30 @@ type FileStorageServiceClient interface {
 	UploadAvatar(ctx context.Context, in *UploadAvatarRequest, opts ...grpc.CallOption) (*UploadAvatarResponse, error)
 	UploadPhotos(ctx context.Context, in *UploadPhotosRequest, opts ...grpc.CallOption) (*UploadPhotosResponse, error)
 	GetPhotoURL(ctx context.Context, in *GetPhotoURLRequest, opts ...grpc.CallOption) (*GetPhotoURLResponse, error)
+	GetPhotoURLs(ctx context.Context, in *GetPhotoURLsRequest, opts ...grpc.CallOption) (*GetPhotoURLsResponse, error)
+	GetImageURL(ctx context.Context, in *GetImageURLRequest, opts ...grpc.CallOption) (*GetImageURLResponse, error)
+	GetDownloadURL(ctx context.Context, in *GetDownloadURLRequest, opts ...grpc.CallOption) (*GetDownloadURLResponse, error)
+	DeletePhoto(ctx context.Context, in *DeletePhotoRequest, opts ...grpc.CallOption) (*DeletePhotoResponse, error)
+	FindSimilarPhotos(ctx context.Context, in *FindSimilarPhotosRequest, opts ...grpc.CallOption) (*FindSimilarPhotosResponse, error)
+	SetPhotoVisibility(ctx context.Context, in *SetPhotoVisibilityRequest, opts ...grpc.CallOption) (*SetPhotoVisibilityResponse, error)
+	CreateShareLink(ctx context.Context, in *CreateShareLinkRequest, opts ...grpc.CallOption) (*CreateShareLinkResponse, error)
+	RevokeShareLink(ctx context.Context, in *RevokeShareLinkRequest, opts ...grpc.CallOption) (*RevokeShareLinkResponse, error)
+	UploadDocument(ctx context.Context, in *UploadDocumentRequest, opts ...grpc.CallOption) (*UploadDocumentResponse, error)
+	GetDocumentURL(ctx context.Context, in *GetDocumentURLRequest, opts ...grpc.CallOption) (*GetDocumentURLResponse, error)
+	DeleteDocument(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DeleteDocumentResponse, error)
+	ListPendingPhotos(ctx context.Context, in *ListPendingPhotosRequest, opts ...grpc.CallOption) (*ListPendingPhotosResponse, error)
+	ModeratePhoto(ctx context.Context, in *ModeratePhotoRequest, opts ...grpc.CallOption) (*ModeratePhotoResponse, error)
+	CreateUploadURL(ctx context.Context, in *CreateUploadURLRequest, opts ...grpc.CallOption) (*CreateUploadURLResponse, error)
+	CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*CreateWebhookResponse, error)
+	DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookResponse, error)
+	ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error)
+	ListDeadWebhookDeliveries(ctx context.Context, in *ListDeadWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListDeadWebhookDeliveriesResponse, error)
+	ReplayWebhook(ctx context.Context, in *ReplayWebhookRequest, opts ...grpc.CallOption) (*ReplayWebhookResponse, error)
+	DeleteAllUserData(ctx context.Context, in *DeleteAllUserDataRequest, opts ...grpc.CallOption) (*DeleteAllUserDataResponse, error)
+	GetErasureStatus(ctx context.Context, in *GetErasureStatusRequest, opts ...grpc.CallOption) (*GetErasureStatusResponse, error)
+	ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (*ExportUserDataResponse, error)
+	ListDeletedPhotos(ctx context.Context, in *ListDeletedPhotosRequest, opts ...grpc.CallOption) (*ListDeletedPhotosResponse, error)
+	RestorePhoto(ctx context.Context, in *RestorePhotoRequest, opts ...grpc.CallOption) (*RestorePhotoResponse, error)
 }
 
 type fileStorageServiceClient struct {
@@ -71,6 +119,246 @@ func (c *fileStorageServiceClient) GetPhotoURL(ctx context.Context, in *GetPhoto
 	return out, nil
 }
 
+func (c *fileStorageServiceClient) GetPhotoURLs(ctx context.Context, in *GetPhotoURLsRequest, opts ...grpc.CallOption) (*GetPhotoURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPhotoURLsResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_GetPhotoURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) GetImageURL(ctx context.Context, in *GetImageURLRequest, opts ...grpc.CallOption) (*GetImageURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetImageURLResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_GetImageURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) GetDownloadURL(ctx context.Context, in *GetDownloadURLRequest, opts ...grpc.CallOption) (*GetDownloadURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDownloadURLResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_GetDownloadURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) DeletePhoto(ctx context.Context, in *DeletePhotoRequest, opts ...grpc.CallOption) (*DeletePhotoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePhotoResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_DeletePhoto_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) FindSimilarPhotos(ctx context.Context, in *FindSimilarPhotosRequest, opts ...grpc.CallOption) (*FindSimilarPhotosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindSimilarPhotosResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_FindSimilarPhotos_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) SetPhotoVisibility(ctx context.Context, in *SetPhotoVisibilityRequest, opts ...grpc.CallOption) (*SetPhotoVisibilityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetPhotoVisibilityResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_SetPhotoVisibility_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) CreateShareLink(ctx context.Context, in *CreateShareLinkRequest, opts ...grpc.CallOption) (*CreateShareLinkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateShareLinkResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_CreateShareLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) RevokeShareLink(ctx context.Context, in *RevokeShareLinkRequest, opts ...grpc.CallOption) (*RevokeShareLinkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeShareLinkResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_RevokeShareLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) UploadDocument(ctx context.Context, in *UploadDocumentRequest, opts ...grpc.CallOption) (*UploadDocumentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadDocumentResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_UploadDocument_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) GetDocumentURL(ctx context.Context, in *GetDocumentURLRequest, opts ...grpc.CallOption) (*GetDocumentURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDocumentURLResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_GetDocumentURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) DeleteDocument(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DeleteDocumentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteDocumentResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_DeleteDocument_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) ListPendingPhotos(ctx context.Context, in *ListPendingPhotosRequest, opts ...grpc.CallOption) (*ListPendingPhotosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPendingPhotosResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_ListPendingPhotos_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) ModeratePhoto(ctx context.Context, in *ModeratePhotoRequest, opts ...grpc.CallOption) (*ModeratePhotoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ModeratePhotoResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_ModeratePhoto_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) CreateUploadURL(ctx context.Context, in *CreateUploadURLRequest, opts ...grpc.CallOption) (*CreateUploadURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateUploadURLResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_CreateUploadURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*CreateWebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateWebhookResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_CreateWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteWebhookResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_DeleteWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhooksResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_ListWebhooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) ListDeadWebhookDeliveries(ctx context.Context, in *ListDeadWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListDeadWebhookDeliveriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeadWebhookDeliveriesResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_ListDeadWebhookDeliveries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) ReplayWebhook(ctx context.Context, in *ReplayWebhookRequest, opts ...grpc.CallOption) (*ReplayWebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplayWebhookResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_ReplayWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) DeleteAllUserData(ctx context.Context, in *DeleteAllUserDataRequest, opts ...grpc.CallOption) (*DeleteAllUserDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAllUserDataResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_DeleteAllUserData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) GetErasureStatus(ctx context.Context, in *GetErasureStatusRequest, opts ...grpc.CallOption) (*GetErasureStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetErasureStatusResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_GetErasureStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (*ExportUserDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportUserDataResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_ExportUserData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) ListDeletedPhotos(ctx context.Context, in *ListDeletedPhotosRequest, opts ...grpc.CallOption) (*ListDeletedPhotosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeletedPhotosResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_ListDeletedPhotos_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileStorageServiceClient) RestorePhoto(ctx context.Context, in *RestorePhotoRequest, opts ...grpc.CallOption) (*RestorePhotoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestorePhotoResponse)
+	err := c.cc.Invoke(ctx, FileStorageService_RestorePhoto_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FileStorageServiceServer is the server API for FileStorageService service.
 // All implementations must embed UnimplementedFileStorageServiceServer
 // for forward compatibility.
@@ -78,6 +366,30 @@ type FileStorageServiceServer interface {
 	UploadAvatar(context.Context, *UploadAvatarRequest) (*UploadAvatarResponse, error)
 	UploadPhotos(context.Context, *UploadPhotosRequest) (*UploadPhotosResponse, error)
 	GetPhotoURL(context.Context, *GetPhotoURLRequest) (*GetPhotoURLResponse, error)
+	GetPhotoURLs(context.Context, *GetPhotoURLsRequest) (*GetPhotoURLsResponse, error)
+	GetImageURL(context.Context, *GetImageURLRequest) (*GetImageURLResponse, error)
+	GetDownloadURL(context.Context, *GetDownloadURLRequest) (*GetDownloadURLResponse, error)
+	DeletePhoto(context.Context, *DeletePhotoRequest) (*DeletePhotoResponse, error)
+	FindSimilarPhotos(context.Context, *FindSimilarPhotosRequest) (*FindSimilarPhotosResponse, error)
+	SetPhotoVisibility(context.Context, *SetPhotoVisibilityRequest) (*SetPhotoVisibilityResponse, error)
+	CreateShareLink(context.Context, *CreateShareLinkRequest) (*CreateShareLinkResponse, error)
+	RevokeShareLink(context.Context, *RevokeShareLinkRequest) (*RevokeShareLinkResponse, error)
+	UploadDocument(context.Context, *UploadDocumentRequest) (*UploadDocumentResponse, error)
+	GetDocumentURL(context.Context, *GetDocumentURLRequest) (*GetDocumentURLResponse, error)
+	DeleteDocument(context.Context, *DeleteDocumentRequest) (*DeleteDocumentResponse, error)
+	ListPendingPhotos(context.Context, *ListPendingPhotosRequest) (*ListPendingPhotosResponse, error)
+	ModeratePhoto(context.Context, *ModeratePhotoRequest) (*ModeratePhotoResponse, error)
+	CreateUploadURL(context.Context, *CreateUploadURLRequest) (*CreateUploadURLResponse, error)
+	CreateWebhook(context.Context, *CreateWebhookRequest) (*CreateWebhookResponse, error)
+	DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookResponse, error)
+	ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error)
+	ListDeadWebhookDeliveries(context.Context, *ListDeadWebhookDeliveriesRequest) (*ListDeadWebhookDeliveriesResponse, error)
+	ReplayWebhook(context.Context, *ReplayWebhookRequest) (*ReplayWebhookResponse, error)
+	DeleteAllUserData(context.Context, *DeleteAllUserDataRequest) (*DeleteAllUserDataResponse, error)
+	GetErasureStatus(context.Context, *GetErasureStatusRequest) (*GetErasureStatusResponse, error)
+	ExportUserData(context.Context, *ExportUserDataRequest) (*ExportUserDataResponse, error)
+	ListDeletedPhotos(context.Context, *ListDeletedPhotosRequest) (*ListDeletedPhotosResponse, error)
+	RestorePhoto(context.Context, *RestorePhotoRequest) (*RestorePhotoResponse, error)
 	mustEmbedUnimplementedFileStorageServiceServer()
 }
 
@@ -97,6 +409,78 @@ func (UnimplementedFileStorageServiceServer) UploadPhotos(context.Context, *Uplo
 func (UnimplementedFileStorageServiceServer) GetPhotoURL(context.Context, *GetPhotoURLRequest) (*GetPhotoURLResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPhotoURL not implemented")
 }
+func (UnimplementedFileStorageServiceServer) GetPhotoURLs(context.Context, *GetPhotoURLsRequest) (*GetPhotoURLsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPhotoURLs not implemented")
+}
+func (UnimplementedFileStorageServiceServer) GetImageURL(context.Context, *GetImageURLRequest) (*GetImageURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetImageURL not implemented")
+}
+func (UnimplementedFileStorageServiceServer) GetDownloadURL(context.Context, *GetDownloadURLRequest) (*GetDownloadURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDownloadURL not implemented")
+}
+func (UnimplementedFileStorageServiceServer) DeletePhoto(context.Context, *DeletePhotoRequest) (*DeletePhotoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePhoto not implemented")
+}
+func (UnimplementedFileStorageServiceServer) FindSimilarPhotos(context.Context, *FindSimilarPhotosRequest) (*FindSimilarPhotosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindSimilarPhotos not implemented")
+}
+func (UnimplementedFileStorageServiceServer) SetPhotoVisibility(context.Context, *SetPhotoVisibilityRequest) (*SetPhotoVisibilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPhotoVisibility not implemented")
+}
+func (UnimplementedFileStorageServiceServer) CreateShareLink(context.Context, *CreateShareLinkRequest) (*CreateShareLinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateShareLink not implemented")
+}
+func (UnimplementedFileStorageServiceServer) RevokeShareLink(context.Context, *RevokeShareLinkRequest) (*RevokeShareLinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeShareLink not implemented")
+}
+func (UnimplementedFileStorageServiceServer) UploadDocument(context.Context, *UploadDocumentRequest) (*UploadDocumentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadDocument not implemented")
+}
+func (UnimplementedFileStorageServiceServer) GetDocumentURL(context.Context, *GetDocumentURLRequest) (*GetDocumentURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDocumentURL not implemented")
+}
+func (UnimplementedFileStorageServiceServer) DeleteDocument(context.Context, *DeleteDocumentRequest) (*DeleteDocumentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDocument not implemented")
+}
+func (UnimplementedFileStorageServiceServer) ListPendingPhotos(context.Context, *ListPendingPhotosRequest) (*ListPendingPhotosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPendingPhotos not implemented")
+}
+func (UnimplementedFileStorageServiceServer) ModeratePhoto(context.Context, *ModeratePhotoRequest) (*ModeratePhotoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ModeratePhoto not implemented")
+}
+func (UnimplementedFileStorageServiceServer) CreateUploadURL(context.Context, *CreateUploadURLRequest) (*CreateUploadURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUploadURL not implemented")
+}
+func (UnimplementedFileStorageServiceServer) CreateWebhook(context.Context, *CreateWebhookRequest) (*CreateWebhookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWebhook not implemented")
+}
+func (UnimplementedFileStorageServiceServer) DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWebhook not implemented")
+}
+func (UnimplementedFileStorageServiceServer) ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhooks not implemented")
+}
+func (UnimplementedFileStorageServiceServer) ListDeadWebhookDeliveries(context.Context, *ListDeadWebhookDeliveriesRequest) (*ListDeadWebhookDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeadWebhookDeliveries not implemented")
+}
+func (UnimplementedFileStorageServiceServer) ReplayWebhook(context.Context, *ReplayWebhookRequest) (*ReplayWebhookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplayWebhook not implemented")
+}
+func (UnimplementedFileStorageServiceServer) DeleteAllUserData(context.Context, *DeleteAllUserDataRequest) (*DeleteAllUserDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAllUserData not implemented")
+}
+func (UnimplementedFileStorageServiceServer) GetErasureStatus(context.Context, *GetErasureStatusRequest) (*GetErasureStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetErasureStatus not implemented")
+}
+func (UnimplementedFileStorageServiceServer) ExportUserData(context.Context, *ExportUserDataRequest) (*ExportUserDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportUserData not implemented")
+}
+func (UnimplementedFileStorageServiceServer) ListDeletedPhotos(context.Context, *ListDeletedPhotosRequest) (*ListDeletedPhotosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeletedPhotos not implemented")
+}
+func (UnimplementedFileStorageServiceServer) RestorePhoto(context.Context, *RestorePhotoRequest) (*RestorePhotoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestorePhoto not implemented")
+}
 func (UnimplementedFileStorageServiceServer) mustEmbedUnimplementedFileStorageServiceServer() {}
 func (UnimplementedFileStorageServiceServer) testEmbeddedByValue()                            {}
 
@@ -172,24 +556,552 @@ func _FileStorageService_GetPhotoURL_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
-// FileStorageService_ServiceDesc is the grpc.ServiceDesc for FileStorageService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var FileStorageService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "s3.v1.FileStorageService",
-	HandlerType: (*FileStorageServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "UploadAvatar",
-			Handler:    _FileStorageService_UploadAvatar_Handler,
-		},
-		{
-			MethodName: "UploadPhotos",
-			Handler:    _FileStorageService_UploadPhotos_Handler,
-		},
-		{
-			MethodName: "GetPhotoURL",
-			Handler:    _FileStorageService_GetPhotoURL_Handler,
+func _FileStorageService_GetPhotoURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPhotoURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).GetPhotoURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_GetPhotoURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).GetPhotoURLs(ctx, req.(*GetPhotoURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_GetImageURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetImageURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).GetImageURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_GetImageURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).GetImageURL(ctx, req.(*GetImageURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_GetDownloadURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDownloadURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).GetDownloadURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_GetDownloadURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).GetDownloadURL(ctx, req.(*GetDownloadURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_DeletePhoto_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePhotoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).DeletePhoto(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_DeletePhoto_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).DeletePhoto(ctx, req.(*DeletePhotoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_FindSimilarPhotos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindSimilarPhotosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).FindSimilarPhotos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_FindSimilarPhotos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).FindSimilarPhotos(ctx, req.(*FindSimilarPhotosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_SetPhotoVisibility_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPhotoVisibilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).SetPhotoVisibility(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_SetPhotoVisibility_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).SetPhotoVisibility(ctx, req.(*SetPhotoVisibilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_CreateShareLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateShareLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).CreateShareLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_CreateShareLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).CreateShareLink(ctx, req.(*CreateShareLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_RevokeShareLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeShareLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).RevokeShareLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_RevokeShareLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).RevokeShareLink(ctx, req.(*RevokeShareLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_UploadDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).UploadDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_UploadDocument_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).UploadDocument(ctx, req.(*UploadDocumentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_GetDocumentURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDocumentURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).GetDocumentURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_GetDocumentURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).GetDocumentURL(ctx, req.(*GetDocumentURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_DeleteDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).DeleteDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_DeleteDocument_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).DeleteDocument(ctx, req.(*DeleteDocumentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_ListPendingPhotos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingPhotosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).ListPendingPhotos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_ListPendingPhotos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).ListPendingPhotos(ctx, req.(*ListPendingPhotosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_ModeratePhoto_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModeratePhotoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).ModeratePhoto(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_ModeratePhoto_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).ModeratePhoto(ctx, req.(*ModeratePhotoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_CreateUploadURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUploadURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).CreateUploadURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_CreateUploadURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).CreateUploadURL(ctx, req.(*CreateUploadURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_CreateWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).CreateWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_CreateWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).CreateWebhook(ctx, req.(*CreateWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_DeleteWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).DeleteWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_DeleteWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).DeleteWebhook(ctx, req.(*DeleteWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_ListWebhooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).ListWebhooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_ListWebhooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).ListWebhooks(ctx, req.(*ListWebhooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_ListDeadWebhookDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadWebhookDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).ListDeadWebhookDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_ListDeadWebhookDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).ListDeadWebhookDeliveries(ctx, req.(*ListDeadWebhookDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_ReplayWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplayWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).ReplayWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_ReplayWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).ReplayWebhook(ctx, req.(*ReplayWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_DeleteAllUserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAllUserDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).DeleteAllUserData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_DeleteAllUserData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).DeleteAllUserData(ctx, req.(*DeleteAllUserDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_GetErasureStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetErasureStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).GetErasureStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_GetErasureStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).GetErasureStatus(ctx, req.(*GetErasureStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_ExportUserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportUserDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).ExportUserData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_ExportUserData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).ExportUserData(ctx, req.(*ExportUserDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_ListDeletedPhotos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeletedPhotosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).ListDeletedPhotos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_ListDeletedPhotos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).ListDeletedPhotos(ctx, req.(*ListDeletedPhotosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileStorageService_RestorePhoto_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestorePhotoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileStorageServiceServer).RestorePhoto(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileStorageService_RestorePhoto_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileStorageServiceServer).RestorePhoto(ctx, req.(*RestorePhotoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FileStorageService_ServiceDesc is the grpc.ServiceDesc for FileStorageService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FileStorageService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "s3.v1.FileStorageService",
+	HandlerType: (*FileStorageServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "UploadAvatar",
+			Handler:    _FileStorageService_UploadAvatar_Handler,
+		},
+		{
+			MethodName: "UploadPhotos",
+			Handler:    _FileStorageService_UploadPhotos_Handler,
+		},
+		{
+			MethodName: "GetPhotoURL",
+			Handler:    _FileStorageService_GetPhotoURL_Handler,
+		},
+		{
+			MethodName: "GetPhotoURLs",
+			Handler:    _FileStorageService_GetPhotoURLs_Handler,
+		},
+		{
+			MethodName: "GetImageURL",
+			Handler:    _FileStorageService_GetImageURL_Handler,
+		},
+		{
+			MethodName: "GetDownloadURL",
+			Handler:    _FileStorageService_GetDownloadURL_Handler,
+		},
+		{
+			MethodName: "DeletePhoto",
+			Handler:    _FileStorageService_DeletePhoto_Handler,
+		},
+		{
+			MethodName: "FindSimilarPhotos",
+			Handler:    _FileStorageService_FindSimilarPhotos_Handler,
+		},
+		{
+			MethodName: "SetPhotoVisibility",
+			Handler:    _FileStorageService_SetPhotoVisibility_Handler,
+		},
+		{
+			MethodName: "CreateShareLink",
+			Handler:    _FileStorageService_CreateShareLink_Handler,
+		},
+		{
+			MethodName: "RevokeShareLink",
+			Handler:    _FileStorageService_RevokeShareLink_Handler,
+		},
+		{
+			MethodName: "UploadDocument",
+			Handler:    _FileStorageService_UploadDocument_Handler,
+		},
+		{
+			MethodName: "GetDocumentURL",
+			Handler:    _FileStorageService_GetDocumentURL_Handler,
+		},
+		{
+			MethodName: "DeleteDocument",
+			Handler:    _FileStorageService_DeleteDocument_Handler,
+		},
+		{
+			MethodName: "ListPendingPhotos",
+			Handler:    _FileStorageService_ListPendingPhotos_Handler,
+		},
+		{
+			MethodName: "ModeratePhoto",
+			Handler:    _FileStorageService_ModeratePhoto_Handler,
+		},
+		{
+			MethodName: "CreateUploadURL",
+			Handler:    _FileStorageService_CreateUploadURL_Handler,
+		},
+		{
+			MethodName: "CreateWebhook",
+			Handler:    _FileStorageService_CreateWebhook_Handler,
+		},
+		{
+			MethodName: "DeleteWebhook",
+			Handler:    _FileStorageService_DeleteWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhooks",
+			Handler:    _FileStorageService_ListWebhooks_Handler,
+		},
+		{
+			MethodName: "ListDeadWebhookDeliveries",
+			Handler:    _FileStorageService_ListDeadWebhookDeliveries_Handler,
+		},
+		{
+			MethodName: "ReplayWebhook",
+			Handler:    _FileStorageService_ReplayWebhook_Handler,
+		},
+		{
+			MethodName: "DeleteAllUserData",
+			Handler:    _FileStorageService_DeleteAllUserData_Handler,
+		},
+		{
+			MethodName: "GetErasureStatus",
+			Handler:    _FileStorageService_GetErasureStatus_Handler,
+		},
+		{
+			MethodName: "ExportUserData",
+			Handler:    _FileStorageService_ExportUserData_Handler,
+		},
+		{
+			MethodName: "ListDeletedPhotos",
+			Handler:    _FileStorageService_ListDeletedPhotos_Handler,
+		},
+		{
+			MethodName: "RestorePhoto",
+			Handler:    _FileStorageService_RestorePhoto_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},